@@ -1,31 +1,238 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	_ "time/tzdata" // restaurant timezones must resolve even on minimal images
 
 	"quickbite/config"
 	"quickbite/db"
 	"quickbite/internal/handler"
+	"quickbite/internal/health"
+	"quickbite/internal/idempotency"
+	"quickbite/internal/jobs"
+	"quickbite/internal/lifecycle"
+	"quickbite/internal/logging"
+	"quickbite/internal/mail"
+	"quickbite/internal/metrics"
 	"quickbite/internal/middleware"
+	"quickbite/internal/model"
+	"quickbite/internal/payment"
+	"quickbite/internal/ratelimit"
+	"quickbite/internal/repository"
+	"quickbite/internal/service"
+	"quickbite/internal/tracing"
+	"quickbite/internal/validate"
+	"quickbite/internal/worker"
 )
 
 func main() {
 	cfg := config.Load()
+	logging.Setup(cfg.LogLevel, cfg.LogFormat)
 
-	db.Connect(cfg)
-	defer db.DB.Close()
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(cfg, os.Args[2:])
+		return
+	}
 
-	mux := handler.NewRouter(cfg)
+	if err := validate.Register(model.Requests...); err != nil {
+		fatal("invalid request validation tags", "error", err)
+	}
 
-	wrappedMux := middleware.CORS(cfg)(middleware.Logger(mux))
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.TraceExporter, cfg.TraceFile)
+	if err != nil {
+		fatal("tracing setup failed", "error", err)
+	}
 
-	addr := fmt.Sprintf(":%s", cfg.Port)
-	log.Printf("🚀 QuickBite API running on http://localhost%s", addr)
-	log.Printf("📦 Environment: %s", cfg.Environment)
+	pool, err := db.Connect(cfg)
+	if err != nil {
+		fatal("database connection failed", "error", err)
+	}
 
-	if err := http.ListenAndServe(addr, wrappedMux); err != nil {
-		log.Fatalf("❌ Server failed to start: %v", err)
+	if cfg.AutoMigrate {
+		if _, err := db.MigrateUp(context.Background(), pool); err != nil {
+			fatal("migrations failed", "error", err)
+		}
 	}
+
+	stores := repository.NewStores(pool)
+	life := lifecycle.New()
+
+	// The router and the background work share one set of services.
+	// Emails go out through the job queue.
+	jobStore := jobs.NewPostgresStore(pool)
+	services := service.NewServices(stores, metrics.OrderEvents{}, mail.NewQueue(jobStore), payment.LogRefunder{}, cfg)
+
+	// Background work. Dispatch runs every few seconds and keeps no state
+	// worth retrying, so it stays a plain worker; the rest goes through the
+	// job queue, which runs each cron slot once across instances and
+	// retries jobs that fail.
+	workers := worker.NewGroup()
+	workers.Go("dispatch", cfg.DispatchInterval, services.Dispatch.Dispatch)
+
+	jobPool := jobs.NewPool(jobStore, cfg.JobWorkers, cfg.JobPollInterval, cfg.JobLease)
+	cron(jobPool, "* * * * *", "menu.reset_stock", services.Menu.ResetDailyStock)
+	cron(jobPool, "0 * * * *", "restaurants.purge_deleted", func(ctx context.Context) error {
+		return services.Restaurants.PurgeDeleted(ctx, cfg.SoftDeleteRetention)
+	})
+	cron(jobPool, "15 * * * *", "auth.purge_login_failures", services.Auth.PurgeLoginFailures)
+	cron(jobPool, "@every 30s", "orders.reject_unanswered", services.Orders.RejectUnanswered)
+	cron(jobPool, "* * * * *", "payments.refund", services.Refunds.Refund)
+	cron(jobPool, "30 * * * *", "jobs.purge", func(ctx context.Context) error {
+		_, err := jobStore.PurgeFinished(ctx, cfg.JobRetention)
+		return err
+	})
+
+	var rateLimits ratelimit.Store
+	switch cfg.RateLimitBackend {
+	case "postgres":
+		pgLimits := ratelimit.NewPostgresStore(pool)
+		rateLimits = pgLimits
+		// A day is far longer than any limit's window, so purged buckets were full anyway
+		cron(jobPool, "45 * * * *", "rate_limits.purge", func(ctx context.Context) error {
+			_, err := pgLimits.PurgeStale(ctx, 24*time.Hour)
+			return err
+		})
+	case "memory":
+		rateLimits = ratelimit.NewMemoryStore()
+	default:
+		fatal("unknown RATE_LIMIT_BACKEND, want memory or postgres", "value", cfg.RateLimitBackend)
+	}
+
+	idempotencyKeys := idempotency.NewPostgresStore(pool)
+	cron(jobPool, "0 * * * *", "idempotency.purge", func(ctx context.Context) error {
+		_, err := idempotencyKeys.PurgeExpired(ctx)
+		return err
+	})
+
+	metrics.RegisterPool(pool)
+
+	checks := health.NewRegistry()
+	checks.Register("database", true, pool.Ping)
+	checks.Register("migrations", true, func(ctx context.Context) error {
+		return db.CheckMigrations(ctx, pool)
+	})
+	// Without SMTP, emails are only logged. A mail server outage shouldn't
+	// take the API out of rotation, so its check is informational. Requests
+	// queue their emails; the job pool sends them, retrying while the
+	// server is down.
+	var mailer mail.Mailer = mail.LogMailer{}
+	if cfg.SMTPHost != "" {
+		smtpMailer := mail.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+		checks.Register("mailer", false, smtpMailer.Ping)
+		mailer = smtpMailer
+	}
+	jobPool.Register(mail.SendKind, mail.SendJob(mailer))
+
+	for _, name := range workers.Names() {
+		checks.Register("worker:"+name, false, workers.HeartbeatCheck(name))
+	}
+	checks.Register("jobs", false, jobPool.Check)
+	jobPool.Start()
+
+	mux := handler.NewRouter(cfg, handler.Deps{
+		Services:    services,
+		Lifecycle:   life,
+		Health:      checks,
+		RateLimits:  rateLimits,
+		Idempotency: idempotencyKeys,
+		Jobs:        jobStore,
+		Metrics:     metrics.Handler(),
+	})
+
+	// Built inside out. RequestID must stay outermost: it creates the
+	// per-request info that Logger and Metrics read, and Route (which fills
+	// in the matched pattern) must wrap the mux directly.
+	var wrappedMux http.Handler = middleware.Route(mux)
+	wrappedMux = middleware.Timeout(cfg.RequestTimeout)(wrappedMux)
+	wrappedMux = middleware.CORS(cfg)(wrappedMux)
+	wrappedMux = middleware.Metrics(wrappedMux)
+	wrappedMux = middleware.Logger(wrappedMux)
+	wrappedMux = middleware.Tracing(wrappedMux)
+	wrappedMux = middleware.RequestID(wrappedMux)
+
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%s", cfg.Port),
+		Handler:           wrappedMux,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+
+	stop, cancelSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancelSignals()
+
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		fatal("server failed to start", "error", err)
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(ln)
+	}()
+
+	life.SetReady()
+	slog.Info("QuickBite API listening", "addr", srv.Addr, "environment", cfg.Environment)
+
+	select {
+	case err := <-serveErr:
+		fatal("server stopped", "error", err)
+	case <-stop.Done():
+	}
+
+	// A second signal skips the drain
+	cancelSignals()
+	slog.Info("shutting down", "drain_delay", cfg.DrainDelay.String(), "timeout", cfg.ShutdownTimeout.String())
+
+	// Fail /readyz but keep serving for DrainDelay: load balancers only
+	// stop routing here once they see it, and until then requests would
+	// hit a closed port
+	life.Drain()
+	time.Sleep(cfg.DrainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	// Order matters: stop taking traffic and let in-flight requests finish,
+	// then stop the workers, and only then close the pool everything above
+	// was using. No handler streams (there is no SSE or websocket endpoint),
+	// so every request ends on its own within the request timeout; one
+	// that streamed would need to end itself when draining starts.
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("HTTP shutdown incomplete", "error", err)
+	}
+	if err := workers.Stop(shutdownCtx); err != nil {
+		slog.Error("worker shutdown incomplete", "error", err)
+	}
+	if err := jobPool.Stop(shutdownCtx); err != nil {
+		slog.Error("job pool shutdown incomplete", "error", err)
+	}
+	pool.Close()
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("trace flush incomplete", "error", err)
+	}
+
+	slog.Info("shutdown complete")
+}
+
+// cron runs task on the pool's schedule spec, exiting if spec is invalid
+func cron(pool *jobs.Pool, spec, kind string, task func(ctx context.Context) error) {
+	if err := pool.Cron(spec, kind, jobs.Task(task)); err != nil {
+		fatal("invalid job schedule", "kind", kind, "error", err)
+	}
+}
+
+// fatal logs at error level and exits; deferred calls don't run
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
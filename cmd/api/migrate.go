@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"quickbite/config"
+	"quickbite/db"
+)
+
+const migrateUsage = `usage: quickbite migrate <command>
+
+commands:
+  up                 apply all pending migrations
+  down [steps]       roll back the latest migration (or the latest N)
+  status             list migrations and whether they are applied
+  baseline <version> mark migrations up to <version> as applied without running them
+                     (for databases that were migrated by hand)`
+
+func runMigrate(cfg *config.Config, args []string) {
+	if len(args) == 0 {
+		fmt.Println(migrateUsage)
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+
+	pool, err := db.Connect(cfg)
+	if err != nil {
+		fatal("database connection failed", "error", err)
+	}
+	defer pool.Close()
+
+	switch args[0] {
+	case "up":
+		count, err := db.MigrateUp(ctx, pool)
+		if err != nil {
+			fatal("migration failed", "error", err)
+		}
+		slog.Info("migrations applied", "count", count)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				fatal("invalid step count", "value", args[1])
+			}
+			steps = n
+		}
+		count, err := db.MigrateDown(ctx, pool, steps)
+		if err != nil {
+			fatal("rollback failed", "error", err)
+		}
+		slog.Info("migrations rolled back", "count", count)
+
+	case "status":
+		statuses, err := db.GetMigrationStatus(ctx, pool)
+		if err != nil {
+			fatal("failed to read migration status", "error", err)
+		}
+		printMigrationStatus(statuses)
+
+	case "baseline":
+		if len(args) < 2 {
+			fatal("baseline needs a version")
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			fatal("invalid version", "value", args[1])
+		}
+		count, err := db.BaselineMigrations(ctx, pool, version)
+		if err != nil {
+			fatal("baseline failed", "error", err)
+		}
+		slog.Info("migrations marked as applied", "count", count)
+
+	default:
+		fmt.Println(migrateUsage)
+		os.Exit(2)
+	}
+}
+
+func printMigrationStatus(statuses []db.MigrationStatus) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+
+	for _, s := range statuses {
+		state := "pending"
+		appliedAt := "-"
+		if s.Applied {
+			state = "applied"
+			appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		if s.Drifted {
+			state = "applied (modified since)"
+		}
+		if s.Missing {
+			state = "applied (unknown to this binary)"
+		}
+		fmt.Fprintf(w, "%03d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+	}
+
+	w.Flush()
+}
@@ -1,8 +1,10 @@
 package config
 
 import (
-	"log"
+	"log/slog"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -18,6 +20,74 @@ type Config struct {
 	Port        string
 	Environment string
 	FrontendURL string
+	AutoMigrate bool // apply pending migrations on startup
+
+	// How long soft-deleted restaurants and menu rows can be restored before they're purged
+	SoftDeleteRetention time.Duration
+
+	// QueryTimeout caps a single SQL statement (Postgres statement_timeout);
+	// RequestTimeout caps a whole HTTP request. Zero disables either.
+	QueryTimeout   time.Duration
+	RequestTimeout time.Duration
+
+	// HTTP server limits; ShutdownTimeout bounds how long in-flight requests
+	// get to finish after SIGINT/SIGTERM. DrainDelay is how long the server
+	// keeps taking new requests after /readyz starts failing, so load
+	// balancers have time to notice before it stops listening.
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration
+	DrainDelay        time.Duration
+
+	LogLevel  string // debug, info, warn or error
+	LogFormat string // json or text
+
+	// TraceExporter is none, stdout, file (writes TraceFile) or otlp
+	// (configured by the standard OTEL_EXPORTER_OTLP_* variables)
+	TraceExporter string
+	TraceFile     string
+
+	// RateLimitBackend is memory (per instance) or postgres (shared by all instances)
+	RateLimitBackend string
+	// TrustProxy takes client IPs from X-Forwarded-For; only enable behind a proxy that sets it
+	TrustProxy bool
+
+	// How long a response to a request with an Idempotency-Key is kept for retries
+	IdempotencyTTL time.Duration
+
+	// Outgoing email; with no SMTPHost, emails are logged instead of sent
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	MailFrom     string
+
+	// How long a staff invitation can be accepted
+	InvitationTTL time.Duration
+
+	// Delivery dispatch: how often ready orders are offered out, how long a
+	// partner has to answer an offer, how far (km) from the restaurant a
+	// partner may be, and how recent their last location ping must be
+	DispatchInterval   time.Duration
+	OfferTimeout       time.Duration
+	DispatchRadiusKm   int
+	PartnerLocationTTL time.Duration
+
+	// How long a restaurant has to accept a new order before it is rejected
+	// for them, and how long delivery adds to the prep estimate in the
+	// customer's ETA
+	OrderAcceptTimeout time.Duration
+	DeliveryEstimate   time.Duration
+
+	// Job queue: how many jobs an instance runs at once, how often idle
+	// workers look for more, how long one run may take before the job is
+	// handed to another worker, and how long finished jobs are kept
+	JobWorkers      int
+	JobPollInterval time.Duration
+	JobLease        time.Duration
+	JobRetention    time.Duration
 }
 
 func Load() *Config {
@@ -26,7 +96,7 @@ func Load() *Config {
 	if env != "production" {
 		err := godotenv.Load()
 		if err != nil {
-			log.Println("Warning: .env file not found, using environment variables")
+			slog.Warn(".env file not found, using environment variables")
 		}
 	}
 
@@ -43,8 +113,53 @@ func Load() *Config {
 		Port:        getEnv("PORT", "8080"),
 		Environment: getEnv("ENVIRONMENT", "development"),
 		FrontendURL: getEnv("FRONTEND_URL", "http://localhost:3000"),
+		AutoMigrate: getEnv("AUTO_MIGRATE", "false") == "true",
+
+		SoftDeleteRetention: time.Duration(getEnvInt("SOFT_DELETE_RETENTION_DAYS", 30)) * 24 * time.Hour,
+		QueryTimeout:        getEnvDuration("QUERY_TIMEOUT", 5*time.Second),
+		RequestTimeout:      getEnvDuration("REQUEST_TIMEOUT", 15*time.Second),
+
+		ReadTimeout:       getEnvDuration("HTTP_READ_TIMEOUT", 15*time.Second),
+		ReadHeaderTimeout: getEnvDuration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+		WriteTimeout:      getEnvDuration("HTTP_WRITE_TIMEOUT", 30*time.Second),
+		IdleTimeout:       getEnvDuration("HTTP_IDLE_TIMEOUT", 2*time.Minute),
+		ShutdownTimeout:   getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		DrainDelay:        getEnvDuration("SHUTDOWN_DRAIN_DELAY", 5*time.Second),
+
+		LogLevel:  getEnv("LOG_LEVEL", "info"),
+		LogFormat: getEnv("LOG_FORMAT", "json"),
+
+		TraceExporter: getEnv("TRACE_EXPORTER", "none"),
+		TraceFile:     getEnv("TRACE_FILE", "traces.jsonl"),
+
+		RateLimitBackend: getEnv("RATE_LIMIT_BACKEND", "memory"),
+		TrustProxy:       getEnv("TRUST_PROXY", "false") == "true",
+
+		IdempotencyTTL: getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		MailFrom:     getEnv("MAIL_FROM", "no-reply@quickbite.local"),
+
+		InvitationTTL: getEnvDuration("INVITATION_TTL", 7*24*time.Hour),
+
+		DispatchInterval:   getEnvDuration("DISPATCH_INTERVAL", 10*time.Second),
+		OfferTimeout:       getEnvDuration("DELIVERY_OFFER_TIMEOUT", 45*time.Second),
+		DispatchRadiusKm:   getEnvInt("DISPATCH_RADIUS_KM", 10),
+		PartnerLocationTTL: getEnvDuration("PARTNER_LOCATION_TTL", 2*time.Minute),
+
+		OrderAcceptTimeout: getEnvDuration("ORDER_ACCEPT_TIMEOUT", 10*time.Minute),
+		DeliveryEstimate:   getEnvDuration("DELIVERY_ESTIMATE", 30*time.Minute),
+
+		JobWorkers:      getEnvInt("JOB_WORKERS", 4),
+		JobPollInterval: getEnvDuration("JOB_POLL_INTERVAL", time.Second),
+		JobLease:        getEnvDuration("JOB_LEASE", 5*time.Minute),
+		JobRetention:    time.Duration(getEnvInt("JOB_RETENTION_DAYS", 7)) * 24 * time.Hour,
 	}
 }
+
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -52,3 +167,29 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		slog.Warn("invalid number in environment, using default", "key", key, "value", value, "default", defaultValue)
+		return defaultValue
+	}
+	return n
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		slog.Warn("invalid duration in environment, using default", "key", key, "value", value, "default", defaultValue.String())
+		return defaultValue
+	}
+	return d
+}
@@ -3,17 +3,17 @@ package db
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"strconv"
 
 	"quickbite/config"
+	"quickbite/internal/tracing"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// DB is the global connection pool
-var DB *pgxpool.Pool
-
-func Connect(cfg *config.Config) {
+// Connect opens the connection pool; the caller owns it and must Close it
+func Connect(cfg *config.Config) (*pgxpool.Pool, error) {
 
 	var connStr string
 
@@ -30,16 +30,31 @@ func Connect(cfg *config.Config) {
 			cfg.DBName,
 		)
 	}
-	pool, err := pgxpool.New(context.Background(), connStr)
+	poolConfig, err := pgxpool.ParseConfig(connStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database config: %w", err)
+	}
+
+	// Every session gets the statement timeout, so a runaway query is
+	// cancelled by Postgres even if the caller's context never expires
+	if cfg.QueryTimeout > 0 {
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.QueryTimeout.Milliseconds(), 10)
+	}
+
+	// One span per query, under whatever span the caller's context carries
+	poolConfig.ConnConfig.Tracer = tracing.QueryTracer{}
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
-		log.Fatalf("❌ Failed to connect to database: %v", err)
+		return nil, fmt.Errorf("connect to database: %w", err)
 	}
 
 	// Ping to verify connection is alive
 	if err := pool.Ping(context.Background()); err != nil {
-		log.Fatalf("❌ Database unreachable: %v", err)
+		pool.Close()
+		return nil, fmt.Errorf("database unreachable: %w", err)
 	}
 
-	DB = pool
-	log.Println("✅ Connected to PostgreSQL successfully")
+	slog.Info("connected to PostgreSQL")
+	return pool, nil
 }
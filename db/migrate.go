@@ -0,0 +1,398 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Every instance takes this advisory lock before touching the schema,
+// so parallel startups apply each migration exactly once
+const migrationLockID = 7_240_113
+
+// Migration is one numbered file pair from db/migrations:
+// NNN_name.sql runs on up, NNN_name.down.sql on down
+type Migration struct {
+	Version  int
+	Name     string
+	UpSQL    string
+	DownSQL  string
+	Checksum string // sha256 of UpSQL
+}
+
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+	Drifted   bool // file changed after it was applied
+	Missing   bool // applied in the database but unknown to this binary
+}
+
+type appliedMigration struct {
+	version   int
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// LoadMigrations reads the embedded migration files sorted by version
+func LoadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+
+	for _, entry := range entries {
+		file := entry.Name()
+		base, isDown := strings.CutSuffix(strings.TrimSuffix(file, ".sql"), ".down")
+
+		prefix, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: expected NNN_name.sql", file)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version %q", file, prefix)
+		}
+
+		content, err := migrationFiles.ReadFile(path.Join("migrations", file))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if m.Name != name {
+			return nil, fmt.Errorf("migration %03d has two names: %s and %s", version, m.Name, name)
+		}
+
+		if isDown {
+			m.DownSQL = string(content)
+		} else {
+			m.UpSQL = string(content)
+			sum := sha256.Sum256(content)
+			m.Checksum = hex.EncodeToString(sum[:])
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.UpSQL == "" {
+			return nil, fmt.Errorf("migration %03d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// MigrateUp applies every pending migration, each in its own transaction.
+// It refuses to run if an applied migration's file has changed since.
+func MigrateUp(ctx context.Context, pool *pgxpool.Pool) (int, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = withMigrationLock(ctx, pool, func(conn *pgxpool.Conn) error {
+		applied, err := loadApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := checkDrift(migrations, applied); err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+
+			err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, m.UpSQL); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx,
+					`INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+					m.Version, m.Name, m.Checksum,
+				)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %03d_%s: %w", m.Version, m.Name, err)
+			}
+
+			slog.Info("applied migration", "version", m.Version, "name", m.Name)
+			count++
+		}
+		return nil
+	})
+
+	return count, err
+}
+
+// MigrateDown rolls back the latest `steps` applied migrations
+func MigrateDown(ctx context.Context, pool *pgxpool.Pool, steps int) (int, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return 0, err
+	}
+
+	known := make(map[int]Migration)
+	for _, m := range migrations {
+		known[m.Version] = m
+	}
+
+	count := 0
+	err = withMigrationLock(ctx, pool, func(conn *pgxpool.Conn) error {
+		applied, err := loadApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := checkDrift(migrations, applied); err != nil {
+			return err
+		}
+
+		versions := make([]int, 0, len(applied))
+		for v := range applied {
+			versions = append(versions, v)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+		for _, version := range versions {
+			if count == steps {
+				break
+			}
+
+			m, ok := known[version]
+			if !ok {
+				return fmt.Errorf("migration %03d is applied but not known to this binary", version)
+			}
+			if m.DownSQL == "" {
+				return fmt.Errorf("migration %03d_%s has no down file", m.Version, m.Name)
+			}
+
+			err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, m.DownSQL); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("rollback %03d_%s: %w", m.Version, m.Name, err)
+			}
+
+			slog.Info("rolled back migration", "version", m.Version, "name", m.Name)
+			count++
+		}
+		return nil
+	})
+
+	return count, err
+}
+
+// BaselineMigrations records migrations up to version as applied without running them.
+// Meant for databases whose schema was created by hand before the runner existed.
+func BaselineMigrations(ctx context.Context, pool *pgxpool.Pool, version int) (int, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = withMigrationLock(ctx, pool, func(conn *pgxpool.Conn) error {
+		for _, m := range migrations {
+			if m.Version > version {
+				break
+			}
+			tag, err := conn.Exec(ctx, `
+				INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)
+				ON CONFLICT (version) DO NOTHING
+			`, m.Version, m.Name, m.Checksum)
+			if err != nil {
+				return err
+			}
+			count += int(tag.RowsAffected())
+		}
+		return nil
+	})
+
+	return count, err
+}
+
+// GetMigrationStatus lists embedded and applied migrations side by side
+func GetMigrationStatus(ctx context.Context, pool *pgxpool.Pool) ([]MigrationStatus, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	applied, err := loadApplied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	for _, m := range migrations {
+		status := MigrationStatus{Version: m.Version, Name: m.Name}
+		if a, ok := applied[m.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &a.appliedAt
+			status.Drifted = a.checksum != m.Checksum
+			delete(applied, m.Version)
+		}
+		statuses = append(statuses, status)
+	}
+
+	// Whatever is left was applied by a newer binary
+	for _, a := range applied {
+		appliedAt := a.appliedAt
+		statuses = append(statuses, MigrationStatus{
+			Version:   a.version,
+			Name:      a.name,
+			Applied:   true,
+			AppliedAt: &appliedAt,
+			Missing:   true,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+
+	return statuses, nil
+}
+
+func withMigrationLock(ctx context.Context, pool *pgxpool.Pool, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	// Waiting for the lock and running DDL can both outlast the per-query timeout
+	if _, err := conn.Exec(ctx, `SET statement_timeout = 0`); err != nil {
+		return err
+	}
+	defer conn.Exec(context.Background(), `RESET statement_timeout`)
+
+	// Session-level lock: held across the per-migration transactions
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func ensureMigrationsTable(ctx context.Context, conn *pgxpool.Conn) error {
+	_, err := conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INT PRIMARY KEY,
+			name       TEXT NOT NULL,
+			checksum   TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)
+	`)
+	return err
+}
+
+func loadApplied(ctx context.Context, conn *pgxpool.Conn) (map[int]appliedMigration, error) {
+	rows, err := conn.Query(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var a appliedMigration
+		if err := rows.Scan(&a.version, &a.name, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[a.version] = a
+	}
+
+	return applied, rows.Err()
+}
+
+// checkDrift fails when a migration file was edited after being applied
+func checkDrift(migrations []Migration, applied map[int]appliedMigration) error {
+	for _, m := range migrations {
+		a, ok := applied[m.Version]
+		if ok && a.checksum != m.Checksum {
+			return fmt.Errorf("migration %03d_%s was modified after it was applied (checksum mismatch)", m.Version, m.Name)
+		}
+	}
+	return nil
+}
+
+// CheckMigrations is the readiness check for the schema: it fails while
+// migrations this binary ships are still pending or an applied file has
+// drifted. A database ahead of the binary passes, so instances still on
+// the previous release keep serving during a rolling deploy.
+func CheckMigrations(ctx context.Context, pool *pgxpool.Pool) error {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return err
+	}
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	applied, err := loadApplied(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if err := checkDrift(migrations, applied); err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; !ok {
+			pending = append(pending, fmt.Sprintf("%03d_%s", m.Version, m.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("pending migrations: %s", strings.Join(pending, ", "))
+	}
+	return nil
+}
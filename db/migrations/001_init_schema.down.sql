@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS menu_items;
+DROP TABLE IF EXISTS menu_categories;
+DROP TABLE IF EXISTS restaurants;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_menu_items_daily_stock;
+
+ALTER TABLE restaurants DROP COLUMN IF EXISTS timezone;
+ALTER TABLE restaurants DROP COLUMN IF EXISTS opens_at;
+
+ALTER TABLE menu_items DROP CONSTRAINT IF EXISTS check_daily_stock_non_negative;
+ALTER TABLE menu_items DROP CONSTRAINT IF EXISTS check_stock_non_negative;
+ALTER TABLE menu_items DROP COLUMN IF EXISTS stock_reset_on;
+ALTER TABLE menu_items DROP COLUMN IF EXISTS daily_stock;
+ALTER TABLE menu_items DROP COLUMN IF EXISTS stock_quantity;
@@ -0,0 +1,13 @@
+-- DAILY STOCK TRACKING (NULL stock_quantity = not tracked)
+ALTER TABLE menu_items ADD COLUMN stock_quantity INT;
+ALTER TABLE menu_items ADD COLUMN daily_stock INT;        -- refilled every day at opening time
+ALTER TABLE menu_items ADD COLUMN stock_reset_on DATE;    -- restaurant-local date of the last refill
+
+ALTER TABLE menu_items ADD CONSTRAINT check_stock_non_negative CHECK (stock_quantity >= 0);
+ALTER TABLE menu_items ADD CONSTRAINT check_daily_stock_non_negative CHECK (daily_stock >= 0);
+
+-- OPENING TIME drives the daily stock refill
+ALTER TABLE restaurants ADD COLUMN opens_at TIME NOT NULL DEFAULT '09:00';
+ALTER TABLE restaurants ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'Asia/Kolkata';
+
+CREATE INDEX idx_menu_items_daily_stock ON menu_items(category_id) WHERE daily_stock IS NOT NULL;
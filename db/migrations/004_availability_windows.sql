@@ -0,0 +1,16 @@
+-- AVAILABILITY WINDOWS (breakfast / lunch / dinner menus)
+-- A category or item with no windows is always available.
+CREATE TABLE availability_windows (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    category_id  UUID REFERENCES menu_categories(id) ON DELETE CASCADE,
+    menu_item_id UUID REFERENCES menu_items(id) ON DELETE CASCADE,
+    days_of_week INT[] NOT NULL,        -- 0 = Sunday ... 6 = Saturday
+    start_time   TIME NOT NULL,         -- restaurant local time
+    end_time     TIME NOT NULL,         -- end before start wraps past midnight
+    created_at   TIMESTAMP DEFAULT NOW(),
+    CONSTRAINT check_window_owner CHECK ((category_id IS NULL) <> (menu_item_id IS NULL)),
+    CONSTRAINT check_window_length CHECK (start_time <> end_time)
+);
+
+CREATE INDEX idx_availability_windows_category ON availability_windows(category_id) WHERE category_id IS NOT NULL;
+CREATE INDEX idx_availability_windows_item ON availability_windows(menu_item_id) WHERE menu_item_id IS NOT NULL;
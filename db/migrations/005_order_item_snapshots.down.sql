@@ -0,0 +1,19 @@
+DROP INDEX IF EXISTS idx_menu_items_live;
+DROP INDEX IF EXISTS idx_menu_categories_live;
+
+-- Archived rows can't be represented before this migration
+DELETE FROM menu_items WHERE deleted_at IS NOT NULL AND id NOT IN (SELECT menu_item_id FROM order_items WHERE menu_item_id IS NOT NULL);
+ALTER TABLE menu_items DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE menu_categories DROP COLUMN IF EXISTS deleted_at;
+
+-- Lines whose dish was purged have nothing to point at any more
+DELETE FROM order_items WHERE menu_item_id IS NULL;
+ALTER TABLE order_items DROP CONSTRAINT order_items_menu_item_id_fkey;
+ALTER TABLE order_items ADD CONSTRAINT order_items_menu_item_id_fkey
+    FOREIGN KEY (menu_item_id) REFERENCES menu_items(id) ON DELETE RESTRICT;
+ALTER TABLE order_items ALTER COLUMN menu_item_id SET NOT NULL;
+
+ALTER TABLE order_items DROP COLUMN IF EXISTS options;
+ALTER TABLE order_items DROP COLUMN IF EXISTS is_veg;
+ALTER TABLE order_items DROP COLUMN IF EXISTS item_image;
+ALTER TABLE order_items DROP COLUMN IF EXISTS item_name;
@@ -0,0 +1,27 @@
+-- ORDER LINE SNAPSHOTS: receipts keep what was ordered even after the dish changes
+ALTER TABLE order_items ADD COLUMN item_name VARCHAR(150);
+ALTER TABLE order_items ADD COLUMN item_image TEXT NOT NULL DEFAULT '';
+ALTER TABLE order_items ADD COLUMN is_veg BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE order_items ADD COLUMN options JSONB NOT NULL DEFAULT '[]';  -- customer customisations
+
+UPDATE order_items oi
+SET item_name = mi.name,
+    item_image = COALESCE(mi.image_url, ''),
+    is_veg = COALESCE(mi.is_veg, FALSE)
+FROM menu_items mi
+WHERE oi.menu_item_id = mi.id;
+
+ALTER TABLE order_items ALTER COLUMN item_name SET NOT NULL;
+
+-- Order history no longer needs the live menu row
+ALTER TABLE order_items ALTER COLUMN menu_item_id DROP NOT NULL;
+ALTER TABLE order_items DROP CONSTRAINT order_items_menu_item_id_fkey;
+ALTER TABLE order_items ADD CONSTRAINT order_items_menu_item_id_fkey
+    FOREIGN KEY (menu_item_id) REFERENCES menu_items(id) ON DELETE SET NULL;
+
+-- ARCHIVING: deleting a dish or category only hides it
+ALTER TABLE menu_categories ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE menu_items ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX idx_menu_categories_live ON menu_categories(restaurant_id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_menu_items_live ON menu_items(category_id) WHERE deleted_at IS NULL;
@@ -0,0 +1,7 @@
+ALTER TABLE orders DROP CONSTRAINT orders_restaurant_id_fkey;
+ALTER TABLE orders ADD CONSTRAINT orders_restaurant_id_fkey
+    FOREIGN KEY (restaurant_id) REFERENCES restaurants(id) ON DELETE CASCADE;
+
+DROP INDEX IF EXISTS idx_restaurants_deleted;
+DROP INDEX IF EXISTS idx_restaurants_live;
+ALTER TABLE restaurants DROP COLUMN IF EXISTS deleted_at;
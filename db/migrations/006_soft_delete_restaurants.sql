@@ -0,0 +1,10 @@
+-- SOFT DELETE for restaurants (categories and items got deleted_at in 005)
+ALTER TABLE restaurants ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX idx_restaurants_live ON restaurants(owner_id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_restaurants_deleted ON restaurants(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Orders are revenue history: a restaurant that has orders is never hard-deleted
+ALTER TABLE orders DROP CONSTRAINT orders_restaurant_id_fkey;
+ALTER TABLE orders ADD CONSTRAINT orders_restaurant_id_fkey
+    FOREIGN KEY (restaurant_id) REFERENCES restaurants(id) ON DELETE RESTRICT;
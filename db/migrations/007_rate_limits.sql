@@ -0,0 +1,17 @@
+-- Token buckets shared by every API instance (RATE_LIMIT_BACKEND=postgres)
+CREATE TABLE rate_limit_buckets (
+    key        TEXT PRIMARY KEY,
+    tokens     DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_rate_limit_buckets_updated ON rate_limit_buckets(updated_at);
+
+-- Failed logins per email, for progressive lockout. Keyed by the address
+-- as typed (lower-cased), so unknown emails are throttled too.
+CREATE TABLE login_failures (
+    email          VARCHAR(255) PRIMARY KEY,
+    failures       INT NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    locked_until   TIMESTAMP
+);
@@ -0,0 +1,2 @@
+ALTER TABLE menu_items DROP COLUMN IF EXISTS version;
+ALTER TABLE restaurants DROP COLUMN IF EXISTS version;
@@ -0,0 +1,4 @@
+-- OPTIMISTIC CONCURRENCY: owner edits bump version, and writes carry the
+-- version they read (If-Match) so a stale edit fails instead of overwriting
+ALTER TABLE restaurants ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE menu_items ADD COLUMN version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,15 @@
+-- Responses to requests sent with an Idempotency-Key, replayed to retries.
+-- status is NULL while the first request is still running.
+CREATE TABLE idempotency_keys (
+    user_id     UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key         VARCHAR(255) NOT NULL,
+    fingerprint TEXT NOT NULL,
+    status      INT,
+    headers     JSONB,
+    body        BYTEA,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at  TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX idx_idempotency_keys_expires ON idempotency_keys(expires_at);
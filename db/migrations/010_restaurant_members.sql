@@ -0,0 +1,32 @@
+-- RESTAURANT STAFF: who works at which restaurant, and as what.
+-- restaurants.owner_id stays as the account that created it; permissions
+-- come from these rows, where that account is the 'owner' member.
+CREATE TABLE restaurant_members (
+    restaurant_id UUID NOT NULL REFERENCES restaurants(id) ON DELETE CASCADE,
+    user_id       UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role          VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'manager', 'kitchen', 'viewer')),
+    created_at    TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (restaurant_id, user_id)
+);
+
+CREATE INDEX idx_restaurant_members_user ON restaurant_members(user_id);
+
+INSERT INTO restaurant_members (restaurant_id, user_id, role, created_at)
+SELECT id, owner_id, 'owner', created_at FROM restaurants;
+
+-- Invitations by email. Only a hash of the token is kept; the token itself
+-- goes out in the email. One pending invitation per restaurant and email.
+CREATE TABLE restaurant_invitations (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    restaurant_id UUID NOT NULL REFERENCES restaurants(id) ON DELETE CASCADE,
+    email         VARCHAR(150) NOT NULL,
+    role          VARCHAR(20) NOT NULL CHECK (role IN ('manager', 'kitchen', 'viewer')),
+    token_hash    TEXT NOT NULL UNIQUE,
+    invited_by    UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at    TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at    TIMESTAMP NOT NULL,
+    accepted_at   TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_restaurant_invitations_pending
+    ON restaurant_invitations(restaurant_id, email) WHERE accepted_at IS NULL;
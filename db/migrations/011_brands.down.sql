@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS outlet_item_overrides;
+DROP INDEX IF EXISTS idx_menu_items_brand;
+DROP INDEX IF EXISTS idx_menu_categories_brand;
+ALTER TABLE menu_items DROP COLUMN IF EXISTS brand_item_id;
+ALTER TABLE menu_categories DROP COLUMN IF EXISTS brand_category_id;
+DROP TABLE IF EXISTS brand_menu_items;
+DROP TABLE IF EXISTS brand_categories;
+ALTER TABLE restaurants DROP COLUMN IF EXISTS brand_id;
+DROP TABLE IF EXISTS brands;
@@ -0,0 +1,64 @@
+-- BRANDS: a chain that runs several restaurants (outlets) off one master
+-- menu. Outlets get linked copies of the master categories and items in
+-- their own menu_categories/menu_items, so ordering, stock and schedules
+-- work unchanged; a sync rewrites the copies whenever the master changes.
+CREATE TABLE brands (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    owner_id    UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name        VARCHAR(150) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    logo_url    TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    version     INT NOT NULL DEFAULT 1
+);
+
+CREATE INDEX idx_brands_owner ON brands(owner_id);
+
+ALTER TABLE restaurants ADD COLUMN brand_id UUID REFERENCES brands(id) ON DELETE SET NULL;
+CREATE INDEX idx_restaurants_brand ON restaurants(brand_id) WHERE brand_id IS NOT NULL;
+
+CREATE TABLE brand_categories (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    brand_id      UUID NOT NULL REFERENCES brands(id) ON DELETE CASCADE,
+    name          VARCHAR(100) NOT NULL,
+    display_order INT NOT NULL DEFAULT 0,
+    created_at    TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_brand_categories_brand ON brand_categories(brand_id);
+
+CREATE TABLE brand_menu_items (
+    id                UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    brand_category_id UUID NOT NULL REFERENCES brand_categories(id) ON DELETE CASCADE,
+    name              VARCHAR(150) NOT NULL,
+    description       TEXT NOT NULL DEFAULT '',
+    price             DECIMAL(10, 2) NOT NULL CHECK (price > 0),
+    image_url         TEXT NOT NULL DEFAULT '',
+    is_available      BOOLEAN NOT NULL DEFAULT TRUE,
+    is_veg            BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at        TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMP NOT NULL DEFAULT NOW(),
+    version           INT NOT NULL DEFAULT 1
+);
+
+CREATE INDEX idx_brand_menu_items_category ON brand_menu_items(brand_category_id);
+
+-- Links from an outlet's copy back to the master row. A NULL link is a
+-- local category or item the outlet added itself.
+ALTER TABLE menu_categories ADD COLUMN brand_category_id UUID REFERENCES brand_categories(id) ON DELETE SET NULL;
+ALTER TABLE menu_items ADD COLUMN brand_item_id UUID REFERENCES brand_menu_items(id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX idx_menu_categories_brand
+    ON menu_categories(restaurant_id, brand_category_id) WHERE brand_category_id IS NOT NULL;
+CREATE INDEX idx_menu_items_brand ON menu_items(brand_item_id) WHERE brand_item_id IS NOT NULL;
+
+-- Per-outlet changes to a master item; NULL keeps the brand's value
+CREATE TABLE outlet_item_overrides (
+    restaurant_id UUID NOT NULL REFERENCES restaurants(id) ON DELETE CASCADE,
+    brand_item_id UUID NOT NULL REFERENCES brand_menu_items(id) ON DELETE CASCADE,
+    price         DECIMAL(10, 2) CHECK (price > 0),
+    is_available  BOOLEAN,
+    updated_at    TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (restaurant_id, brand_item_id)
+);
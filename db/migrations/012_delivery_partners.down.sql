@@ -0,0 +1,5 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS delivery_code;
+DROP TABLE IF EXISTS delivery_assignments;
+ALTER TABLE restaurants DROP COLUMN IF EXISTS longitude;
+ALTER TABLE restaurants DROP COLUMN IF EXISTS latitude;
+DROP TABLE IF EXISTS delivery_partners;
@@ -0,0 +1,47 @@
+-- DELIVERY PARTNERS: users with the delivery_partner role. The row is made
+-- the first time a partner goes online or sends their location.
+CREATE TABLE delivery_partners (
+    user_id             UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    is_online           BOOLEAN NOT NULL DEFAULT FALSE,
+    latitude            DOUBLE PRECISION,
+    longitude           DOUBLE PRECISION,
+    location_updated_at TIMESTAMP,
+    updated_at          TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_delivery_partners_online ON delivery_partners(is_online) WHERE is_online;
+
+-- Where the restaurant is, for finding the nearest partner. Orders from
+-- restaurants without one are never offered out.
+ALTER TABLE restaurants ADD COLUMN latitude DOUBLE PRECISION;
+ALTER TABLE restaurants ADD COLUMN longitude DOUBLE PRECISION;
+
+-- DELIVERY ASSIGNMENTS: an order offered to a partner, who accepts or
+-- rejects it before expires_at. Accepted ones are the partner's delivery.
+-- An order and a partner each have at most one open (offered or accepted)
+-- assignment. 'released' closes one whose order was cancelled or finished
+-- without the partner.
+CREATE TABLE delivery_assignments (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id      UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    partner_id    UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status        VARCHAR(20) NOT NULL DEFAULT 'offered'
+                  CHECK (status IN ('offered', 'accepted', 'rejected', 'expired', 'delivered', 'released')),
+    distance_km   DOUBLE PRECISION NOT NULL,       -- partner to restaurant when offered
+    offered_at    TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at    TIMESTAMP NOT NULL,
+    responded_at  TIMESTAMP,
+    picked_up_at  TIMESTAMP,
+    delivered_at  TIMESTAMP
+);
+
+CREATE INDEX idx_delivery_assignments_order ON delivery_assignments(order_id);
+CREATE INDEX idx_delivery_assignments_partner ON delivery_assignments(partner_id);
+CREATE UNIQUE INDEX idx_delivery_assignments_open_order
+    ON delivery_assignments(order_id) WHERE status IN ('offered', 'accepted');
+CREATE UNIQUE INDEX idx_delivery_assignments_open_partner
+    ON delivery_assignments(partner_id) WHERE status IN ('offered', 'accepted');
+
+-- Proof of delivery: set when a partner accepts the order and shown only to
+-- the customer, who gives it to the partner at the door
+ALTER TABLE orders ADD COLUMN delivery_code VARCHAR(4);
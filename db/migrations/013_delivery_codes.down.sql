@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS delivery_code_events;
+ALTER TABLE orders DROP COLUMN IF EXISTS delivery_code_attempts;
@@ -0,0 +1,24 @@
+-- DELIVERY CODES: set when an order is confirmed and shown only to the
+-- customer, who gives it at the door. Whoever marks the order delivered,
+-- restaurant staff or the delivery partner, has to enter it.
+-- delivery_code_attempts counts entries against a limit; the customer
+-- can issue a new code, which starts the count over.
+ALTER TABLE orders ADD COLUMN delivery_code_attempts INT NOT NULL DEFAULT 0;
+
+-- Codes used to be set only when a partner accepted the delivery; give
+-- every other order in flight one so it can still be delivered
+UPDATE orders
+SET delivery_code = LPAD(FLOOR(RANDOM() * 10000)::int::text, 4, '0')
+WHERE delivery_code IS NULL
+  AND status IN ('confirmed', 'preparing', 'ready', 'out_for_delivery');
+
+-- Audit trail: every code issued and every attempt to enter one
+CREATE TABLE delivery_code_events (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id   UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    user_id    UUID REFERENCES users(id) ON DELETE SET NULL,
+    action     VARCHAR(20) NOT NULL CHECK (action IN ('issued', 'reissued', 'verified', 'failed')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_delivery_code_events_order ON delivery_code_events(order_id, created_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_orders_restaurant_active;
+DROP TABLE IF EXISTS kitchen_settings;
+ALTER TABLE order_items DROP COLUMN IF EXISTS prepared_at;
@@ -0,0 +1,13 @@
+-- KITCHEN DISPLAY: which items of an order the kitchen has finished, and
+-- how long a restaurant aims to take from order to ready
+ALTER TABLE order_items ADD COLUMN prepared_at TIMESTAMP;
+
+CREATE TABLE kitchen_settings (
+    restaurant_id       UUID PRIMARY KEY REFERENCES restaurants(id) ON DELETE CASCADE,
+    prep_target_minutes INT NOT NULL CHECK (prep_target_minutes > 0),
+    updated_at          TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- The board lists a restaurant's orders in the kitchen's statuses
+CREATE INDEX idx_orders_restaurant_active ON orders(restaurant_id, created_at)
+    WHERE status IN ('confirmed', 'preparing', 'ready');
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_orders_refund_pending;
+DROP INDEX IF EXISTS idx_orders_pending;
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS rejection_reason,
+    DROP COLUMN IF EXISTS prep_minutes,
+    DROP COLUMN IF EXISTS accepted_at;
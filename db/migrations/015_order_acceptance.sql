@@ -0,0 +1,15 @@
+-- ORDER ACCEPTANCE: restaurants accept a pending order with an estimate of
+-- its prep time or reject it with a reason. Orders nobody answers are
+-- rejected automatically, and paid orders that end up cancelled wait in
+-- payment_status 'refund_pending' until the refund goes through.
+ALTER TABLE orders
+    ADD COLUMN accepted_at      TIMESTAMP,
+    ADD COLUMN prep_minutes     INT CHECK (prep_minutes > 0),
+    ADD COLUMN rejection_reason VARCHAR(30);
+
+-- Orders confirmed before this count as accepted then, with no estimate
+UPDATE orders SET accepted_at = updated_at
+WHERE status NOT IN ('pending', 'cancelled');
+
+CREATE INDEX idx_orders_pending ON orders(created_at) WHERE status = 'pending';
+CREATE INDEX idx_orders_refund_pending ON orders(updated_at) WHERE payment_status = 'refund_pending';
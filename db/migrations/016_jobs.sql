@@ -0,0 +1,25 @@
+-- JOB QUEUE: background work, claimed by workers with FOR UPDATE SKIP
+-- LOCKED. A running job whose lease (locked_until) runs out is picked up
+-- again; one out of attempts is parked as dead until an admin retries it.
+-- unique_key stops a cron slot being enqueued twice by different instances.
+CREATE TABLE jobs (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind         VARCHAR(100) NOT NULL,
+    payload      JSONB NOT NULL DEFAULT '{}',
+    status       VARCHAR(20) NOT NULL DEFAULT 'queued'
+                 CHECK (status IN ('queued', 'running', 'done', 'dead')),
+    attempts     INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL CHECK (max_attempts > 0),
+    last_error   TEXT,
+    unique_key   VARCHAR(255) UNIQUE,
+    run_at       TIMESTAMP NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMP,
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    finished_at  TIMESTAMP
+);
+
+CREATE INDEX idx_jobs_queued ON jobs(run_at) WHERE status = 'queued';
+CREATE INDEX idx_jobs_running ON jobs(locked_until) WHERE status = 'running';
+CREATE INDEX idx_jobs_finished ON jobs(finished_at) WHERE status IN ('done', 'dead');
+CREATE INDEX idx_jobs_status_kind ON jobs(status, kind, created_at);
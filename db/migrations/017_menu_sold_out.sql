@@ -0,0 +1,8 @@
+-- SOLD OUT: set when running out of stock switches an item off, so a cancel
+-- or the daily refill only switches back on what the stock switched off.
+-- Items the owner turns off by hand stay off.
+ALTER TABLE menu_items ADD COLUMN sold_out BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Until now an item off with no stock left was taken to have sold out
+UPDATE menu_items SET sold_out = TRUE
+WHERE stock_quantity = 0 AND NOT is_available;
@@ -0,0 +1,2 @@
+-- Logins and invitations look users up by email regardless of case
+CREATE INDEX idx_users_email_lower ON users(LOWER(email));
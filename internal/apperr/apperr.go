@@ -0,0 +1,140 @@
+// Package apperr holds the domain errors services return. Each carries a
+// Kind the HTTP layer maps to a status code and a message that is safe to
+// show the client; the underlying cause, if any, is only ever logged.
+package apperr
+
+import (
+	"errors"
+	"time"
+)
+
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindTooManyRequests
+	KindTooLarge
+	KindPreconditionFailed
+	KindPreconditionRequired
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindValidation:
+		return "validation"
+	case KindUnauthorized:
+		return "unauthorized"
+	case KindForbidden:
+		return "forbidden"
+	case KindNotFound:
+		return "not_found"
+	case KindConflict:
+		return "conflict"
+	case KindTooManyRequests:
+		return "too_many_requests"
+	case KindTooLarge:
+		return "too_large"
+	case KindPreconditionFailed:
+		return "precondition_failed"
+	case KindPreconditionRequired:
+		return "precondition_required"
+	default:
+		return "internal"
+	}
+}
+
+// FieldError points at one invalid input field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type Error struct {
+	Kind       Kind
+	Message    string
+	Fields     []FieldError  // validation only
+	RetryAfter time.Duration // too many requests only
+	Err        error         // cause; never sent to the client
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func NotFound(message string) *Error {
+	return &Error{Kind: KindNotFound, Message: message}
+}
+
+func Forbidden(message string) *Error {
+	return &Error{Kind: KindForbidden, Message: message}
+}
+
+func Unauthorized(message string) *Error {
+	return &Error{Kind: KindUnauthorized, Message: message}
+}
+
+func Conflict(message string) *Error {
+	return &Error{Kind: KindConflict, Message: message}
+}
+
+// Validation rejects the input as a whole; fields say which parts were wrong
+func Validation(message string, fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Message: message, Fields: fields}
+}
+
+// InvalidField is Validation for a single field, e.g.
+// InvalidField("restaurant_id", "is required") reads "restaurant_id is required"
+func InvalidField(field, message string) *Error {
+	return Validation(field+" "+message, FieldError{Field: field, Message: message})
+}
+
+func TooManyRequests(message string, retryAfter time.Duration) *Error {
+	return &Error{Kind: KindTooManyRequests, Message: message, RetryAfter: retryAfter}
+}
+
+func TooLarge(message string) *Error {
+	return &Error{Kind: KindTooLarge, Message: message}
+}
+
+// PreconditionFailed means the caller's If-Match no longer describes the resource
+func PreconditionFailed(message string) *Error {
+	return &Error{Kind: KindPreconditionFailed, Message: message}
+}
+
+// PreconditionRequired means a write came without the If-Match it needs
+func PreconditionRequired(message string) *Error {
+	return &Error{Kind: KindPreconditionRequired, Message: message}
+}
+
+// Internal wraps a failure the client can't do anything about. message is
+// what they see; cause is kept for the logs.
+func Internal(message string, cause error) *Error {
+	return &Error{Kind: KindInternal, Message: message, Err: cause}
+}
+
+// As finds the first *Error in err's chain
+func As(err error) (*Error, bool) {
+	var e *Error
+	ok := errors.As(err, &e)
+	return e, ok
+}
+
+// KindOf reports err's kind; anything that isn't an *Error is internal
+func KindOf(err error) Kind {
+	if e, ok := As(err); ok {
+		return e.Kind
+	}
+	return KindInternal
+}
@@ -0,0 +1,81 @@
+// Package authz defines the roles a user can hold at a restaurant and what
+// each role is allowed to do there. service.Authorizer applies it.
+package authz
+
+// Role is a user's role at one restaurant; the account-wide role on the
+// user (customer, restaurant_owner) is separate
+type Role string
+
+const (
+	RoleOwner   Role = "owner"
+	RoleManager Role = "manager"
+	RoleKitchen Role = "kitchen"
+	RoleViewer  Role = "viewer"
+)
+
+type Permission string
+
+const (
+	EditRestaurant    Permission = "edit_restaurant"
+	DeleteRestaurant  Permission = "delete_restaurant"
+	EditMenu          Permission = "edit_menu"
+	ViewOrders        Permission = "view_orders"
+	UpdateOrderStatus Permission = "update_order_status"
+	ViewRevenue       Permission = "view_revenue"
+	ManageMembers     Permission = "manage_members"
+	ManageBrand       Permission = "manage_brand"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleOwner: {
+		EditRestaurant, DeleteRestaurant, EditMenu, ViewOrders,
+		UpdateOrderStatus, ViewRevenue, ManageMembers, ManageBrand,
+	},
+	RoleManager: {EditRestaurant, EditMenu, ViewOrders, UpdateOrderStatus, ViewRevenue},
+	RoleKitchen: {ViewOrders, UpdateOrderStatus},
+	RoleViewer:  {ViewOrders},
+}
+
+// Can reports whether the role grants p
+func (r Role) Can(p Permission) bool {
+	for _, granted := range rolePermissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Valid reports whether r is one of the known roles
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Permissions lists what the role grants, for showing to the client
+func (r Role) Permissions() []Permission {
+	return append([]Permission(nil), rolePermissions[r]...)
+}
+
+// Describe is the action behind p, worded to follow "you can't"
+func (p Permission) Describe() string {
+	switch p {
+	case EditRestaurant:
+		return "edit this restaurant"
+	case DeleteRestaurant:
+		return "delete this restaurant"
+	case EditMenu:
+		return "edit this restaurant's menu"
+	case ViewOrders:
+		return "view this restaurant's orders"
+	case UpdateOrderStatus:
+		return "update this restaurant's orders"
+	case ViewRevenue:
+		return "view this restaurant's revenue"
+	case ManageMembers:
+		return "manage this restaurant's staff"
+	case ManageBrand:
+		return "add this restaurant to a brand or take it out"
+	}
+	return string(p)
+}
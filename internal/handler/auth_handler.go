@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"quickbite/config"
@@ -11,34 +10,25 @@ import (
 )
 
 type AuthHandler struct {
-	cfg *config.Config
+	cfg  *config.Config
+	auth *service.AuthService
 }
 
-func NewAuthHandler(cfg *config.Config) *AuthHandler {
-	return &AuthHandler{cfg: cfg}
+func NewAuthHandler(cfg *config.Config, auth *service.AuthService) *AuthHandler {
+	return &AuthHandler{cfg: cfg, auth: auth}
 }
 
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var req model.RegisterRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, "invalid request body")
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	if req.Name == "" || req.Email == "" || req.Password == "" {
-		utils.WriteError(w, http.StatusBadRequest, "name, email and password are required")
-		return
-	}
-
-	if len(req.Password) < 6 {
-		utils.WriteError(w, http.StatusBadRequest, "password must be at least 6 characters")
-		return
-	}
-
-	resp, err := service.Register(&req, h.cfg)
+	resp, err := h.auth.Register(r.Context(), &req)
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		writeError(w, r, err)
 		return
 	}
 
@@ -48,19 +38,14 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req model.LoginRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, "invalid request body")
-		return
-	}
-
-	if req.Email == "" || req.Password == "" {
-		utils.WriteError(w, http.StatusBadRequest, "email and password are required")
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	resp, err := service.Login(&req, h.cfg)
+	resp, err := h.auth.Login(r.Context(), &req)
 	if err != nil {
-		utils.WriteError(w, http.StatusUnauthorized, err.Error())
+		writeError(w, r, err)
 		return
 	}
 
@@ -0,0 +1,488 @@
+package handler
+
+import (
+	"net/http"
+
+	"quickbite/config"
+	"quickbite/internal/middleware"
+	"quickbite/internal/model"
+	"quickbite/internal/service"
+	"quickbite/internal/utils"
+)
+
+type BrandHandler struct {
+	cfg    *config.Config
+	brands *service.BrandService
+}
+
+func NewBrandHandler(cfg *config.Config, brands *service.BrandService) *BrandHandler {
+	return &BrandHandler{cfg: cfg, brands: brands}
+}
+
+// CreateBrand handles POST /api/brands
+func (h *BrandHandler) CreateBrand(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req model.CreateBrandRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	brand, err := h.brands.CreateBrand(r.Context(), &req, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, brand.Version)
+	utils.WriteJSON(w, http.StatusCreated, brand)
+}
+
+// GetBrandByID handles GET /api/brands/{id}
+func (h *BrandHandler) GetBrandByID(w http.ResponseWriter, r *http.Request) {
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	brand, err := h.brands.GetBrandByID(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, brand)
+}
+
+// GetMyBrands handles GET /api/brands/my/list
+func (h *BrandHandler) GetMyBrands(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	brands, err := h.brands.GetMyBrands(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, brands)
+}
+
+// UpdateBrand handles PUT /api/brands/{id}
+func (h *BrandHandler) UpdateBrand(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	version, err := ifMatch(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req model.UpdateBrandRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	brand, err := h.brands.UpdateBrand(r.Context(), id, version, &req, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, brand.Version)
+	utils.WriteJSON(w, http.StatusOK, brand)
+}
+
+// SyncBrand handles POST /api/brands/{id}/sync
+func (h *BrandHandler) SyncBrand(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.brands.SyncBrand(r.Context(), id, userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "outlet menus updated successfully"})
+}
+
+// GetDashboard handles GET /api/brands/{id}/dashboard?from=&to=, with the
+// same from and to as the restaurant revenue endpoint
+func (h *BrandHandler) GetDashboard(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	from, err := parseTimeParam(r, "from")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	to, err := parseTimeParam(r, "to")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	dashboard, err := h.brands.GetDashboard(r.Context(), id, from, to, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, dashboard)
+}
+
+// ====== OUTLETS ======
+
+// AttachOutlet handles POST /api/brands/{id}/outlets
+func (h *BrandHandler) AttachOutlet(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req model.AttachOutletRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	restaurant, err := h.brands.AttachOutlet(r.Context(), id, &req, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, restaurant)
+}
+
+// DetachOutlet handles DELETE /api/brands/{id}/outlets/{restaurant_id}
+func (h *BrandHandler) DetachOutlet(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	restaurantID, err := pathUUID(r, "restaurant_id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.brands.DetachOutlet(r.Context(), id, restaurantID, userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "outlet removed successfully"})
+}
+
+// ====== MASTER MENU ======
+
+// GetBrandMenu handles GET /api/brands/{id}/menu
+func (h *BrandHandler) GetBrandMenu(w http.ResponseWriter, r *http.Request) {
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	menu, err := h.brands.GetBrandMenu(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, menu)
+}
+
+// CreateCategory handles POST /api/brands/{id}/categories
+func (h *BrandHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req model.CreateBrandCategoryRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	category, err := h.brands.CreateCategory(r.Context(), id, &req, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, category)
+}
+
+// DeleteCategory handles DELETE /api/brands/{id}/categories/{category_id}
+func (h *BrandHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	categoryID, err := pathUUID(r, "category_id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.brands.DeleteCategory(r.Context(), id, categoryID, userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "category deleted successfully"})
+}
+
+// CreateMenuItem handles POST /api/brands/{id}/items
+func (h *BrandHandler) CreateMenuItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req model.CreateBrandMenuItemRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	item, err := h.brands.CreateMenuItem(r.Context(), id, &req, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, item.Version)
+	utils.WriteJSON(w, http.StatusCreated, item)
+}
+
+// UpdateMenuItem handles PUT /api/brands/{id}/items/{item_id}
+func (h *BrandHandler) UpdateMenuItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	itemID, err := pathUUID(r, "item_id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	version, err := ifMatch(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req model.UpdateBrandMenuItemRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	item, err := h.brands.UpdateMenuItem(r.Context(), id, itemID, version, &req, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, item.Version)
+	utils.WriteJSON(w, http.StatusOK, item)
+}
+
+// DeleteMenuItem handles DELETE /api/brands/{id}/items/{item_id}
+func (h *BrandHandler) DeleteMenuItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	itemID, err := pathUUID(r, "item_id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.brands.DeleteMenuItem(r.Context(), id, itemID, userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "menu item deleted successfully"})
+}
+
+// ====== OVERRIDES ======
+
+// GetOverrides handles GET /api/restaurants/{id}/overrides
+func (h *BrandHandler) GetOverrides(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	overrides, err := h.brands.GetOverrides(r.Context(), id, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, overrides)
+}
+
+// SetOverride handles PUT /api/restaurants/{id}/overrides/{brand_item_id}
+func (h *BrandHandler) SetOverride(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	brandItemID, err := pathUUID(r, "brand_item_id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req model.SetOverrideRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	override, err := h.brands.SetOverride(r.Context(), id, brandItemID, &req, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, override)
+}
+
+// DeleteOverride handles DELETE /api/restaurants/{id}/overrides/{brand_item_id}
+func (h *BrandHandler) DeleteOverride(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	brandItemID, err := pathUUID(r, "brand_item_id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.brands.DeleteOverride(r.Context(), id, brandItemID, userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "override removed successfully"})
+}
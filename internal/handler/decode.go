@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"quickbite/internal/apperr"
+	"quickbite/internal/validate"
+)
+
+// No request body needs more than this; anything bigger is a mistake or abuse
+const maxBodyBytes = 1 << 20
+
+// decodeJSON reads a single JSON object into dst. Unknown fields, trailing
+// data and bodies over maxBodyBytes are rejected, and every failure comes
+// back as an apperr error naming the offending field where there is one.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return decodeError(err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field == "" {
+			if field, ok := typeErrorField(data, dst); ok {
+				return apperr.InvalidField(field, "must be "+jsonTypeName(typeErr.Type))
+			}
+		}
+		return decodeError(err)
+	}
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return apperr.Validation("request body must contain a single JSON object")
+	}
+	return nil
+}
+
+func decodeError(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var tooLarge *http.MaxBytesError
+
+	switch {
+	case errors.Is(err, io.EOF):
+		return apperr.Validation("request body is required")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return apperr.Validation("request body is not valid JSON")
+	case errors.As(err, &syntaxErr):
+		return apperr.Validation(fmt.Sprintf("request body is not valid JSON (at byte %d)", syntaxErr.Offset))
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return apperr.InvalidField(typeErr.Field, "must be "+jsonTypeName(typeErr.Type))
+	case errors.As(err, &typeErr):
+		return apperr.Validation("request body must be a JSON object")
+	case errors.As(err, &tooLarge):
+		return apperr.TooLarge(fmt.Sprintf("request body must not exceed %d bytes", tooLarge.Limit))
+	}
+
+	// encoding/json has no type for this one
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return apperr.InvalidField(strings.Trim(field, `"`), "is not a known field")
+	}
+	return apperr.Validation("invalid request body")
+}
+
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+// typeErrorField finds the top-level field of dst whose value has the
+// wrong type. encoding/json only names the field when it decoded it
+// itself, not when the field's own UnmarshalJSON failed, as a
+// model.Optional's does.
+func typeErrorField(data []byte, dst any) (string, bool) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", false
+	}
+
+	t := reflect.TypeOf(dst)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return "", false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		raw, ok := fields[name]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(raw, reflect.New(field.Type).Interface()); err != nil {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// pathUUID reads the named path parameter, which must be a UUID. Anything
+// else can't name a row, so it is a bad request rather than a not found.
+func pathUUID(r *http.Request, name string) (string, error) {
+	id := r.PathValue(name)
+	if !validate.UUID(id) {
+		return "", apperr.InvalidField(name, "must be a valid UUID")
+	}
+	return id, nil
+}
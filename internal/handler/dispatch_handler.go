@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"net/http"
+
+	"quickbite/config"
+	"quickbite/internal/middleware"
+	"quickbite/internal/model"
+	"quickbite/internal/service"
+	"quickbite/internal/utils"
+)
+
+type DispatchHandler struct {
+	cfg      *config.Config
+	dispatch *service.DispatchService
+}
+
+func NewDispatchHandler(cfg *config.Config, dispatch *service.DispatchService) *DispatchHandler {
+	return &DispatchHandler{cfg: cfg, dispatch: dispatch}
+}
+
+// ====== PARTNERS ======
+
+// GetMyStatus handles GET /api/partners/me
+func (h *DispatchHandler) GetMyStatus(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	partner, err := h.dispatch.GetMyStatus(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, partner)
+}
+
+// SetStatus handles PUT /api/partners/me/status
+func (h *DispatchHandler) SetStatus(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req model.UpdatePartnerStatusRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	partner, err := h.dispatch.SetStatus(r.Context(), &req, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, partner)
+}
+
+// UpdateLocation handles POST /api/partners/me/location
+func (h *DispatchHandler) UpdateLocation(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req model.LocationRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	partner, err := h.dispatch.UpdateLocation(r.Context(), &req, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, partner)
+}
+
+// GetMyTasks handles GET /api/partners/me/deliveries
+func (h *DispatchHandler) GetMyTasks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	tasks, err := h.dispatch.GetMyTasks(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, tasks)
+}
+
+// ====== DELIVERIES ======
+
+// AcceptOffer handles POST /api/deliveries/{id}/accept
+func (h *DispatchHandler) AcceptOffer(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	assignment, err := h.dispatch.AcceptOffer(r.Context(), id, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, assignment)
+}
+
+// RejectOffer handles POST /api/deliveries/{id}/reject
+func (h *DispatchHandler) RejectOffer(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.dispatch.RejectOffer(r.Context(), id, userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "offer rejected"})
+}
+
+// PickUp handles POST /api/deliveries/{id}/pickup
+func (h *DispatchHandler) PickUp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.dispatch.PickUp(r.Context(), id, userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "order picked up"})
+}
+
+// CompleteDelivery handles POST /api/deliveries/{id}/deliver
+func (h *DispatchHandler) CompleteDelivery(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req model.CompleteDeliveryRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.dispatch.CompleteDelivery(r.Context(), id, &req, userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "order delivered"})
+}
+
+// GetOrderDelivery handles GET /api/orders/{id}/delivery
+func (h *DispatchHandler) GetOrderDelivery(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	delivery, err := h.dispatch.GetOrderDelivery(r.Context(), id, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, delivery)
+}
+
+// SetRestaurantLocation handles PUT /api/restaurants/{id}/location
+func (h *DispatchHandler) SetRestaurantLocation(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req model.LocationRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.dispatch.SetRestaurantLocation(r.Context(), id, &req, userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "location updated successfully"})
+}
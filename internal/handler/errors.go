@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+
+	"quickbite/internal/apperr"
+	"quickbite/internal/logging"
+	"quickbite/internal/repository"
+	"quickbite/internal/utils"
+)
+
+// StatusClientClosedRequest is nginx's non-standard code for a client that
+// hung up before the response was ready
+const StatusClientClosedRequest = 499
+
+// writeError is the one place service errors become responses: the error's
+// kind picks the status, its message becomes the problem detail. Anything
+// that isn't an apperr.Error is treated as internal and only logged.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	problem := utils.Problem{Instance: r.URL.Path}
+	if info := logging.FromContext(r.Context()); info != nil {
+		problem.RequestID = info.ID
+	}
+
+	switch ctxErr := repository.ContextError(err); {
+	case errors.Is(ctxErr, context.Canceled):
+		problem.Status, problem.Detail = StatusClientClosedRequest, "request cancelled"
+		utils.WriteProblem(w, problem)
+		return
+	case errors.Is(ctxErr, context.DeadlineExceeded):
+		problem.Status, problem.Detail = http.StatusGatewayTimeout, "request timed out"
+		utils.WriteProblem(w, problem)
+		return
+	}
+
+	appErr, ok := apperr.As(err)
+	if !ok {
+		appErr = apperr.Internal("internal server error", err)
+	}
+
+	problem.Status = statusFor(appErr.Kind)
+	problem.Detail = appErr.Message
+	for _, f := range appErr.Fields {
+		problem.Errors = append(problem.Errors, utils.InvalidParam{Field: f.Field, Message: f.Message})
+	}
+
+	switch appErr.Kind {
+	case apperr.KindInternal:
+		slog.ErrorContext(r.Context(), appErr.Message, "error", err)
+	case apperr.KindTooManyRequests:
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(appErr.RetryAfter.Seconds()))))
+	}
+
+	utils.WriteProblem(w, problem)
+}
+
+func statusFor(kind apperr.Kind) int {
+	switch kind {
+	case apperr.KindValidation:
+		return http.StatusBadRequest
+	case apperr.KindUnauthorized:
+		return http.StatusUnauthorized
+	case apperr.KindForbidden:
+		return http.StatusForbidden
+	case apperr.KindNotFound:
+		return http.StatusNotFound
+	case apperr.KindConflict:
+		return http.StatusConflict
+	case apperr.KindTooManyRequests:
+		return http.StatusTooManyRequests
+	case apperr.KindTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case apperr.KindPreconditionFailed:
+		return http.StatusPreconditionFailed
+	case apperr.KindPreconditionRequired:
+		return http.StatusPreconditionRequired
+	default:
+		return http.StatusInternalServerError
+	}
+}
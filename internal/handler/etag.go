@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"quickbite/internal/apperr"
+)
+
+// ETags are the row version, quoted: "7". Owner edits bump it, so a client
+// that sends back what it read can't overwrite someone else's change.
+
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", etag(version))
+}
+
+// ifMatch returns the version named by the If-Match header, or 0 for "*".
+// Writes to versioned rows must send one: without it the client is editing
+// blind.
+func ifMatch(r *http.Request) (int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return 0, apperr.PreconditionRequired("If-Match header is required; send the ETag you last read")
+	}
+	if header == "*" {
+		return 0, nil
+	}
+
+	// A list may name several tags but a row only ever has one version
+	// worth matching; take the first
+	tag, _, _ := strings.Cut(header, ",")
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+	version, err := strconv.Atoi(strings.Trim(tag, `"`))
+	if err != nil || version <= 0 || len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, apperr.PreconditionFailed("If-Match does not name a current version")
+	}
+	return version, nil
+}
+
+// notModified answers 304 when If-None-Match already names version
+func notModified(w http.ResponseWriter, r *http.Request, version int) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	current := etag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == current || tag == "*" {
+			setETag(w, version)
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+
+	"quickbite/internal/health"
+	"quickbite/internal/lifecycle"
+	"quickbite/internal/utils"
+)
+
+type HealthHandler struct {
+	life   *lifecycle.Lifecycle
+	checks *health.Registry
+}
+
+func NewHealthHandler(life *lifecycle.Lifecycle, checks *health.Registry) *HealthHandler {
+	if checks == nil {
+		checks = health.NewRegistry()
+	}
+	return &HealthHandler{life: life, checks: checks}
+}
+
+// Health handles GET /health: 503 while starting up or draining,
+// so the load balancer stops sending new requests before we exit
+func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
+	if !h.life.Ready() {
+		utils.WriteJSON(w, http.StatusServiceUnavailable, map[string]string{
+			"status": "draining",
+			"app":    "QuickBite",
+		})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"status": "ok",
+		"app":    "QuickBite",
+	})
+}
+
+// Livez handles GET /livez. It never touches dependencies: a database
+// outage should take us out of rotation, not get the process restarted.
+func (h *HealthHandler) Livez(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Readyz handles GET /readyz: every registered check with its latency.
+// Only critical failures (and draining) answer 503.
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	if !h.life.Ready() {
+		utils.WriteJSON(w, http.StatusServiceUnavailable, health.Report{
+			Status: "draining",
+			Checks: map[string]health.Result{},
+		})
+		return
+	}
+
+	report := h.checks.Run(r.Context())
+
+	status := http.StatusOK
+	if report.Status == health.StatusFail {
+		status = http.StatusServiceUnavailable
+	}
+	utils.WriteJSON(w, status, report)
+}
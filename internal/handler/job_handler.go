@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"quickbite/internal/apperr"
+	"quickbite/internal/jobs"
+	"quickbite/internal/utils"
+)
+
+// Jobs listed per request unless ?limit= says otherwise, and the most it may ask for
+const (
+	defaultJobLimit = 50
+	maxJobLimit     = 500
+)
+
+// JobHandler shows admins the job queue and lets them retry dead jobs
+type JobHandler struct {
+	jobs jobs.Store
+}
+
+func NewJobHandler(store jobs.Store) *JobHandler {
+	return &JobHandler{jobs: store}
+}
+
+// ListJobs handles GET /api/admin/jobs?status=&kind=&limit=
+func (h *JobHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := jobs.Filter{
+		Status: query.Get("status"),
+		Kind:   query.Get("kind"),
+		Limit:  defaultJobLimit,
+	}
+
+	switch filter.Status {
+	case "", jobs.StatusQueued, jobs.StatusRunning, jobs.StatusDone, jobs.StatusDead:
+	default:
+		writeError(w, r, apperr.InvalidField("status", "must be one of: queued, running, done, dead"))
+		return
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxJobLimit {
+			writeError(w, r, apperr.InvalidField("limit", "must be between 1 and "+strconv.Itoa(maxJobLimit)))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	list, err := h.jobs.List(r.Context(), filter)
+	if err != nil {
+		writeError(w, r, apperr.Internal("failed to fetch jobs", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, list)
+}
+
+// GetJobStats handles GET /api/admin/jobs/stats: job counts by kind and status
+func (h *JobHandler) GetJobStats(w http.ResponseWriter, r *http.Request) {
+	counts, err := h.jobs.Counts(r.Context())
+	if err != nil {
+		writeError(w, r, apperr.Internal("failed to fetch job stats", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, counts)
+}
+
+// GetJob handles GET /api/admin/jobs/{id}
+func (h *JobHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	jobID, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	job, err := h.jobs.Get(r.Context(), jobID)
+	if err != nil {
+		writeError(w, r, jobErr(err, "failed to fetch job"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, job)
+}
+
+// RetryJob handles POST /api/admin/jobs/{id}/retry, giving a dead job a
+// fresh set of attempts
+func (h *JobHandler) RetryJob(w http.ResponseWriter, r *http.Request) {
+	jobID, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.jobs.Requeue(r.Context(), jobID); err != nil {
+		writeError(w, r, jobErr(err, "failed to retry job"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "job queued to run again"})
+}
+
+func jobErr(err error, message string) error {
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		return apperr.NotFound("job not found")
+	case errors.Is(err, jobs.ErrNotDead):
+		return apperr.Conflict("only dead jobs can be retried")
+	}
+	return apperr.Internal(message, err)
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"encoding/json"
+	"hash/crc32"
+	"net/http"
+
+	"quickbite/config"
+	"quickbite/internal/apperr"
+	"quickbite/internal/middleware"
+	"quickbite/internal/model"
+	"quickbite/internal/service"
+	"quickbite/internal/utils"
+)
+
+type KitchenHandler struct {
+	cfg     *config.Config
+	kitchen *service.KitchenService
+}
+
+func NewKitchenHandler(cfg *config.Config, kitchen *service.KitchenService) *KitchenHandler {
+	return &KitchenHandler{cfg: cfg, kitchen: kitchen}
+}
+
+// GetBoard handles GET /api/restaurants/{id}/kds. Tablets poll it; the ETag
+// is a checksum of the board, so an unchanged board costs them a 304.
+func (h *KitchenHandler) GetBoard(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	board, err := h.kitchen.GetBoard(r.Context(), id, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	body, err := json.Marshal(board)
+	if err != nil {
+		writeError(w, r, apperr.Internal("failed to encode board", err))
+		return
+	}
+	version := int(crc32.ChecksumIEEE(body))
+	if notModified(w, r, version) {
+		return
+	}
+
+	setETag(w, version)
+	utils.WriteJSON(w, http.StatusOK, board)
+}
+
+// Bump handles POST /api/kds/orders/{id}/bump
+func (h *KitchenHandler) Bump(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	status, err := h.kitchen.Bump(r.Context(), id, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"status": status})
+}
+
+// Recall handles POST /api/kds/orders/{id}/recall
+func (h *KitchenHandler) Recall(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	status, err := h.kitchen.Recall(r.Context(), id, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"status": status})
+}
+
+// SetItemDone handles PUT /api/kds/orders/{id}/items/{item_id}
+func (h *KitchenHandler) SetItemDone(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	itemID, err := pathUUID(r, "item_id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req model.UpdateKitchenItemRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.kitchen.SetItemDone(r.Context(), id, itemID, &req, userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]bool{"done": *req.Done})
+}
+
+// UpdateSettings handles PUT /api/restaurants/{id}/kds/settings
+func (h *KitchenHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req model.KitchenSettingsRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.kitchen.UpdateSettings(r.Context(), id, &req, userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "kitchen settings updated successfully"})
+}
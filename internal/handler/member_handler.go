@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"net/http"
+
+	"quickbite/config"
+	"quickbite/internal/middleware"
+	"quickbite/internal/model"
+	"quickbite/internal/service"
+	"quickbite/internal/utils"
+)
+
+type MemberHandler struct {
+	cfg     *config.Config
+	members *service.MemberService
+}
+
+func NewMemberHandler(cfg *config.Config, members *service.MemberService) *MemberHandler {
+	return &MemberHandler{cfg: cfg, members: members}
+}
+
+// GetMembers handles GET /api/restaurants/{id}/members
+func (h *MemberHandler) GetMembers(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	members, err := h.members.GetMembers(r.Context(), id, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, members)
+}
+
+// UpdateMember handles PUT /api/restaurants/{id}/members/{user_id}
+func (h *MemberHandler) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	memberID, err := pathUUID(r, "user_id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req model.UpdateMemberRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	member, err := h.members.UpdateMemberRole(r.Context(), id, memberID, &req, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, member)
+}
+
+// RemoveMember handles DELETE /api/restaurants/{id}/members/{user_id}
+func (h *MemberHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	memberID, err := pathUUID(r, "user_id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.members.RemoveMember(r.Context(), id, memberID, userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "member removed successfully"})
+}
+
+// InviteMember handles POST /api/restaurants/{id}/invitations
+func (h *MemberHandler) InviteMember(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req model.InviteMemberRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	invitation, err := h.members.InviteMember(r.Context(), id, &req, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, invitation)
+}
+
+// GetInvitations handles GET /api/restaurants/{id}/invitations
+func (h *MemberHandler) GetInvitations(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	invitations, err := h.members.GetInvitations(r.Context(), id, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, invitations)
+}
+
+// RevokeInvitation handles DELETE /api/restaurants/{id}/invitations/{invitation_id}
+func (h *MemberHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	invitationID, err := pathUUID(r, "invitation_id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.members.RevokeInvitation(r.Context(), id, invitationID, userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "invitation revoked successfully"})
+}
+
+// AcceptInvitation handles POST /api/invitations/accept
+func (h *MemberHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req model.AcceptInvitationRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	member, err := h.members.AcceptInvitation(r.Context(), &req, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, member)
+}
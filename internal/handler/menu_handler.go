@@ -1,8 +1,7 @@
 package handler
 
 import (
-	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 
 	"quickbite/config"
@@ -13,11 +12,12 @@ import (
 )
 
 type MenuHandler struct {
-	cfg *config.Config
+	cfg  *config.Config
+	menu *service.MenuService
 }
 
-func NewMenuHandler(cfg *config.Config) *MenuHandler {
-	return &MenuHandler{cfg: cfg}
+func NewMenuHandler(cfg *config.Config, menu *service.MenuService) *MenuHandler {
+	return &MenuHandler{cfg: cfg, menu: menu}
 }
 
 // ====== MENU CATEGORIES ======
@@ -30,14 +30,14 @@ func (h *MenuHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req model.CreateCategoryRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, "invalid request body")
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	category, err := service.CreateCategory(&req, userID)
+	category, err := h.menu.CreateCategory(r.Context(), &req, userID)
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		writeError(w, r, err)
 		return
 	}
 
@@ -45,15 +45,15 @@ func (h *MenuHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *MenuHandler) GetCategoriesByRestaurant(w http.ResponseWriter, r *http.Request) {
-	restaurantID := r.PathValue("restaurant_id")
-	if restaurantID == "" {
-		utils.WriteError(w, http.StatusBadRequest, "restaurant id is required")
+	restaurantID, err := pathUUID(r, "restaurant_id")
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	categories, err := service.GetCategoriesByRestaurant(restaurantID)
+	categories, err := h.menu.GetCategoriesByRestaurant(r.Context(), restaurantID)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch categories")
+		writeError(w, r, err)
 		return
 	}
 
@@ -63,30 +63,76 @@ func (h *MenuHandler) GetCategoriesByRestaurant(w http.ResponseWriter, r *http.R
 func (h *MenuHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
 	if !ok {
-		log.Println("DeleteCategory: unauthorized - no userID in context")
 		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
-	id := r.PathValue("id")
-	if id == "" {
-		log.Println("DeleteCategory: category id is required")
-		utils.WriteError(w, http.StatusBadRequest, "category id is required")
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	log.Printf("DeleteCategory: attempting to delete category %s by user %s", id, userID)
+	slog.DebugContext(r.Context(), "deleting category", "category_id", id)
 
-	if err := service.DeleteCategory(id, userID); err != nil {
-		log.Printf("DeleteCategory ERROR: %v", err)
-		utils.WriteError(w, http.StatusBadRequest, err.Error())
+	if err := h.menu.DeleteCategory(r.Context(), id, userID); err != nil {
+		slog.WarnContext(r.Context(), "delete category failed", "category_id", id, "error", err)
+		writeError(w, r, err)
 		return
 	}
 
-	log.Printf("DeleteCategory: successfully deleted category %s", id)
+	slog.InfoContext(r.Context(), "category deleted", "category_id", id)
 	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "category deleted successfully"})
 }
 
+func (h *MenuHandler) UpdateCategorySchedule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req model.UpdateScheduleRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.menu.UpdateCategorySchedule(r.Context(), id, &req, userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "category schedule updated successfully"})
+}
+
+func (h *MenuHandler) RestoreCategory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.menu.RestoreCategory(r.Context(), id, userID, h.cfg.SoftDeleteRetention); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "category restored successfully"})
+}
+
 // ====== MENU ITEMS ======
 
 func (h *MenuHandler) CreateMenuItem(w http.ResponseWriter, r *http.Request) {
@@ -97,36 +143,82 @@ func (h *MenuHandler) CreateMenuItem(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req model.CreateMenuItemRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, "invalid request body")
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	item, err := service.CreateMenuItem(&req, userID)
+	item, err := h.menu.CreateMenuItem(r.Context(), &req, userID)
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		writeError(w, r, err)
 		return
 	}
 
 	utils.WriteJSON(w, http.StatusCreated, item)
 }
 
+// GetFullMenu handles GET /api/restaurants/{id}/menu, the menu as its
+// editors see it, whatever the time of day
+func (h *MenuHandler) GetFullMenu(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	menu, err := h.menu.GetFullMenu(r.Context(), id, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, menu)
+}
+
 func (h *MenuHandler) GetMenuItemsByCategory(w http.ResponseWriter, r *http.Request) {
-	categoryID := r.PathValue("category_id")
-	if categoryID == "" {
-		utils.WriteError(w, http.StatusBadRequest, "category id is required")
+	categoryID, err := pathUUID(r, "category_id")
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	items, err := service.GetMenuItemsByCategory(categoryID)
+	items, err := h.menu.GetMenuItemsByCategory(r.Context(), categoryID)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch menu items")
+		writeError(w, r, err)
 		return
 	}
 
 	utils.WriteJSON(w, http.StatusOK, items)
 }
 
+// GetMenuItemByID handles GET /api/menu/items/{id}; the ETag it sends is
+// what edits pass back in If-Match
+func (h *MenuHandler) GetMenuItemByID(w http.ResponseWriter, r *http.Request) {
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	item, err := h.menu.GetMenuItemByID(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if notModified(w, r, item.Version) {
+		return
+	}
+	setETag(w, item.Version)
+	utils.WriteJSON(w, http.StatusOK, item)
+}
+
 func (h *MenuHandler) UpdateMenuItem(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
 	if !ok {
@@ -134,26 +226,158 @@ func (h *MenuHandler) UpdateMenuItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id := r.PathValue("id")
-	if id == "" {
-		utils.WriteError(w, http.StatusBadRequest, "menu item id is required")
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	version, err := ifMatch(r)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	var req model.UpdateMenuItemRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, "invalid request body")
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	if err := service.UpdateMenuItem(id, &req, userID); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, err.Error())
+	if err := h.menu.UpdateMenuItem(r.Context(), id, version, &req, userID); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "menu item updated successfully"})
 }
 
+// PatchMenuItem handles PATCH /api/menu/items/{id} with a JSON merge patch body
+func (h *MenuHandler) PatchMenuItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	version, err := ifMatch(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req model.PatchMenuItemRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	item, err := h.menu.PatchMenuItem(r.Context(), id, version, &req, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, item.Version)
+	utils.WriteJSON(w, http.StatusOK, item)
+}
+
+// UpdateMenuItemAvailability handles PUT /api/menu/items/{id}/availability
+func (h *MenuHandler) UpdateMenuItemAvailability(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	version, err := ifMatch(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req model.UpdateAvailabilityRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	item, err := h.menu.SetMenuItemAvailability(r.Context(), id, version, &req, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, item.Version)
+	utils.WriteJSON(w, http.StatusOK, item)
+}
+
+func (h *MenuHandler) UpdateMenuItemStock(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req model.UpdateStockRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.menu.UpdateMenuItemStock(r.Context(), id, &req, userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "menu item stock updated successfully"})
+}
+
+func (h *MenuHandler) UpdateMenuItemSchedule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req model.UpdateScheduleRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.menu.UpdateMenuItemSchedule(r.Context(), id, &req, userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "menu item schedule updated successfully"})
+}
+
 func (h *MenuHandler) DeleteMenuItem(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
 	if !ok {
@@ -161,16 +385,37 @@ func (h *MenuHandler) DeleteMenuItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id := r.PathValue("id")
-	if id == "" {
-		utils.WriteError(w, http.StatusBadRequest, "menu item id is required")
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	if err := service.DeleteMenuItem(id, userID); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, err.Error())
+	if err := h.menu.DeleteMenuItem(r.Context(), id, userID); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "menu item deleted successfully"})
 }
+
+func (h *MenuHandler) RestoreMenuItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.menu.RestoreMenuItem(r.Context(), id, userID, h.cfg.SoftDeleteRetention); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "menu item restored successfully"})
+}
@@ -1,11 +1,12 @@
 package handler
 
 import (
-	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
+	"time"
 
 	"quickbite/config"
+	"quickbite/internal/apperr"
 	"quickbite/internal/middleware"
 	"quickbite/internal/model"
 	"quickbite/internal/service"
@@ -13,11 +14,12 @@ import (
 )
 
 type OrderHandler struct {
-	cfg *config.Config
+	cfg    *config.Config
+	orders *service.OrderService
 }
 
-func NewOrderHandler(cfg *config.Config) *OrderHandler {
-	return &OrderHandler{cfg: cfg}
+func NewOrderHandler(cfg *config.Config, orders *service.OrderService) *OrderHandler {
+	return &OrderHandler{cfg: cfg, orders: orders}
 }
 
 // CreateOrder handles POST /api/orders
@@ -29,21 +31,21 @@ func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req model.CreateOrderRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, "invalid request body")
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	log.Printf("CreateOrder: user %s ordering from restaurant %s", userID, req.RestaurantID)
+	slog.DebugContext(r.Context(), "creating order", "restaurant_id", req.RestaurantID)
 
-	order, err := service.CreateOrder(&req, userID)
+	order, err := h.orders.CreateOrder(r.Context(), &req, userID)
 	if err != nil {
-		log.Printf("CreateOrder error: %v", err)
-		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		slog.WarnContext(r.Context(), "create order failed", "error", err)
+		writeError(w, r, err)
 		return
 	}
 
-	log.Printf("CreateOrder: order %s created successfully", order.ID)
+	slog.InfoContext(r.Context(), "order created", "order_id", order.ID)
 	utils.WriteJSON(w, http.StatusCreated, order)
 }
 
@@ -55,15 +57,15 @@ func (h *OrderHandler) GetOrderByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	orderID := r.PathValue("id")
-	if orderID == "" {
-		utils.WriteError(w, http.StatusBadRequest, "order id is required")
+	orderID, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	order, err := service.GetOrderByID(orderID, userID)
+	order, err := h.orders.GetOrderByID(r.Context(), orderID, userID)
 	if err != nil {
-		utils.WriteError(w, http.StatusNotFound, err.Error())
+		writeError(w, r, err)
 		return
 	}
 
@@ -78,9 +80,9 @@ func (h *OrderHandler) GetMyOrders(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	orders, err := service.GetMyOrders(userID)
+	orders, err := h.orders.GetMyOrders(r.Context(), userID)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch orders")
+		writeError(w, r, err)
 		return
 	}
 
@@ -95,22 +97,58 @@ func (h *OrderHandler) GetRestaurantOrders(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	restaurantID := r.PathValue("id")
-	if restaurantID == "" {
-		utils.WriteError(w, http.StatusBadRequest, "restaurant id is required")
+	restaurantID, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	orders, err := service.GetRestaurantOrders(restaurantID, userID)
+	orders, err := h.orders.GetRestaurantOrders(r.Context(), restaurantID, userID)
 	if err != nil {
-		log.Printf("GetRestaurantOrders error: %v", err)
-		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		slog.WarnContext(r.Context(), "get restaurant orders failed", "restaurant_id", restaurantID, "error", err)
+		writeError(w, r, err)
 		return
 	}
 
 	utils.WriteJSON(w, http.StatusOK, orders)
 }
 
+// GetRestaurantRevenue handles GET /api/restaurants/:id/revenue?from=&to=.
+// from and to are dates (2006-01-02, UTC) or RFC 3339 times; from is
+// inclusive, to exclusive, and either can be left out.
+func (h *OrderHandler) GetRestaurantRevenue(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	restaurantID, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	from, err := parseTimeParam(r, "from")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	to, err := parseTimeParam(r, "to")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	summary, err := h.orders.GetRevenue(r.Context(), restaurantID, from, to, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, summary)
+}
+
 // UpdateOrderStatus handles PUT /api/orders/:id/status
 func (h *OrderHandler) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
@@ -119,37 +157,95 @@ func (h *OrderHandler) UpdateOrderStatus(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	orderID := r.PathValue("id")
-	if orderID == "" {
-		utils.WriteError(w, http.StatusBadRequest, "order id is required")
+	orderID, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	var body struct {
-		Status string `json:"status"`
+		Status       string `json:"status"`
+		DeliveryCode string `json:"delivery_code"` // needed for "delivered"
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, "invalid request body")
+	if err := decodeJSON(w, r, &body); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	if body.Status == "" {
-		utils.WriteError(w, http.StatusBadRequest, "status is required")
+		writeError(w, r, apperr.InvalidField("status", "is required"))
 		return
 	}
 
-	log.Printf("UpdateOrderStatus: order %s to status %s by user %s", orderID, body.Status, userID)
+	slog.DebugContext(r.Context(), "updating order status", "order_id", orderID, "status", body.Status)
 
-	if err := service.UpdateOrderStatus(orderID, body.Status, userID); err != nil {
-		log.Printf("UpdateOrderStatus error: %v", err)
-		utils.WriteError(w, http.StatusBadRequest, err.Error())
+	if err := h.orders.UpdateOrderStatus(r.Context(), orderID, body.Status, body.DeliveryCode, userID); err != nil {
+		slog.WarnContext(r.Context(), "update order status failed", "order_id", orderID, "error", err)
+		writeError(w, r, err)
 		return
 	}
 
 	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "order status updated successfully"})
 }
 
+// AcceptOrder handles POST /api/orders/:id/accept
+func (h *OrderHandler) AcceptOrder(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req model.AcceptOrderRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	order, err := h.orders.AcceptOrder(r.Context(), id, &req, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, order)
+}
+
+// RejectOrder handles POST /api/orders/:id/reject
+func (h *OrderHandler) RejectOrder(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req model.RejectOrderRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.orders.RejectOrder(r.Context(), id, &req, userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "order rejected"})
+}
+
 // CancelOrder handles POST /api/orders/:id/cancel
 func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
@@ -158,19 +254,79 @@ func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	orderID := r.PathValue("id")
-	if orderID == "" {
-		utils.WriteError(w, http.StatusBadRequest, "order id is required")
+	orderID, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	log.Printf("CancelOrder: user %s cancelling order %s", userID, orderID)
+	slog.DebugContext(r.Context(), "cancelling order", "order_id", orderID)
 
-	if err := service.CancelOrder(orderID, userID); err != nil {
-		log.Printf("CancelOrder error: %v", err)
-		utils.WriteError(w, http.StatusBadRequest, err.Error())
+	if err := h.orders.CancelOrder(r.Context(), orderID, userID); err != nil {
+		slog.WarnContext(r.Context(), "cancel order failed", "order_id", orderID, "error", err)
+		writeError(w, r, err)
 		return
 	}
 
 	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "order cancelled successfully"})
 }
+
+// ReissueDeliveryCode handles POST /api/orders/:id/delivery-code
+func (h *OrderHandler) ReissueDeliveryCode(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	order, err := h.orders.ReissueDeliveryCode(r.Context(), id, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, order)
+}
+
+// GetDeliveryCodeEvents handles GET /api/orders/:id/delivery-code/events
+func (h *OrderHandler) GetDeliveryCodeEvents(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	events, err := h.orders.GetDeliveryCodeEvents(r.Context(), id, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, events)
+}
+
+// parseTimeParam reads an optional date or RFC 3339 time from the query string
+func parseTimeParam(r *http.Request, name string) (*time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.DateOnly, time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, apperr.InvalidField(name, "must be a date (YYYY-MM-DD) or an RFC 3339 time")
+}
@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"quickbite/config"
@@ -12,11 +11,12 @@ import (
 )
 
 type RestaurantHandler struct {
-	cfg *config.Config
+	cfg         *config.Config
+	restaurants *service.RestaurantService
 }
 
-func NewRestaurantHandler(cfg *config.Config) *RestaurantHandler {
-	return &RestaurantHandler{cfg: cfg}
+func NewRestaurantHandler(cfg *config.Config, restaurants *service.RestaurantService) *RestaurantHandler {
+	return &RestaurantHandler{cfg: cfg, restaurants: restaurants}
 }
 
 func (h *RestaurantHandler) CreateRestaurant(w http.ResponseWriter, r *http.Request) {
@@ -27,14 +27,14 @@ func (h *RestaurantHandler) CreateRestaurant(w http.ResponseWriter, r *http.Requ
 	}
 
 	var req model.CreateRestaurantRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, "invalid request body")
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	restaurant, err := service.CreateRestaurant(&req, userID)
+	restaurant, err := h.restaurants.CreateRestaurant(r.Context(), &req, userID)
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		writeError(w, r, err)
 		return
 	}
 
@@ -42,18 +42,22 @@ func (h *RestaurantHandler) CreateRestaurant(w http.ResponseWriter, r *http.Requ
 }
 
 func (h *RestaurantHandler) GetRestaurantByID(w http.ResponseWriter, r *http.Request) {
-	id := r.PathValue("id")
-	if id == "" {
-		utils.WriteError(w, http.StatusBadRequest, "restaurant id is required")
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	restaurant, err := service.GetRestaurantByID(id)
+	restaurant, err := h.restaurants.GetRestaurantByID(r.Context(), id)
 	if err != nil {
-		utils.WriteError(w, http.StatusNotFound, err.Error())
+		writeError(w, r, err)
 		return
 	}
 
+	if notModified(w, r, restaurant.Version) {
+		return
+	}
+	setETag(w, restaurant.Version)
 	utils.WriteJSON(w, http.StatusOK, restaurant)
 }
 
@@ -64,9 +68,9 @@ func (h *RestaurantHandler) GetMyRestaurants(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	restaurants, err := service.GetRestaurantsByOwner(userID)
+	restaurants, err := h.restaurants.GetRestaurantsByMember(r.Context(), userID)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch restaurants")
+		writeError(w, r, err)
 		return
 	}
 
@@ -76,9 +80,9 @@ func (h *RestaurantHandler) GetMyRestaurants(w http.ResponseWriter, r *http.Requ
 func (h *RestaurantHandler) GetAllRestaurants(w http.ResponseWriter, r *http.Request) {
 	city := r.URL.Query().Get("city")
 
-	restaurants, err := service.GetAllRestaurants(city)
+	restaurants, err := h.restaurants.GetAllRestaurants(r.Context(), city)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch restaurants")
+		writeError(w, r, err)
 		return
 	}
 
@@ -92,26 +96,69 @@ func (h *RestaurantHandler) UpdateRestaurant(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	id := r.PathValue("id")
-	if id == "" {
-		utils.WriteError(w, http.StatusBadRequest, "restaurant id is required")
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	version, err := ifMatch(r)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	var req model.UpdateRestaurantRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, "invalid request body")
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	if err := service.UpdateRestaurant(id, &req, userID); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, err.Error())
+	if err := h.restaurants.UpdateRestaurant(r.Context(), id, version, &req, userID); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "restaurant updated successfully"})
 }
 
+// PatchRestaurant handles PATCH /api/restaurants/{id} with a JSON merge
+// patch body (application/merge-patch+json or application/json)
+func (h *RestaurantHandler) PatchRestaurant(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	version, err := ifMatch(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req model.PatchRestaurantRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	restaurant, err := h.restaurants.PatchRestaurant(r.Context(), id, version, &req, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, restaurant.Version)
+	utils.WriteJSON(w, http.StatusOK, restaurant)
+}
+
 func (h *RestaurantHandler) DeleteRestaurant(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
 	if !ok {
@@ -119,16 +166,53 @@ func (h *RestaurantHandler) DeleteRestaurant(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	id := r.PathValue("id")
-	if id == "" {
-		utils.WriteError(w, http.StatusBadRequest, "restaurant id is required")
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	if err := service.DeleteRestaurant(id, userID); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, err.Error())
+	if err := h.restaurants.DeleteRestaurant(r.Context(), id, userID); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "restaurant deleted successfully"})
 }
+
+func (h *RestaurantHandler) GetMyTrash(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	trash, err := h.restaurants.GetTrash(r.Context(), userID, h.cfg.SoftDeleteRetention)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, trash)
+}
+
+func (h *RestaurantHandler) RestoreRestaurant(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.restaurants.RestoreRestaurant(r.Context(), id, userID, h.cfg.SoftDeleteRetention); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "restaurant restored successfully"})
+}
@@ -2,27 +2,93 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
 	"quickbite/config"
+	"quickbite/internal/health"
+	"quickbite/internal/idempotency"
+	"quickbite/internal/jobs"
+	"quickbite/internal/lifecycle"
 	"quickbite/internal/middleware"
-	"quickbite/internal/utils"
+	"quickbite/internal/ratelimit"
+	"quickbite/internal/service"
 )
 
-func NewRouter(cfg *config.Config) *http.ServeMux {
+// Rate limits for the routes most worth abusing
+var (
+	loginLimit       = ratelimit.Limit{Burst: 10, Every: 6 * time.Second}  // 10/min
+	registerLimit    = ratelimit.Limit{Burst: 5, Every: 12 * time.Minute}  // 5/hour
+	createOrderLimit = ratelimit.Limit{Burst: 10, Every: 30 * time.Second} // 2/min sustained
+	inviteLimit      = ratelimit.Limit{Burst: 20, Every: 3 * time.Minute}  // 20/hour
+)
+
+// Deps is everything NewRouter wires into the handlers
+type Deps struct {
+	Services    *service.Services
+	Lifecycle   *lifecycle.Lifecycle
+	Health      *health.Registry  // checks behind /readyz
+	RateLimits  ratelimit.Store   // defaults to an in-memory store
+	Idempotency idempotency.Store // defaults to an in-memory store
+	Metrics     http.Handler      // served at /metrics when set
+	Jobs        jobs.Store        // the queue shown to admins; defaults to an in-memory store
+}
+
+func NewRouter(cfg *config.Config, deps Deps) *http.ServeMux {
 	mux := http.NewServeMux()
+	services := deps.Services
+
+	jobStore := deps.Jobs
+	if jobStore == nil {
+		jobStore = jobs.NewMemoryStore()
+	}
 
 	// Initialize handlers
-	authHandler := NewAuthHandler(cfg)
-	restaurantHandler := NewRestaurantHandler(cfg)
-	menuHandler := NewMenuHandler(cfg)
-	orderHandler := NewOrderHandler(cfg)
+	authHandler := NewAuthHandler(cfg, services.Auth)
+	restaurantHandler := NewRestaurantHandler(cfg, services.Restaurants)
+	menuHandler := NewMenuHandler(cfg, services.Menu)
+	orderHandler := NewOrderHandler(cfg, services.Orders)
+	memberHandler := NewMemberHandler(cfg, services.Members)
+	brandHandler := NewBrandHandler(cfg, services.Brands)
+	dispatchHandler := NewDispatchHandler(cfg, services.Dispatch)
+	kitchenHandler := NewKitchenHandler(cfg, services.Kitchen)
+	jobHandler := NewJobHandler(jobStore)
 
-	// Health check
-	mux.HandleFunc("GET /health", healthCheck)
+	// Health checks: /livez says the process is up, /readyz whether it
+	// should get traffic. /health is kept for existing probes.
+	healthHandler := NewHealthHandler(deps.Lifecycle, deps.Health)
+	mux.HandleFunc("GET /health", healthHandler.Health)
+	mux.HandleFunc("GET /livez", healthHandler.Livez)
+	mux.HandleFunc("GET /readyz", healthHandler.Readyz)
+
+	if deps.Metrics != nil {
+		mux.Handle("GET /metrics", deps.Metrics)
+	}
+
+	rateLimits := deps.RateLimits
+	if rateLimits == nil {
+		rateLimits = ratelimit.NewMemoryStore()
+	}
+	limiter := middleware.NewRateLimiter(rateLimits, cfg.TrustProxy)
+
+	// Retrying these after a timeout must not order or cancel twice
+	idempotencyStore := deps.Idempotency
+	if idempotencyStore == nil {
+		idempotencyStore = idempotency.NewMemoryStore()
+	}
+	idempotent := middleware.Idempotent(idempotencyStore, cfg.IdempotencyTTL)
 
 	// ====== AUTH ROUTES (Public) ======
-	mux.HandleFunc("POST /api/auth/register", authHandler.Register)
-	mux.HandleFunc("POST /api/auth/login", authHandler.Login)
+	// Throttled per IP; repeated failures for one email also lock it out (see AuthService.Login)
+	mux.Handle("POST /api/auth/register",
+		limiter.PerIP("auth-register", registerLimit)(
+			http.HandlerFunc(authHandler.Register),
+		),
+	)
+	mux.Handle("POST /api/auth/login",
+		limiter.PerIP("auth-login", loginLimit)(
+			http.HandlerFunc(authHandler.Login),
+		),
+	)
 
 	// ====== RESTAURANT ROUTES ======
 
@@ -30,11 +96,19 @@ func NewRouter(cfg *config.Config) *http.ServeMux {
 	mux.HandleFunc("GET /api/restaurants", restaurantHandler.GetAllRestaurants)
 	mux.HandleFunc("GET /api/restaurants/{id}", restaurantHandler.GetRestaurantByID)
 
-	// Protected routes - require auth
+	// Protected routes - require auth. Creating a restaurant takes a
+	// restaurant_owner account; everything on an existing one is checked
+	// against the caller's role there (see service.Authorizer).
 	mux.Handle("GET /api/restaurants/my/list",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(restaurantHandler.GetMyRestaurants),
+		),
+	)
+
+	mux.Handle("GET /api/restaurants/my/trash",
 		middleware.Auth(cfg)(
 			middleware.RequireRole("restaurant_owner")(
-				http.HandlerFunc(restaurantHandler.GetMyRestaurants),
+				http.HandlerFunc(restaurantHandler.GetMyTrash),
 			),
 		),
 	)
@@ -49,17 +123,25 @@ func NewRouter(cfg *config.Config) *http.ServeMux {
 
 	mux.Handle("PUT /api/restaurants/{id}",
 		middleware.Auth(cfg)(
-			middleware.RequireRole("restaurant_owner")(
-				http.HandlerFunc(restaurantHandler.UpdateRestaurant),
-			),
+			http.HandlerFunc(restaurantHandler.UpdateRestaurant),
+		),
+	)
+
+	mux.Handle("PATCH /api/restaurants/{id}",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(restaurantHandler.PatchRestaurant),
 		),
 	)
 
 	mux.Handle("DELETE /api/restaurants/{id}",
 		middleware.Auth(cfg)(
-			middleware.RequireRole("restaurant_owner")(
-				http.HandlerFunc(restaurantHandler.DeleteRestaurant),
-			),
+			http.HandlerFunc(restaurantHandler.DeleteRestaurant),
+		),
+	)
+
+	mux.Handle("POST /api/restaurants/{id}/restore",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(restaurantHandler.RestoreRestaurant),
 		),
 	)
 
@@ -68,20 +150,34 @@ func NewRouter(cfg *config.Config) *http.ServeMux {
 	// Public route
 	mux.HandleFunc("GET /api/restaurants/{restaurant_id}/categories", menuHandler.GetCategoriesByRestaurant)
 
-	// Protected routes - require auth and restaurant_owner role
+	// Protected routes - require auth and the edit_menu permission
+	mux.Handle("GET /api/restaurants/{id}/menu",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(menuHandler.GetFullMenu),
+		),
+	)
+
 	mux.Handle("POST /api/menu/categories",
 		middleware.Auth(cfg)(
-			middleware.RequireRole("restaurant_owner")(
-				http.HandlerFunc(menuHandler.CreateCategory),
-			),
+			http.HandlerFunc(menuHandler.CreateCategory),
+		),
+	)
+
+	mux.Handle("PUT /api/menu/categories/{id}/schedule",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(menuHandler.UpdateCategorySchedule),
 		),
 	)
 
 	mux.Handle("DELETE /api/menu/categories/{id}",
 		middleware.Auth(cfg)(
-			middleware.RequireRole("restaurant_owner")(
-				http.HandlerFunc(menuHandler.DeleteCategory),
-			),
+			http.HandlerFunc(menuHandler.DeleteCategory),
+		),
+	)
+
+	mux.Handle("POST /api/menu/categories/{id}/restore",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(menuHandler.RestoreCategory),
 		),
 	)
 
@@ -89,29 +185,54 @@ func NewRouter(cfg *config.Config) *http.ServeMux {
 
 	// Public route
 	mux.HandleFunc("GET /api/categories/{category_id}/items", menuHandler.GetMenuItemsByCategory)
+	mux.HandleFunc("GET /api/menu/items/{id}", menuHandler.GetMenuItemByID)
 
-	// Protected routes - require auth and restaurant_owner role
+	// Protected routes - require auth and the edit_menu permission
 	mux.Handle("POST /api/menu/items",
 		middleware.Auth(cfg)(
-			middleware.RequireRole("restaurant_owner")(
-				http.HandlerFunc(menuHandler.CreateMenuItem),
-			),
+			http.HandlerFunc(menuHandler.CreateMenuItem),
 		),
 	)
 
 	mux.Handle("PUT /api/menu/items/{id}",
 		middleware.Auth(cfg)(
-			middleware.RequireRole("restaurant_owner")(
-				http.HandlerFunc(menuHandler.UpdateMenuItem),
-			),
+			http.HandlerFunc(menuHandler.UpdateMenuItem),
+		),
+	)
+
+	mux.Handle("PUT /api/menu/items/{id}/schedule",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(menuHandler.UpdateMenuItemSchedule),
+		),
+	)
+
+	mux.Handle("PUT /api/menu/items/{id}/stock",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(menuHandler.UpdateMenuItemStock),
+		),
+	)
+
+	mux.Handle("PATCH /api/menu/items/{id}",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(menuHandler.PatchMenuItem),
+		),
+	)
+
+	mux.Handle("PUT /api/menu/items/{id}/availability",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(menuHandler.UpdateMenuItemAvailability),
 		),
 	)
 
 	mux.Handle("DELETE /api/menu/items/{id}",
 		middleware.Auth(cfg)(
-			middleware.RequireRole("restaurant_owner")(
-				http.HandlerFunc(menuHandler.DeleteMenuItem),
-			),
+			http.HandlerFunc(menuHandler.DeleteMenuItem),
+		),
+	)
+
+	mux.Handle("POST /api/menu/items/{id}/restore",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(menuHandler.RestoreMenuItem),
 		),
 	)
 
@@ -120,7 +241,11 @@ func NewRouter(cfg *config.Config) *http.ServeMux {
 	// Customer routes - require auth (any authenticated user)
 	mux.Handle("POST /api/orders",
 		middleware.Auth(cfg)(
-			http.HandlerFunc(orderHandler.CreateOrder),
+			idempotent(
+				limiter.PerUser("create-order", createOrderLimit)(
+					http.HandlerFunc(orderHandler.CreateOrder),
+				),
+			),
 		),
 	)
 
@@ -138,33 +263,361 @@ func NewRouter(cfg *config.Config) *http.ServeMux {
 
 	mux.Handle("POST /api/orders/{id}/cancel",
 		middleware.Auth(cfg)(
-			http.HandlerFunc(orderHandler.CancelOrder),
+			idempotent(
+				http.HandlerFunc(orderHandler.CancelOrder),
+			),
+		),
+	)
+
+	// A new delivery code, for the customer; the audit trail is also for staff
+	mux.Handle("POST /api/orders/{id}/delivery-code",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(orderHandler.ReissueDeliveryCode),
+		),
+	)
+
+	mux.Handle("GET /api/orders/{id}/delivery-code/events",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(orderHandler.GetDeliveryCodeEvents),
 		),
 	)
 
-	// Restaurant owner routes - require auth and restaurant_owner role
+	// Restaurant staff routes - require auth and a role at the restaurant
 	mux.Handle("GET /api/restaurants/{id}/orders",
 		middleware.Auth(cfg)(
-			middleware.RequireRole("restaurant_owner")(
-				http.HandlerFunc(orderHandler.GetRestaurantOrders),
-			),
+			http.HandlerFunc(orderHandler.GetRestaurantOrders),
 		),
 	)
 
 	mux.Handle("PUT /api/orders/{id}/status",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(orderHandler.UpdateOrderStatus),
+		),
+	)
+
+	// Answering a new order; unanswered ones are rejected by the
+	// order-timeout worker (see OrderService.RejectUnanswered)
+	mux.Handle("POST /api/orders/{id}/accept",
+		middleware.Auth(cfg)(
+			idempotent(
+				http.HandlerFunc(orderHandler.AcceptOrder),
+			),
+		),
+	)
+
+	mux.Handle("POST /api/orders/{id}/reject",
+		middleware.Auth(cfg)(
+			idempotent(
+				http.HandlerFunc(orderHandler.RejectOrder),
+			),
+		),
+	)
+
+	mux.Handle("GET /api/restaurants/{id}/revenue",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(orderHandler.GetRestaurantRevenue),
+		),
+	)
+
+	// ====== KITCHEN DISPLAY ROUTES ======
+
+	// Restaurant staff routes - the board needs view_orders, the ticket
+	// actions update_order_status, settings edit_restaurant
+	mux.Handle("GET /api/restaurants/{id}/kds",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(kitchenHandler.GetBoard),
+		),
+	)
+
+	mux.Handle("PUT /api/restaurants/{id}/kds/settings",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(kitchenHandler.UpdateSettings),
+		),
+	)
+
+	mux.Handle("POST /api/kds/orders/{id}/bump",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(kitchenHandler.Bump),
+		),
+	)
+
+	mux.Handle("POST /api/kds/orders/{id}/recall",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(kitchenHandler.Recall),
+		),
+	)
+
+	mux.Handle("PUT /api/kds/orders/{id}/items/{item_id}",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(kitchenHandler.SetItemDone),
+		),
+	)
+
+	// ====== STAFF ROUTES ======
+
+	mux.Handle("GET /api/restaurants/{id}/members",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(memberHandler.GetMembers),
+		),
+	)
+
+	mux.Handle("PUT /api/restaurants/{id}/members/{user_id}",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(memberHandler.UpdateMember),
+		),
+	)
+
+	mux.Handle("DELETE /api/restaurants/{id}/members/{user_id}",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(memberHandler.RemoveMember),
+		),
+	)
+
+	mux.Handle("POST /api/restaurants/{id}/invitations",
+		middleware.Auth(cfg)(
+			limiter.PerUser("invite-member", inviteLimit)(
+				http.HandlerFunc(memberHandler.InviteMember),
+			),
+		),
+	)
+
+	mux.Handle("GET /api/restaurants/{id}/invitations",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(memberHandler.GetInvitations),
+		),
+	)
+
+	mux.Handle("DELETE /api/restaurants/{id}/invitations/{invitation_id}",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(memberHandler.RevokeInvitation),
+		),
+	)
+
+	// Any signed-in account can accept an invitation sent to its email
+	mux.Handle("POST /api/invitations/accept",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(memberHandler.AcceptInvitation),
+		),
+	)
+
+	// ====== BRAND ROUTES ======
+
+	// Public routes
+	mux.HandleFunc("GET /api/brands/{id}", brandHandler.GetBrandByID)
+	mux.HandleFunc("GET /api/brands/{id}/menu", brandHandler.GetBrandMenu)
+
+	// Protected routes. Creating a brand takes a restaurant_owner account;
+	// the rest is for the brand's owner (see service.Authorizer).
+	mux.Handle("POST /api/brands",
 		middleware.Auth(cfg)(
 			middleware.RequireRole("restaurant_owner")(
-				http.HandlerFunc(orderHandler.UpdateOrderStatus),
+				http.HandlerFunc(brandHandler.CreateBrand),
 			),
 		),
 	)
 
-	return mux
-}
+	mux.Handle("GET /api/brands/my/list",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(brandHandler.GetMyBrands),
+		),
+	)
+
+	mux.Handle("PUT /api/brands/{id}",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(brandHandler.UpdateBrand),
+		),
+	)
+
+	mux.Handle("GET /api/brands/{id}/dashboard",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(brandHandler.GetDashboard),
+		),
+	)
+
+	mux.Handle("POST /api/brands/{id}/sync",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(brandHandler.SyncBrand),
+		),
+	)
+
+	mux.Handle("POST /api/brands/{id}/outlets",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(brandHandler.AttachOutlet),
+		),
+	)
+
+	mux.Handle("DELETE /api/brands/{id}/outlets/{restaurant_id}",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(brandHandler.DetachOutlet),
+		),
+	)
+
+	mux.Handle("POST /api/brands/{id}/categories",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(brandHandler.CreateCategory),
+		),
+	)
+
+	mux.Handle("DELETE /api/brands/{id}/categories/{category_id}",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(brandHandler.DeleteCategory),
+		),
+	)
+
+	mux.Handle("POST /api/brands/{id}/items",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(brandHandler.CreateMenuItem),
+		),
+	)
+
+	mux.Handle("PUT /api/brands/{id}/items/{item_id}",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(brandHandler.UpdateMenuItem),
+		),
+	)
+
+	mux.Handle("DELETE /api/brands/{id}/items/{item_id}",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(brandHandler.DeleteMenuItem),
+		),
+	)
+
+	// Outlet overrides - require auth and the edit_menu permission at the outlet
+	mux.Handle("GET /api/restaurants/{id}/overrides",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(brandHandler.GetOverrides),
+		),
+	)
+
+	mux.Handle("PUT /api/restaurants/{id}/overrides/{brand_item_id}",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(brandHandler.SetOverride),
+		),
+	)
+
+	mux.Handle("DELETE /api/restaurants/{id}/overrides/{brand_item_id}",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(brandHandler.DeleteOverride),
+		),
+	)
+
+	// ====== DELIVERY ROUTES ======
 
-func healthCheck(w http.ResponseWriter, r *http.Request) {
-	utils.WriteJSON(w, http.StatusOK, map[string]string{
-		"status": "ok",
-		"app":    "QuickBite",
-	})
+	// Delivery partner routes - require a delivery_partner account. Offers
+	// come from the dispatch worker (see DispatchService.Dispatch).
+	mux.Handle("GET /api/partners/me",
+		middleware.Auth(cfg)(
+			middleware.RequireRole("delivery_partner")(
+				http.HandlerFunc(dispatchHandler.GetMyStatus),
+			),
+		),
+	)
+
+	mux.Handle("PUT /api/partners/me/status",
+		middleware.Auth(cfg)(
+			middleware.RequireRole("delivery_partner")(
+				http.HandlerFunc(dispatchHandler.SetStatus),
+			),
+		),
+	)
+
+	mux.Handle("POST /api/partners/me/location",
+		middleware.Auth(cfg)(
+			middleware.RequireRole("delivery_partner")(
+				http.HandlerFunc(dispatchHandler.UpdateLocation),
+			),
+		),
+	)
+
+	mux.Handle("GET /api/partners/me/deliveries",
+		middleware.Auth(cfg)(
+			middleware.RequireRole("delivery_partner")(
+				http.HandlerFunc(dispatchHandler.GetMyTasks),
+			),
+		),
+	)
+
+	mux.Handle("POST /api/deliveries/{id}/accept",
+		middleware.Auth(cfg)(
+			middleware.RequireRole("delivery_partner")(
+				http.HandlerFunc(dispatchHandler.AcceptOffer),
+			),
+		),
+	)
+
+	mux.Handle("POST /api/deliveries/{id}/reject",
+		middleware.Auth(cfg)(
+			middleware.RequireRole("delivery_partner")(
+				http.HandlerFunc(dispatchHandler.RejectOffer),
+			),
+		),
+	)
+
+	mux.Handle("POST /api/deliveries/{id}/pickup",
+		middleware.Auth(cfg)(
+			middleware.RequireRole("delivery_partner")(
+				http.HandlerFunc(dispatchHandler.PickUp),
+			),
+		),
+	)
+
+	mux.Handle("POST /api/deliveries/{id}/deliver",
+		middleware.Auth(cfg)(
+			middleware.RequireRole("delivery_partner")(
+				http.HandlerFunc(dispatchHandler.CompleteDelivery),
+			),
+		),
+	)
+
+	// The customer who placed the order, or staff of the restaurant
+	mux.Handle("GET /api/orders/{id}/delivery",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(dispatchHandler.GetOrderDelivery),
+		),
+	)
+
+	// Require auth and the edit_restaurant permission
+	mux.Handle("PUT /api/restaurants/{id}/location",
+		middleware.Auth(cfg)(
+			http.HandlerFunc(dispatchHandler.SetRestaurantLocation),
+		),
+	)
+
+	// ====== ADMIN ROUTES ======
+
+	// Require an admin account. Users can't register as admin; the role is
+	// granted in the database.
+	mux.Handle("GET /api/admin/jobs",
+		middleware.Auth(cfg)(
+			middleware.RequireRole("admin")(
+				http.HandlerFunc(jobHandler.ListJobs),
+			),
+		),
+	)
+
+	mux.Handle("GET /api/admin/jobs/stats",
+		middleware.Auth(cfg)(
+			middleware.RequireRole("admin")(
+				http.HandlerFunc(jobHandler.GetJobStats),
+			),
+		),
+	)
+
+	mux.Handle("GET /api/admin/jobs/{id}",
+		middleware.Auth(cfg)(
+			middleware.RequireRole("admin")(
+				http.HandlerFunc(jobHandler.GetJob),
+			),
+		),
+	)
+
+	mux.Handle("POST /api/admin/jobs/{id}/retry",
+		middleware.Auth(cfg)(
+			middleware.RequireRole("admin")(
+				http.HandlerFunc(jobHandler.RetryJob),
+			),
+		),
+	)
+
+	return mux
 }
@@ -0,0 +1,116 @@
+// Package health runs the readiness checks behind /readyz. Subsystems
+// register their own checks, so adding a dependency doesn't mean touching
+// the handler.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Check returns nil when the dependency is usable
+type Check func(ctx context.Context) error
+
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded" // a non-critical check failed
+	StatusFail     = "fail"     // a critical check failed
+)
+
+// Each check gets this long before it counts as failed
+const checkTimeout = 2 * time.Second
+
+type Registry struct {
+	mu     sync.RWMutex
+	checks map[string]registered
+}
+
+type registered struct {
+	check    Check
+	critical bool
+}
+
+type Result struct {
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+func NewRegistry() *Registry {
+	return &Registry{checks: make(map[string]registered)}
+}
+
+// Register adds or replaces a check. A failing critical check takes the
+// instance out of rotation; a failing non-critical one only degrades it.
+func (r *Registry) Register(name string, critical bool, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checks[name] = registered{check: check, critical: critical}
+}
+
+// Run executes every check concurrently, each under its own timeout
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := make(map[string]registered, len(r.checks))
+	for name, c := range r.checks {
+		checks[name] = c
+	}
+	r.mu.RUnlock()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := runCheck(ctx, c)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status == StatusOK {
+			continue
+		}
+		if result.Critical {
+			report.Status = StatusFail
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report
+}
+
+func runCheck(ctx context.Context, c registered) Result {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.check(ctx)
+
+	result := Result{
+		Status:    StatusOK,
+		Critical:  c.critical,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
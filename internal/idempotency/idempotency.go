@@ -0,0 +1,56 @@
+// Package idempotency stores the responses to requests sent with an
+// Idempotency-Key so a retried request gets the original answer instead of
+// running twice. It has an in-memory backend for single instances and a
+// Postgres one shared across instances.
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Key scopes a client's Idempotency-Key to the user who sent it, so two
+// users picking the same key never see each other's responses
+type Key struct {
+	UserID string
+	Key    string
+}
+
+// Response is what gets replayed
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Record is the stored state of a key. Response is nil while the first
+// request is still running.
+type Record struct {
+	Fingerprint string
+	Response    *Response
+}
+
+// Claim is a request's hold on a key, from Begin. Once its lock expires a
+// retry can take the key over; the old claim then no longer matches, so
+// the first request finishing late can't overwrite or drop the new one.
+type Claim struct {
+	Key         Key
+	Fingerprint string
+	ClaimedAt   time.Time
+}
+
+// Store claims and completes keys. Expired records behave as if they were
+// never there.
+type Store interface {
+	// Begin claims key for a request with the given fingerprint, holding
+	// it for at most lockFor. If key is already claimed or completed it
+	// returns that record and a nil claim instead.
+	Begin(ctx context.Context, key Key, fingerprint string, lockFor time.Duration) (*Record, *Claim, error)
+	// Complete saves the response and keeps it for ttl, if claim still
+	// holds the key
+	Complete(ctx context.Context, claim *Claim, resp Response, ttl time.Duration) error
+	// Release drops a claim whose request failed, so a retry runs again.
+	// A claim that was taken over is left alone.
+	Release(ctx context.Context, claim *Claim) error
+}
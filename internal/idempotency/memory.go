@@ -0,0 +1,93 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// How many Begins between sweeps of expired records
+const sweepEvery = 1000
+
+type entry struct {
+	record    Record
+	claimedAt time.Time
+	expiresAt time.Time
+}
+
+// MemoryStore keeps records in process; each instance only knows its own
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[Key]*entry
+	begins  int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[Key]*entry)}
+}
+
+func (s *MemoryStore) Begin(_ context.Context, key Key, fingerprint string, lockFor time.Duration) (*Record, *Claim, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	s.begins++
+	if s.begins%sweepEvery == 0 {
+		s.sweep(now)
+	}
+
+	if e, ok := s.entries[key]; ok && now.Before(e.expiresAt) {
+		record := e.record
+		return &record, nil, nil
+	}
+
+	s.entries[key] = &entry{
+		record:    Record{Fingerprint: fingerprint},
+		claimedAt: now,
+		expiresAt: now.Add(lockFor),
+	}
+	return nil, &Claim{Key: key, Fingerprint: fingerprint, ClaimedAt: now}, nil
+}
+
+func (s *MemoryStore) Complete(_ context.Context, claim *Claim, resp Response, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.held(claim)
+	if !ok {
+		return nil
+	}
+	resp.Header = resp.Header.Clone()
+	resp.Body = append([]byte(nil), resp.Body...)
+	e.record.Response = &resp
+	e.expiresAt = time.Now().Add(ttl)
+	return nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, claim *Claim) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.held(claim); ok {
+		delete(s.entries, claim.Key)
+	}
+	return nil
+}
+
+// held returns claim's entry if the claim still holds its key
+func (s *MemoryStore) held(claim *Claim) (*entry, bool) {
+	e, ok := s.entries[claim.Key]
+	if !ok || e.record.Response != nil || !e.claimedAt.Equal(claim.ClaimedAt) || e.record.Fingerprint != claim.Fingerprint {
+		return nil, false
+	}
+	return e, true
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
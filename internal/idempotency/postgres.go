@@ -0,0 +1,119 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresStore keeps records in idempotency_keys so a retry that lands on
+// another instance still finds the original. The primary key is the lock:
+// only one insert per (user, key) can win.
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{pool: pool}
+}
+
+// Begin claims the key or reads its record in one transaction. When the
+// insert conflicts, ON CONFLICT DO UPDATE locks the row even though it
+// doesn't change it, so a Release can't delete it before the read.
+func (s *PostgresStore) Begin(ctx context.Context, key Key, fingerprint string, lockFor time.Duration) (*Record, *Claim, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// An expired row is taken over as if it were new
+	claim := Claim{Key: key, Fingerprint: fingerprint}
+	err = tx.QueryRow(ctx, `
+		INSERT INTO idempotency_keys (user_id, key, fingerprint, expires_at)
+		VALUES ($1, $2, $3, NOW() + $4::interval)
+		ON CONFLICT (user_id, key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint,
+		    status = NULL, headers = NULL, body = NULL,
+		    created_at = NOW(), expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= NOW()
+		RETURNING created_at
+	`, key.UserID, key.Key, fingerprint, lockFor).Scan(&claim.ClaimedAt)
+	if err == nil {
+		if err := tx.Commit(ctx); err != nil {
+			return nil, nil, err
+		}
+		return nil, &claim, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, err
+	}
+
+	var (
+		record  Record
+		status  *int
+		headers []byte
+		body    []byte
+	)
+	err = tx.QueryRow(ctx, `
+		SELECT fingerprint, status, headers, body
+		FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2
+	`, key.UserID, key.Key).Scan(&record.Fingerprint, &status, &headers, &body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	if status != nil {
+		record.Response = &Response{Status: *status, Body: body}
+		if err := json.Unmarshal(headers, &record.Response.Header); err != nil {
+			return nil, nil, err
+		}
+	}
+	return &record, nil, nil
+}
+
+// heldBy matches the row only while claim still holds it: not completed,
+// and not taken over by a later claim since
+const heldBy = `
+	user_id = $1 AND key = $2 AND fingerprint = $3 AND created_at = $4 AND status IS NULL`
+
+func (s *PostgresStore) Complete(ctx context.Context, claim *Claim, resp Response, ttl time.Duration) error {
+	headers, err := json.Marshal(resp.Header)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.pool.Exec(ctx, `
+		UPDATE idempotency_keys
+		SET status = $5, headers = $6, body = $7, expires_at = NOW() + $8::interval
+		WHERE`+heldBy,
+		claim.Key.UserID, claim.Key.Key, claim.Fingerprint, claim.ClaimedAt,
+		resp.Status, headers, resp.Body, ttl)
+	return err
+}
+
+func (s *PostgresStore) Release(ctx context.Context, claim *Claim) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE`+heldBy,
+		claim.Key.UserID, claim.Key.Key, claim.Fingerprint, claim.ClaimedAt)
+	return err
+}
+
+// PurgeExpired deletes records past their expiry; Begin already ignores
+// them, this just keeps the table small
+func (s *PostgresStore) PurgeExpired(ctx context.Context) (int64, error) {
+	tag, err := s.pool.Exec(ctx, `
+		DELETE FROM idempotency_keys WHERE expires_at <= NOW()
+	`)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
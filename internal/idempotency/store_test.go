@@ -0,0 +1,161 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"quickbite/db"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testStore runs the Store contract against store; userID must be a user
+// the store can key records to
+func testStore(t *testing.T, store Store, userID string) {
+	ctx := context.Background()
+	resp := Response{Status: http.StatusCreated, Header: http.Header{"Location": {"/api/orders/1"}}, Body: []byte(`{"id":"1"}`)}
+
+	// Each subtest gets its own key, unique across runs against one database
+	run := time.Now().UnixNano()
+	n := 0
+	newKey := func() Key {
+		n++
+		return Key{UserID: userID, Key: fmt.Sprintf("test-%d-%d", run, n)}
+	}
+
+	begin := func(t *testing.T, key Key, fingerprint string, lockFor time.Duration) (*Record, *Claim) {
+		t.Helper()
+		record, claim, err := store.Begin(ctx, key, fingerprint, lockFor)
+		if err != nil {
+			t.Fatalf("Begin: %v", err)
+		}
+		if (record == nil) == (claim == nil) {
+			t.Fatalf("Begin = %v, %v; want exactly one of record and claim", record, claim)
+		}
+		return record, claim
+	}
+
+	t.Run("in flight", func(t *testing.T) {
+		key := newKey()
+		begin(t, key, "fp", time.Minute)
+
+		record, _ := begin(t, key, "fp", time.Minute)
+		if record.Fingerprint != "fp" || record.Response != nil {
+			t.Errorf("second Begin = %+v, want the running claim without a response", record)
+		}
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		key := newKey()
+		_, claim := begin(t, key, "fp", time.Minute)
+		if err := store.Complete(ctx, claim, resp, time.Hour); err != nil {
+			t.Fatalf("Complete: %v", err)
+		}
+
+		record, _ := begin(t, key, "other", time.Minute)
+		if record.Fingerprint != "fp" || record.Response == nil {
+			t.Fatalf("Begin after Complete = %+v, want the stored response", record)
+		}
+		got := record.Response
+		if got.Status != resp.Status || string(got.Body) != string(resp.Body) || got.Header.Get("Location") != "/api/orders/1" {
+			t.Errorf("replayed %+v, want %+v", got, resp)
+		}
+	})
+
+	t.Run("release", func(t *testing.T) {
+		key := newKey()
+		_, claim := begin(t, key, "fp", time.Minute)
+		if err := store.Release(ctx, claim); err != nil {
+			t.Fatalf("Release: %v", err)
+		}
+
+		if _, claim := begin(t, key, "fp", time.Minute); claim == nil {
+			t.Error("Begin after Release found a record, want a new claim")
+		}
+	})
+
+	t.Run("reclaim after expiry", func(t *testing.T) {
+		key := newKey()
+		_, stale := begin(t, key, "fp", 50*time.Millisecond)
+		time.Sleep(100 * time.Millisecond)
+
+		_, fresh := begin(t, key, "fp", time.Minute)
+		if fresh == nil {
+			t.Fatal("Begin after the lock expired found a record, want a new claim")
+		}
+
+		// The first request finishing late must not touch the new claim
+		if err := store.Complete(ctx, stale, resp, time.Hour); err != nil {
+			t.Fatalf("stale Complete: %v", err)
+		}
+		if err := store.Release(ctx, stale); err != nil {
+			t.Fatalf("stale Release: %v", err)
+		}
+		record, _ := begin(t, key, "fp", time.Minute)
+		if record.Response != nil {
+			t.Errorf("stale claim completed the new one: %+v", record.Response)
+		}
+
+		if err := store.Complete(ctx, fresh, resp, time.Hour); err != nil {
+			t.Fatalf("Complete: %v", err)
+		}
+		if record, _ := begin(t, key, "fp", time.Minute); record.Response == nil {
+			t.Error("new claim's response wasn't stored")
+		}
+	})
+
+	t.Run("completed record expires", func(t *testing.T) {
+		key := newKey()
+		_, claim := begin(t, key, "fp", time.Minute)
+		if err := store.Complete(ctx, claim, resp, 50*time.Millisecond); err != nil {
+			t.Fatalf("Complete: %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+
+		if _, claim := begin(t, key, "other", time.Minute); claim == nil {
+			t.Error("Begin after the record expired found it, want a new claim")
+		}
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore(), "user-1")
+}
+
+// TestPostgresStore runs against the database in TEST_DATABASE_URL, which
+// it migrates; it is skipped when that isn't set
+func TestPostgresStore(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	if _, err := db.MigrateUp(ctx, pool); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	var userID string
+	err = pool.QueryRow(ctx, `
+		INSERT INTO users (name, email, password)
+		VALUES ('Idempotency Test', 'idempotency-' || gen_random_uuid() || '@example.com', 'x')
+		RETURNING id
+	`).Scan(&userID)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM users WHERE id = $1`, userID)
+	})
+
+	testStore(t, NewPostgresStore(pool), userID)
+}
@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	// Monday 19 October 2026
+	monday := time.Date(2026, 10, 19, 10, 7, 30, 0, time.UTC)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"every minute", "* * * * *", monday, at(19, 10, 8)},
+		{"step", "*/15 * * * *", monday, at(19, 10, 15)},
+		{"step from an offset", "5/20 * * * *", monday, at(19, 10, 25)},
+		{"range", "0 13-15 * * *", monday, at(19, 13, 0)},
+		{"range with step", "0 9-17/4 * * *", monday, at(19, 13, 0)},
+		{"list", "0 8,12-14 * * *", at(19, 14, 0), at(20, 8, 0)},
+		{"rolls over the hour", "5 * * * *", monday, at(19, 11, 5)},
+		{"day of month", "0 0 21 * *", monday, at(21, 0, 0)},
+		{"month", "0 0 1 1 *", monday, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"sunday as 0", "30 2 * * 0", monday, at(25, 2, 30)},
+		{"sunday as 7", "30 2 * * 7", monday, at(25, 2, 30)},
+		{"weekday range", "0 9 * * 1-5", at(23, 10, 0), at(26, 9, 0)},
+		// Both day fields restricted: either one matching is enough
+		{"either day, weekday first", "0 0 30 * 5", monday, at(23, 0, 0)},
+		{"either day, date first", "0 0 20 * 5", monday, at(20, 0, 0)},
+		{"every", "@every 90m", monday, at(19, 10, 30)},
+		{"never", "0 0 31 2 *", monday, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseSchedule(%q): %v", tt.spec, err)
+			}
+			if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseScheduleRejects(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"1-x * * * *",
+		"@every 500ms",
+		"@every soon",
+	} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) accepted it", spec)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Package jobs runs background work from a durable queue. Jobs are claimed
+// one at a time with SELECT ... FOR UPDATE SKIP LOCKED, so any number of
+// instances can share the queue without running a job twice. A failed job
+// is retried with exponential backoff and parked as dead once it runs out
+// of attempts. Cron schedules enqueue jobs of their own, once per slot
+// across all instances. It has a Postgres backend and an in-memory one for
+// development.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// DefaultMaxAttempts is how many times a job runs before it's dead, unless
+// it says otherwise
+const DefaultMaxAttempts = 5
+
+// Backoff between attempts: doubling from the base, up to the cap
+const (
+	backoffBase = 10 * time.Second
+	backoffCap  = time.Hour
+)
+
+var (
+	ErrNotFound = errors.New("jobs: job not found")
+	// ErrNoJob is Claim finding nothing ready to run
+	ErrNoJob = errors.New("jobs: no job ready")
+	// ErrDuplicate is Enqueue finding a job with the same unique key
+	ErrDuplicate = errors.New("jobs: job already enqueued")
+	// ErrNotDead is Requeue on a job that hasn't failed for good
+	ErrNotDead = errors.New("jobs: job is not dead")
+)
+
+// Statuses a job moves through. A running job whose lease runs out is
+// claimed again, as if it had failed.
+const (
+	StatusQueued  = "queued"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusDead    = "dead"
+)
+
+type Job struct {
+	ID          string          `json:"id"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"` // runs started so far, including the current one
+	MaxAttempts int             `json:"max_attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+	UniqueKey   string          `json:"unique_key,omitempty"` // at most one job per key, ever
+	RunAt       time.Time       `json:"run_at"`               // not before; zero means now
+	LockedUntil *time.Time      `json:"locked_until,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+}
+
+// New makes a job of kind with payload encoded as JSON, ready to enqueue
+func New(kind string, payload any) (*Job, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &Job{Kind: kind, Payload: raw, MaxAttempts: DefaultMaxAttempts}, nil
+}
+
+// Handler runs one job. Returning an error retries it, until the job is
+// out of attempts. ctx ends when the job's lease does.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// Task adapts a function that takes no payload, such as a periodic sweep
+func Task(fn func(ctx context.Context) error) Handler {
+	return func(ctx context.Context, _ json.RawMessage) error {
+		return fn(ctx)
+	}
+}
+
+// Filter narrows List; empty fields match everything
+type Filter struct {
+	Status string
+	Kind   string
+	Limit  int
+}
+
+// Count is how many jobs of a kind are in a status
+type Count struct {
+	Kind   string `json:"kind"`
+	Status string `json:"status"`
+	Jobs   int    `json:"jobs"`
+}
+
+type Store interface {
+	// Enqueue saves job as queued, filling in its ID and timestamps.
+	// Returns ErrDuplicate if its unique key is already taken.
+	Enqueue(ctx context.Context, job *Job) error
+	// Claim takes the ready job of one of kinds that has waited longest,
+	// marks it running for lease and counts the attempt. Returns ErrNoJob
+	// when there is none.
+	Claim(ctx context.Context, kinds []string, lease time.Duration) (*Job, error)
+	// Complete marks a running job done
+	Complete(ctx context.Context, id string) error
+	// Reschedule puts a failed job back in the queue to run after delay
+	Reschedule(ctx context.Context, id string, lastErr string, delay time.Duration) error
+	// Bury marks a failed job dead
+	Bury(ctx context.Context, id string, lastErr string) error
+
+	Get(ctx context.Context, id string) (*Job, error)
+	// List returns matching jobs, newest first
+	List(ctx context.Context, filter Filter) ([]Job, error)
+	Counts(ctx context.Context) ([]Count, error)
+	// Requeue gives a dead job a fresh set of attempts, to run now
+	Requeue(ctx context.Context, id string) error
+	// PurgeFinished deletes done and dead jobs that finished more than
+	// olderThan ago
+	PurgeFinished(ctx context.Context, olderThan time.Duration) (int64, error)
+}
+
+// backoff is the wait after a job's attempt-th failed run, with up to 10%
+// jitter so jobs that failed together don't all retry together
+func backoff(attempt int) time.Duration {
+	delay := backoffCap
+	if attempt < 20 {
+		delay = min(backoffBase<<(attempt-1), backoffCap)
+	}
+	return delay + rand.N(delay/10+1)
+}
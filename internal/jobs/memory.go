@@ -0,0 +1,227 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps the queue in process; jobs are lost on restart and each
+// instance only works its own
+type MemoryStore struct {
+	mu   sync.Mutex
+	jobs map[string]*Job
+	keys map[string]bool // unique keys of the jobs kept
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: make(map[string]*Job), keys: make(map[string]bool)}
+}
+
+func (s *MemoryStore) Enqueue(_ context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if job.UniqueKey != "" {
+		if s.keys[job.UniqueKey] {
+			return ErrDuplicate
+		}
+		s.keys[job.UniqueKey] = true
+	}
+
+	now := time.Now()
+	if job.RunAt.IsZero() {
+		job.RunAt = now
+	}
+	if job.MaxAttempts == 0 {
+		job.MaxAttempts = DefaultMaxAttempts
+	}
+	if len(job.Payload) == 0 {
+		job.Payload = []byte("{}")
+	}
+	job.ID = newID()
+	job.Status = StatusQueued
+	job.CreatedAt = now
+	job.UpdatedAt = now
+
+	stored := *job
+	s.jobs[job.ID] = &stored
+	return nil
+}
+
+func (s *MemoryStore) Claim(_ context.Context, kinds []string, lease time.Duration) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var next *Job
+	for _, job := range s.jobs {
+		if !slices.Contains(kinds, job.Kind) || job.RunAt.After(now) {
+			continue
+		}
+		leaseExpired := job.Status == StatusRunning && job.LockedUntil.Before(now)
+		if job.Status != StatusQueued && !leaseExpired {
+			continue
+		}
+		if next == nil || job.RunAt.Before(next.RunAt) {
+			next = job
+		}
+	}
+	if next == nil {
+		return nil, ErrNoJob
+	}
+
+	lockedUntil := now.Add(lease)
+	next.Status = StatusRunning
+	next.Attempts++
+	next.LockedUntil = &lockedUntil
+	next.UpdatedAt = now
+
+	claimed := *next
+	return &claimed, nil
+}
+
+func (s *MemoryStore) Complete(_ context.Context, id string) error {
+	return s.finish(id, StatusDone, "")
+}
+
+func (s *MemoryStore) Reschedule(_ context.Context, id string, lastErr string, delay time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil
+	}
+	now := time.Now()
+	job.Status = StatusQueued
+	job.LastError = lastErr
+	job.RunAt = now.Add(delay)
+	job.LockedUntil = nil
+	job.UpdatedAt = now
+	return nil
+}
+
+func (s *MemoryStore) Bury(_ context.Context, id string, lastErr string) error {
+	return s.finish(id, StatusDead, lastErr)
+}
+
+func (s *MemoryStore) finish(id string, status string, lastErr string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil
+	}
+	now := time.Now()
+	job.Status = status
+	if lastErr != "" {
+		job.LastError = lastErr
+	}
+	job.LockedUntil = nil
+	job.FinishedAt = &now
+	job.UpdatedAt = now
+	return nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	found := *job
+	return &found, nil
+}
+
+func (s *MemoryStore) List(_ context.Context, filter Filter) ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := []Job{}
+	for _, job := range s.jobs {
+		if (filter.Status == "" || job.Status == filter.Status) && (filter.Kind == "" || job.Kind == filter.Kind) {
+			jobs = append(jobs, *job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+	})
+	if len(jobs) > filter.Limit {
+		jobs = jobs[:filter.Limit]
+	}
+	return jobs, nil
+}
+
+func (s *MemoryStore) Counts(_ context.Context) ([]Count, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byKey := make(map[[2]string]int)
+	for _, job := range s.jobs {
+		byKey[[2]string{job.Kind, job.Status}]++
+	}
+
+	counts := []Count{}
+	for key, n := range byKey {
+		counts = append(counts, Count{Kind: key[0], Status: key[1], Jobs: n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Kind != counts[j].Kind {
+			return counts[i].Kind < counts[j].Kind
+		}
+		return counts[i].Status < counts[j].Status
+	})
+	return counts, nil
+}
+
+func (s *MemoryStore) Requeue(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if job.Status != StatusDead {
+		return ErrNotDead
+	}
+	now := time.Now()
+	job.Status = StatusQueued
+	job.Attempts = 0
+	job.RunAt = now
+	job.FinishedAt = nil
+	job.UpdatedAt = now
+	return nil
+}
+
+func (s *MemoryStore) PurgeFinished(_ context.Context, olderThan time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	cutoff := time.Now().Add(-olderThan)
+	for id, job := range s.jobs {
+		if job.FinishedAt != nil && job.FinishedAt.Before(cutoff) {
+			delete(s.jobs, id)
+			delete(s.keys, job.UniqueKey)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,258 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type cronEntry struct {
+	kind     string
+	schedule Schedule
+	next     time.Time
+}
+
+// Pool runs jobs from a Store on a fixed number of workers, and enqueues
+// cron jobs when they fall due. Register handlers and schedules, then
+// Start; Stop at shutdown.
+type Pool struct {
+	store   Store
+	workers int
+	poll    time.Duration // how long an idle worker waits before looking again
+	lease   time.Duration // how long a claimed job may run
+
+	handlers map[string]Handler
+	kinds    []string
+	crons    []*cronEntry
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu       sync.Mutex
+	lastPoll time.Time
+	lastErr  error
+}
+
+func NewPool(store Store, workers int, poll, lease time.Duration) *Pool {
+	return &Pool{
+		store:    store,
+		workers:  max(workers, 1),
+		poll:     poll,
+		lease:    lease,
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Register sets the handler for jobs of kind. Only registered kinds are
+// claimed, so instances can run different sets of handlers.
+func (p *Pool) Register(kind string, handler Handler) {
+	if _, ok := p.handlers[kind]; !ok {
+		p.kinds = append(p.kinds, kind)
+	}
+	p.handlers[kind] = handler
+}
+
+// Cron enqueues a job of kind, with no payload, in every slot of spec (see
+// ParseSchedule). Each slot runs once however many instances share the
+// queue. A cron job gets a single attempt: the next slot is its retry.
+// Slots missed while no instance was running are skipped.
+func (p *Pool) Cron(spec string, kind string, handler Handler) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+	if schedule.Next(time.Now()).IsZero() {
+		return fmt.Errorf("cron %q never runs", spec)
+	}
+	p.Register(kind, handler)
+	p.crons = append(p.crons, &cronEntry{kind: kind, schedule: schedule})
+	return nil
+}
+
+// Start launches the workers and the cron scheduler. Not safe for
+// concurrent use; start the pool from main.
+func (p *Pool) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.beat(nil)
+
+	now := time.Now()
+	for _, c := range p.crons {
+		c.next = c.schedule.Next(now)
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.schedule(ctx)
+	}()
+
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.work(ctx)
+		}()
+	}
+
+	slog.Info("job pool started", "workers", p.workers, "kinds", len(p.kinds), "cron", len(p.crons))
+}
+
+// Stop lets running jobs finish and waits for the workers to exit. If ctx
+// expires first it returns without waiting; jobs still running are picked
+// up again once their lease runs out.
+func (p *Pool) Stop(ctx context.Context) error {
+	if p.cancel == nil {
+		return nil
+	}
+	p.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		slog.Info("job pool stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Check fails when the workers haven't reached the store lately
+func (p *Pool) Check(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if since := time.Since(p.lastPoll); since > 2*p.poll+p.lease {
+		return fmt.Errorf("no poll for %s", since.Round(time.Second))
+	}
+	if p.lastErr != nil {
+		return fmt.Errorf("last poll failed: %w", p.lastErr)
+	}
+	return nil
+}
+
+func (p *Pool) beat(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.lastPoll = time.Now()
+	p.lastErr = err
+}
+
+// work claims and runs jobs until ctx is cancelled, waiting a poll
+// interval whenever the queue has nothing ready
+func (p *Pool) work(ctx context.Context) {
+	for {
+		job, err := p.store.Claim(ctx, p.kinds, p.lease)
+		if errors.Is(err, ErrNoJob) {
+			err = nil
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		p.beat(err)
+		if err != nil {
+			slog.Error("job claim failed", "error", err)
+		}
+
+		if job != nil {
+			p.run(ctx, job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.poll):
+		}
+	}
+}
+
+// run runs one claimed job and records how it went. Recording uses its
+// own context so a job that finishes during shutdown is still marked.
+func (p *Pool) run(ctx context.Context, job *Job) {
+	log := slog.With("job_id", job.ID, "kind", job.Kind, "attempt", job.Attempts)
+
+	var err error
+	if job.Attempts > job.MaxAttempts {
+		// Its last attempt's lease ran out, most likely in a crash
+		err = errors.New("lease expired on the last attempt")
+	} else {
+		err = p.call(ctx, job)
+	}
+
+	recordCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	switch {
+	case err == nil:
+		err = p.store.Complete(recordCtx, job.ID)
+	case job.Attempts >= job.MaxAttempts:
+		log.Error("job failed for good", "error", err)
+		err = p.store.Bury(recordCtx, job.ID, err.Error())
+	default:
+		delay := backoff(job.Attempts)
+		log.Warn("job failed, will retry", "error", err, "retry_in", delay.Round(time.Second).String())
+		err = p.store.Reschedule(recordCtx, job.ID, err.Error(), delay)
+	}
+	if err != nil {
+		log.Error("job result not saved", "error", err)
+	}
+}
+
+// call runs the handler within the job's lease, turning a panic into an
+// error so one bad job can't take down its worker
+func (p *Pool) call(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, p.lease)
+	defer cancel()
+	return p.handlers[job.Kind](ctx, job.Payload)
+}
+
+// schedule enqueues cron jobs as their slots come round. The slot's time
+// is in the unique key, so of all the instances enqueuing it, one wins.
+func (p *Pool) schedule(ctx context.Context) {
+	if len(p.crons) == 0 {
+		return
+	}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, c := range p.crons {
+				if now.Before(c.next) {
+					continue
+				}
+				job := &Job{
+					Kind:        c.kind,
+					MaxAttempts: 1,
+					UniqueKey:   "cron:" + c.kind + ":" + strconv.FormatInt(c.next.Unix(), 10),
+					RunAt:       c.next,
+				}
+				if err := p.store.Enqueue(ctx, job); err != nil && !errors.Is(err, ErrDuplicate) {
+					slog.Error("cron job not enqueued", "kind", c.kind, "error", err)
+					continue
+				}
+				c.next = c.schedule.Next(now)
+			}
+		}
+	}
+}
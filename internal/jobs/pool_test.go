@@ -0,0 +1,148 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// claimNow makes the job ready, whatever its backoff, and claims it the
+// way a worker would
+func claimNow(t *testing.T, store *MemoryStore, id string) *Job {
+	t.Helper()
+
+	store.mu.Lock()
+	store.jobs[id].RunAt = time.Now()
+	store.mu.Unlock()
+
+	job, err := store.Claim(context.Background(), []string{"test"}, time.Minute)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	return job
+}
+
+func enqueue(t *testing.T, store *MemoryStore, maxAttempts int) *Job {
+	t.Helper()
+
+	job := &Job{Kind: "test", MaxAttempts: maxAttempts}
+	if err := store.Enqueue(context.Background(), job); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	return job
+}
+
+func get(t *testing.T, store *MemoryStore, id string) *Job {
+	t.Helper()
+
+	job, err := store.Get(context.Background(), id)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	return job
+}
+
+func TestPoolRetriesWithBackoff(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	pool := NewPool(store, 1, time.Second, time.Minute)
+
+	calls := 0
+	pool.Register("test", func(ctx context.Context, _ json.RawMessage) error {
+		calls++
+		if calls < 3 {
+			return errors.New("flaky")
+		}
+		return nil
+	})
+	job := enqueue(t, store, 3)
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		before := time.Now()
+		pool.run(ctx, claimNow(t, store, job.ID))
+
+		got := get(t, store, job.ID)
+		if got.Status != StatusQueued || got.LastError != "flaky" || got.Attempts != attempt {
+			t.Fatalf("after failed attempt %d: %+v, want queued with the error", attempt, got)
+		}
+		// Doubling from the base, with up to 10% jitter
+		wait := got.RunAt.Sub(before)
+		base := backoffBase << (attempt - 1)
+		if wait < base || wait > base+base/10+time.Second {
+			t.Errorf("attempt %d retries in %s, want %s plus jitter", attempt, wait, base)
+		}
+		if _, err := store.Claim(ctx, []string{"test"}, time.Minute); !errors.Is(err, ErrNoJob) {
+			t.Errorf("job claimable during its backoff: %v", err)
+		}
+	}
+
+	pool.run(ctx, claimNow(t, store, job.ID))
+	if got := get(t, store, job.ID); got.Status != StatusDone || got.FinishedAt == nil {
+		t.Errorf("after the third attempt: %+v, want done", got)
+	}
+}
+
+func TestPoolBuriesAfterLastAttempt(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	pool := NewPool(store, 1, time.Second, time.Minute)
+
+	calls := 0
+	pool.Register("test", func(ctx context.Context, _ json.RawMessage) error {
+		calls++
+		panic("boom")
+	})
+	job := enqueue(t, store, 2)
+
+	pool.run(ctx, claimNow(t, store, job.ID))
+	if got := get(t, store, job.ID); got.Status != StatusQueued {
+		t.Fatalf("after the first attempt: status %s, want queued", got.Status)
+	}
+
+	pool.run(ctx, claimNow(t, store, job.ID))
+	got := get(t, store, job.ID)
+	if got.Status != StatusDead || got.LastError != "panic: boom" || got.FinishedAt == nil {
+		t.Errorf("after the last attempt: %+v, want dead with the panic", got)
+	}
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want 2", calls)
+	}
+}
+
+func TestPoolBuriesJobWhoseLastLeaseExpired(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	pool := NewPool(store, 1, time.Second, time.Minute)
+
+	calls := 0
+	pool.Register("test", func(ctx context.Context, _ json.RawMessage) error {
+		calls++
+		return nil
+	})
+	job := enqueue(t, store, 1)
+
+	// Its only attempt crashed: the lease ran out and it was claimed again
+	claimNow(t, store, job.ID)
+	store.mu.Lock()
+	expired := time.Now().Add(-time.Second)
+	store.jobs[job.ID].LockedUntil = &expired
+	store.mu.Unlock()
+
+	pool.run(ctx, claimNow(t, store, job.ID))
+	if got := get(t, store, job.ID); got.Status != StatusDead {
+		t.Errorf("status %s, want dead", got.Status)
+	}
+	if calls != 0 {
+		t.Errorf("handler ran %d times, want it not run past its attempts", calls)
+	}
+}
+
+func TestBackoffIsCapped(t *testing.T) {
+	for _, attempt := range []int{10, 20, 100} {
+		if got := backoff(attempt); got < backoffCap || got > backoffCap+backoffCap/10 {
+			t.Errorf("backoff(%d) = %s, want the %s cap plus jitter", attempt, got, backoffCap)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresStore keeps the queue in the jobs table, shared by every instance
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{pool: pool}
+}
+
+const jobColumns = `
+	id, kind, payload, status, attempts, max_attempts, COALESCE(last_error, ''),
+	COALESCE(unique_key, ''), run_at, locked_until, created_at, updated_at, finished_at`
+
+func scanJob(row pgx.Row) (*Job, error) {
+	var job Job
+	err := row.Scan(
+		&job.ID,
+		&job.Kind,
+		&job.Payload,
+		&job.Status,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.LastError,
+		&job.UniqueKey,
+		&job.RunAt,
+		&job.LockedUntil,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&job.FinishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (s *PostgresStore) Enqueue(ctx context.Context, job *Job) error {
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now()
+	}
+	if job.MaxAttempts == 0 {
+		job.MaxAttempts = DefaultMaxAttempts
+	}
+	if len(job.Payload) == 0 {
+		job.Payload = []byte("{}")
+	}
+
+	err := s.pool.QueryRow(ctx, `
+		INSERT INTO jobs (kind, payload, max_attempts, unique_key, run_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+		RETURNING id, status, created_at, updated_at
+	`, job.Kind, job.Payload, job.MaxAttempts, job.UniqueKey, job.RunAt).Scan(
+		&job.ID, &job.Status, &job.CreatedAt, &job.UpdatedAt,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrDuplicate
+	}
+	return err
+}
+
+// Claim locks the oldest ready row, skipping rows other workers have
+// locked, and takes it in the same statement
+func (s *PostgresStore) Claim(ctx context.Context, kinds []string, lease time.Duration) (*Job, error) {
+	job, err := scanJob(s.pool.QueryRow(ctx, `
+		UPDATE jobs
+		SET status = 'running', attempts = attempts + 1,
+		    locked_until = NOW() + $2::interval, updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE kind = ANY($1)
+			  AND run_at <= NOW()
+			  AND (status = 'queued' OR (status = 'running' AND locked_until < NOW()))
+			ORDER BY run_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+jobColumns,
+		kinds, lease))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNoJob
+	}
+	return job, err
+}
+
+func (s *PostgresStore) Complete(ctx context.Context, id string) error {
+	_, err := s.pool.Exec(ctx, `
+		UPDATE jobs
+		SET status = 'done', locked_until = NULL, finished_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`, id)
+	return err
+}
+
+func (s *PostgresStore) Reschedule(ctx context.Context, id string, lastErr string, delay time.Duration) error {
+	_, err := s.pool.Exec(ctx, `
+		UPDATE jobs
+		SET status = 'queued', last_error = $2, run_at = NOW() + $3::interval,
+		    locked_until = NULL, updated_at = NOW()
+		WHERE id = $1
+	`, id, lastErr, delay)
+	return err
+}
+
+func (s *PostgresStore) Bury(ctx context.Context, id string, lastErr string) error {
+	_, err := s.pool.Exec(ctx, `
+		UPDATE jobs
+		SET status = 'dead', last_error = $2, locked_until = NULL,
+		    finished_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`, id, lastErr)
+	return err
+}
+
+func (s *PostgresStore) Get(ctx context.Context, id string) (*Job, error) {
+	job, err := scanJob(s.pool.QueryRow(ctx, `SELECT `+jobColumns+` FROM jobs WHERE id = $1`, id))
+	if isNotFound(err) {
+		return nil, ErrNotFound
+	}
+	return job, err
+}
+
+func (s *PostgresStore) List(ctx context.Context, filter Filter) ([]Job, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+jobColumns+`
+		FROM jobs
+		WHERE ($1 = '' OR status = $1)
+		  AND ($2 = '' OR kind = $2)
+		ORDER BY created_at DESC
+		LIMIT $3
+	`, filter.Status, filter.Kind, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, rows.Err()
+}
+
+func (s *PostgresStore) Counts(ctx context.Context) ([]Count, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT kind, status, COUNT(*)
+		FROM jobs
+		GROUP BY kind, status
+		ORDER BY kind, status
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []Count{}
+	for rows.Next() {
+		var c Count
+		if err := rows.Scan(&c.Kind, &c.Status, &c.Jobs); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+func (s *PostgresStore) Requeue(ctx context.Context, id string) error {
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE jobs
+		SET status = 'queued', attempts = 0, run_at = NOW(),
+		    finished_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = 'dead'
+	`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		if _, err := s.Get(ctx, id); err != nil {
+			return err
+		}
+		return ErrNotDead
+	}
+	return nil
+}
+
+func (s *PostgresStore) PurgeFinished(ctx context.Context, olderThan time.Duration) (int64, error) {
+	tag, err := s.pool.Exec(ctx, `
+		DELETE FROM jobs
+		WHERE status IN ('done', 'dead') AND finished_at < NOW() - $1::interval
+	`, olderThan)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// isNotFound reports whether a lookup by id matched no row
+func isNotFound(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
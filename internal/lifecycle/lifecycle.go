@@ -0,0 +1,29 @@
+// Package lifecycle tracks whether the process is serving traffic or draining
+// for shutdown, so health checks can take it out of rotation.
+package lifecycle
+
+import "sync/atomic"
+
+type Lifecycle struct {
+	ready atomic.Bool
+}
+
+// New starts out not ready; call SetReady once the server is listening
+func New() *Lifecycle {
+	return &Lifecycle{}
+}
+
+func (l *Lifecycle) SetReady() {
+	l.ready.Store(true)
+}
+
+// Ready is false before startup finishes and from the moment draining begins
+func (l *Lifecycle) Ready() bool {
+	return l.ready.Load()
+}
+
+// Drain flips readiness off so load balancers stop sending new traffic.
+// Safe to call more than once.
+func (l *Lifecycle) Drain() {
+	l.ready.Store(false)
+}
@@ -0,0 +1,67 @@
+// Package logging sets up the process-wide slog logger and carries
+// per-request fields (request ID, user, route) through the context so every
+// line logged with a request context is tagged with them.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Setup installs the default logger. format is "json" (default) or "text";
+// level is one of debug, info, warn, error.
+func Setup(level, format string) *slog.Logger {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		slog.Warn("invalid LOG_LEVEL, using info", "value", level)
+		lvl = slog.LevelInfo
+	}
+
+	logger := slog.New(&contextHandler{Handler: newHandler(os.Stdout, lvl, format)})
+	slog.SetDefault(logger)
+	return logger
+}
+
+func newHandler(w io.Writer, level slog.Level, format string) slog.Handler {
+	opts := &slog.HandlerOptions{Level: level}
+	if strings.EqualFold(format, "text") {
+		return slog.NewTextHandler(w, opts)
+	}
+	return slog.NewJSONHandler(w, opts)
+}
+
+// contextHandler adds the request fields and trace IDs found in the record's context
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if info := FromContext(ctx); info != nil {
+		if info.ID != "" {
+			r.AddAttrs(slog.String("request_id", info.ID))
+		}
+		if info.UserID != "" {
+			r.AddAttrs(slog.String("user_id", info.UserID))
+		}
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,25 @@
+package logging
+
+import "context"
+
+// RequestInfo is created once per request by the outermost middleware and
+// shared by pointer, so fields learned deeper in the chain (the user after
+// auth, the route after mux matching) are visible to the access log.
+// It is only touched from the request's own goroutine.
+type RequestInfo struct {
+	ID     string
+	UserID string
+	Route  string
+}
+
+type requestInfoKey struct{}
+
+func WithRequestInfo(ctx context.Context, info *RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// FromContext returns the request's info, or nil outside a request
+func FromContext(ctx context.Context) *RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(*RequestInfo)
+	return info
+}
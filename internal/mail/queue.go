@@ -0,0 +1,41 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+
+	"quickbite/internal/jobs"
+)
+
+// SendKind is the job that sends one queued message
+const SendKind = "email.send"
+
+// Queue is a Mailer that hands messages to the job queue, so a slow or
+// failing mail server delays emails instead of failing the request that
+// sent them. Register SendJob to deliver them.
+type Queue struct {
+	store jobs.Store
+}
+
+func NewQueue(store jobs.Store) *Queue {
+	return &Queue{store: store}
+}
+
+func (q *Queue) Send(ctx context.Context, msg Message) error {
+	job, err := jobs.New(SendKind, msg)
+	if err != nil {
+		return err
+	}
+	return q.store.Enqueue(ctx, job)
+}
+
+// SendJob delivers queued messages through m
+func SendJob(m Mailer) jobs.Handler {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var msg Message
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			return err
+		}
+		return m.Send(ctx, msg)
+	}
+}
@@ -0,0 +1,20 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+)
+
+// Requests that matched no route share one label so scanners probing random
+// paths can't blow up the series count
+const unmatchedRoute = "unmatched"
+
+// ObserveRequest records one finished HTTP request. route is the mux pattern
+// (e.g. "GET /api/orders/{id}"), never the raw path.
+func ObserveRequest(method, route string, status int, elapsed time.Duration) {
+	if route == "" {
+		route = unmatchedRoute
+	}
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(elapsed.Seconds())
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"context"
+
+	"quickbite/internal/model"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	ordersTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orders_total",
+		Help:      "Orders entering each status; status=\"pending\" counts new orders.",
+	}, []string{"status"})
+
+	orderCancellations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "order_cancellations_total",
+		Help:      "Cancelled orders by who cancelled them (customer, restaurant or timeout).",
+	}, []string{"by"})
+
+	orderRevenue = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "order_revenue_total",
+		Help:      "Item total of delivered orders by restaurant.",
+	}, []string{"restaurant_id"})
+)
+
+// OrderEvents turns order lifecycle events from the service layer into counters
+type OrderEvents struct{}
+
+func (OrderEvents) OrderCreated(_ context.Context, order *model.Order) {
+	ordersTotal.WithLabelValues(order.Status).Inc()
+}
+
+func (OrderEvents) OrderStatusChanged(_ context.Context, order *model.Order, status string) {
+	ordersTotal.WithLabelValues(status).Inc()
+	if status == "delivered" {
+		orderRevenue.WithLabelValues(order.RestaurantID).Add(order.TotalAmount)
+	}
+}
+
+func (OrderEvents) OrderCancelled(_ context.Context, _ *model.Order, by string) {
+	orderCancellations.WithLabelValues(by).Inc()
+}
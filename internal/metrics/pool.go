@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector reads pgxpool.Stat on every scrape
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquired    *prometheus.Desc
+	idle        *prometheus.Desc
+	total       *prometheus.Desc
+	max         *prometheus.Desc
+	acquires    *prometheus.Desc
+	emptyWaits  *prometheus.Desc
+	waitSeconds *prometheus.Desc
+}
+
+// RegisterPool exports the pool's connection stats
+func RegisterPool(pool *pgxpool.Pool) {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+
+	Registry.MustRegister(&poolCollector{
+		pool:        pool,
+		acquired:    desc("acquired_connections", "Connections currently checked out of the pool."),
+		idle:        desc("idle_connections", "Connections idle in the pool."),
+		total:       desc("total_connections", "Connections open, acquired or idle."),
+		max:         desc("max_connections", "Configured maximum pool size."),
+		acquires:    desc("acquires_total", "Successful connection acquisitions."),
+		emptyWaits:  desc("empty_acquires_total", "Acquisitions that had to wait because the pool was empty."),
+		waitSeconds: desc("acquire_wait_seconds_total", "Time spent waiting for a connection."),
+	})
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquired
+	ch <- c.idle
+	ch <- c.total
+	ch <- c.max
+	ch <- c.acquires
+	ch <- c.emptyWaits
+	ch <- c.waitSeconds
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(c.acquired, prometheus.GaugeValue, float64(s.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(s.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(s.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.max, prometheus.GaugeValue, float64(s.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(s.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.emptyWaits, prometheus.CounterValue, float64(s.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.waitSeconds, prometheus.CounterValue, s.AcquireDuration().Seconds())
+}
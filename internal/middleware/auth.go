@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"quickbite/config"
+	"quickbite/internal/logging"
 	"quickbite/internal/utils"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -49,6 +50,10 @@ func Auth(cfg *config.Config) func(http.Handler) http.Handler {
 				return
 			}
 
+			if info := logging.FromContext(r.Context()); info != nil {
+				info.UserID, _ = claims["user_id"].(string)
+			}
+
 			ctx := context.WithValue(r.Context(), UserIDKey, claims["user_id"])
 			ctx = context.WithValue(ctx, UserRoleKey, claims["role"])
 			ctx = context.WithValue(ctx, UserEmailKey, claims["email"])
@@ -0,0 +1,195 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"quickbite/internal/idempotency"
+	"quickbite/internal/utils"
+)
+
+const (
+	maxIdempotencyKeyLen = 255
+
+	// How long a claimed key stays locked if its request never finishes,
+	// e.g. the instance died mid-request. Well past any request timeout.
+	idempotencyLockFor = 5 * time.Minute
+
+	// Only this much of the body goes into the fingerprint; handlers reject
+	// anything bigger anyway
+	maxFingerprintBody = 1 << 20
+)
+
+// Headers worth replaying; the rest describe the original exchange, not the result
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// Idempotent makes a route safe to retry. A request with an Idempotency-Key
+// header runs once per user and key; repeats within ttl get the stored
+// response back, a repeat with a different body gets 422, and one that
+// arrives while the first is still running gets 409. Requests without the
+// header run as usual. It must run after Auth.
+func Idempotent(store idempotency.Store, ttl time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Idempotency-Key")
+			userID, _ := r.Context().Value(UserIDKey).(string)
+			if header == "" || userID == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !validIdempotencyKey(header) {
+				utils.WriteError(w, http.StatusBadRequest, "Idempotency-Key must be 1 to 255 printable ASCII characters")
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxFingerprintBody))
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, "failed to read request body")
+				return
+			}
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+
+			key := idempotency.Key{UserID: userID, Key: header}
+			fingerprint := fingerprint(r, body)
+
+			record, claim, err := store.Begin(r.Context(), key, fingerprint, idempotencyLockFor)
+			if err != nil {
+				// Fail closed: running the request unguarded is exactly the
+				// duplicate the client sent the key to avoid
+				slog.ErrorContext(r.Context(), "idempotency check failed", "error", err)
+				w.Header().Set("Retry-After", "1")
+				utils.WriteError(w, http.StatusServiceUnavailable, "could not check Idempotency-Key, please retry")
+				return
+			}
+
+			if claim == nil {
+				switch {
+				case record.Fingerprint != fingerprint:
+					utils.WriteError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+				case record.Response == nil:
+					w.Header().Set("Retry-After", "1")
+					utils.WriteError(w, http.StatusConflict, "a request with this Idempotency-Key is still in progress")
+				default:
+					replay(w, record.Response)
+				}
+				return
+			}
+
+			// The result has to be saved even if the client hung up: that
+			// client is exactly the one about to retry
+			storeCtx := context.WithoutCancel(r.Context())
+			rec := &recordingWriter{ResponseWriter: w}
+			completed := false
+			defer func() {
+				if !completed {
+					if err := store.Release(storeCtx, claim); err != nil {
+						slog.WarnContext(storeCtx, "failed to release idempotency key", "error", err)
+					}
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+
+			status := rec.statusCode()
+			if !replayable(status) {
+				return
+			}
+
+			resp := idempotency.Response{Status: status, Header: http.Header{}, Body: rec.body.Bytes()}
+			for _, name := range replayedHeaders {
+				if v := w.Header().Values(name); len(v) > 0 {
+					resp.Header[name] = v
+				}
+			}
+			if err := store.Complete(storeCtx, claim, resp, ttl); err != nil {
+				slog.ErrorContext(storeCtx, "failed to save idempotent response", "error", err)
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+// replayable reports whether a response is the request's real outcome.
+// Server errors, rate limiting and cancelled or timed out requests are
+// not: the retry should run again.
+func replayable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, 499: // 499: client closed request
+		return false
+	}
+	return status < http.StatusInternalServerError
+}
+
+func replay(w http.ResponseWriter, resp *idempotency.Response) {
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
+
+// fingerprint identifies what was asked, so a reused key with a different
+// request can be told apart from a retry
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.Path+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLen {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// recordingWriter keeps a copy of the response on its way to the client
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	if rw.status == 0 {
+		rw.status = code
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+func (rw *recordingWriter) statusCode() int {
+	if rw.status == 0 {
+		return http.StatusOK
+	}
+	return rw.status
+}
+
+// Unwrap lets http.ResponseController reach Flush and deadlines underneath
+func (rw *recordingWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
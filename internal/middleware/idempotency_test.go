@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"quickbite/internal/idempotency"
+)
+
+// idempotentServer wraps handler in Idempotent over a fresh memory store
+// and counts how often handler runs
+func idempotentServer(ttl time.Duration, handler http.HandlerFunc) (http.Handler, *atomic.Int32) {
+	var calls atomic.Int32
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		handler(w, r)
+	})
+	return Idempotent(idempotency.NewMemoryStore(), ttl)(next), &calls
+}
+
+// send posts body as userID with an Idempotency-Key of key
+func send(h http.Handler, userID, key, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/api/orders", strings.NewReader(body))
+	if key != "" {
+		r.Header.Set("Idempotency-Key", key)
+	}
+	r = r.WithContext(context.WithValue(r.Context(), UserIDKey, userID))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func created(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/orders/1")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(body)
+}
+
+func TestIdempotentReplays(t *testing.T) {
+	h, calls := idempotentServer(time.Hour, created)
+
+	first := send(h, "user-1", "k1", `{"n":1}`)
+	second := send(h, "user-1", "k1", `{"n":1}`)
+
+	if calls.Load() != 1 {
+		t.Fatalf("handler ran %d times, want once", calls.Load())
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Errorf("replay = %d %q, want %d %q", second.Code, second.Body, first.Code, first.Body)
+	}
+	if second.Header().Get("Location") != "/api/orders/1" || second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("replay headers = %v", second.Header())
+	}
+
+	// The key belongs to the user who sent it
+	if send(h, "user-2", "k1", `{"n":1}`); calls.Load() != 2 {
+		t.Errorf("another user's request with the same key didn't run")
+	}
+}
+
+func TestIdempotentRejectsDifferentRequest(t *testing.T) {
+	h, calls := idempotentServer(time.Hour, created)
+
+	send(h, "user-1", "k1", `{"n":1}`)
+	w := send(h, "user-1", "k1", `{"n":2}`)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want 422", w.Code)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("handler ran %d times, want once", calls.Load())
+	}
+}
+
+func TestIdempotentInFlight(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	h, calls := idempotentServer(time.Hour, func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		created(w, r)
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- send(h, "user-1", "k1", `{"n":1}`) }()
+	<-started
+
+	w := send(h, "user-1", "k1", `{"n":1}`)
+	if w.Code != http.StatusConflict || w.Header().Get("Retry-After") == "" {
+		t.Errorf("retry while running = %d, Retry-After %q; want 409 with Retry-After", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	close(release)
+	if first := <-done; first.Code != http.StatusCreated {
+		t.Errorf("first request = %d, want 201", first.Code)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("handler ran %d times, want once", calls.Load())
+	}
+}
+
+func TestIdempotentReleasesOnServerError(t *testing.T) {
+	fail := true
+	h, calls := idempotentServer(time.Hour, func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		created(w, r)
+	})
+
+	if w := send(h, "user-1", "k1", `{"n":1}`); w.Code != http.StatusInternalServerError {
+		t.Fatalf("first request = %d, want 500", w.Code)
+	}
+
+	fail = false
+	if w := send(h, "user-1", "k1", `{"n":1}`); w.Code != http.StatusCreated {
+		t.Errorf("retry = %d, want it to run again and return 201", w.Code)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("handler ran %d times, want twice", calls.Load())
+	}
+}
+
+func TestIdempotentRunsAgainAfterTTL(t *testing.T) {
+	h, calls := idempotentServer(50*time.Millisecond, created)
+
+	send(h, "user-1", "k1", `{"n":1}`)
+	time.Sleep(100 * time.Millisecond)
+
+	// Past the TTL the key is free again, even for a different body
+	if w := send(h, "user-1", "k1", `{"n":2}`); w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("after TTL = %d %v, want a fresh 201", w.Code, w.Header())
+	}
+	if calls.Load() != 2 {
+		t.Errorf("handler ran %d times, want twice", calls.Load())
+	}
+}
+
+func TestIdempotentWithoutKey(t *testing.T) {
+	h, calls := idempotentServer(time.Hour, created)
+
+	send(h, "user-1", "", `{"n":1}`)
+	send(h, "user-1", "", `{"n":1}`)
+	if calls.Load() != 2 {
+		t.Errorf("handler ran %d times, want every request to run", calls.Load())
+	}
+
+	if w := send(h, "user-1", "bad\nkey", `{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("control character in key = %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"quickbite/internal/logging"
+	"quickbite/internal/metrics"
+)
+
+// Metrics counts requests and their latency by route pattern and status.
+// Like Logger it relies on Route having recorded the pattern further in.
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+		next.ServeHTTP(rw, r)
+
+		route := ""
+		if info := logging.FromContext(r.Context()); info != nil {
+			route = info.Route
+		}
+		metrics.ObserveRequest(r.Method, route, rw.statusCode, time.Since(start))
+	})
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"quickbite/internal/ratelimit"
+	"quickbite/internal/utils"
+)
+
+// RateLimiter applies token-bucket limits per route, keyed by client IP or
+// by the authenticated user
+type RateLimiter struct {
+	store      ratelimit.Store
+	trustProxy bool
+}
+
+// NewRateLimiter takes client IPs from X-Forwarded-For only when
+// trustProxy is set; otherwise anyone could pick their own bucket
+func NewRateLimiter(store ratelimit.Store, trustProxy bool) *RateLimiter {
+	return &RateLimiter{store: store, trustProxy: trustProxy}
+}
+
+// PerIP limits each client address separately on the named route
+func (rl *RateLimiter) PerIP(route string, limit ratelimit.Limit) func(http.Handler) http.Handler {
+	return rl.limit(limit, func(r *http.Request) string {
+		return route + "|ip|" + ClientIP(r, rl.trustProxy)
+	})
+}
+
+// PerUser limits each user separately on the named route. It must run after
+// Auth; requests without a user fall back to their IP.
+func (rl *RateLimiter) PerUser(route string, limit ratelimit.Limit) func(http.Handler) http.Handler {
+	return rl.limit(limit, func(r *http.Request) string {
+		if userID, ok := r.Context().Value(UserIDKey).(string); ok && userID != "" {
+			return route + "|user|" + userID
+		}
+		return route + "|ip|" + ClientIP(r, rl.trustProxy)
+	})
+}
+
+func (rl *RateLimiter) limit(limit ratelimit.Limit, key func(r *http.Request) string) func(http.Handler) http.Handler {
+	policy := fmt.Sprintf("%d;w=%d", limit.Burst, int(limit.Window().Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := rl.store.Take(r.Context(), key(r), limit)
+			if err != nil {
+				// Fail open: a limiter outage shouldn't take the API down with it
+				slog.WarnContext(r.Context(), "rate limit check failed", "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Headers from the IETF RateLimit header fields draft
+			w.Header().Set("RateLimit-Policy", policy)
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", seconds(result.ResetAfter))
+
+			if !result.Allowed {
+				w.Header().Set("Retry-After", seconds(result.RetryAfter))
+				utils.WriteError(w, http.StatusTooManyRequests, "too many requests, please slow down")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientIP is the address the request came from. Behind a trusted proxy
+// that's the last X-Forwarded-For entry: the one our proxy appended, as
+// opposed to anything the client put in front of it.
+func ClientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			parts := strings.Split(forwarded, ",")
+			if ip := strings.TrimSpace(parts[len(parts)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// seconds rounds up, so a client that waits that long is never early
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,121 @@
+package model
+
+import "time"
+
+// Brand is a chain of restaurants (its outlets) sharing one master menu
+type Brand struct {
+	ID          string       `json:"id"`
+	OwnerID     string       `json:"owner_id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	LogoURL     string       `json:"logo_url"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	Version     int          `json:"version"` // bumped by every owner edit; also sent as the ETag
+	Outlets     []Restaurant `json:"outlets,omitempty"`
+}
+
+type BrandCategory struct {
+	ID           string          `json:"id"`
+	BrandID      string          `json:"brand_id"`
+	Name         string          `json:"name"`
+	DisplayOrder int             `json:"display_order"`
+	CreatedAt    time.Time       `json:"created_at"`
+	Items        []BrandMenuItem `json:"items,omitempty"`
+}
+
+// BrandMenuItem is a master item. Outlets sell a copy of it, with their
+// override's price and availability where they have one.
+type BrandMenuItem struct {
+	ID          string    `json:"id"`
+	CategoryID  string    `json:"category_id"` // a brand category
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Price       float64   `json:"price"`
+	ImageURL    string    `json:"image_url"`
+	IsAvailable bool      `json:"is_available"`
+	IsVeg       bool      `json:"is_veg"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Version     int       `json:"version"`
+}
+
+// OutletOverride changes a master item at one outlet; nil fields keep the
+// brand's value
+type OutletOverride struct {
+	RestaurantID string    `json:"restaurant_id"`
+	BrandItemID  string    `json:"brand_item_id"`
+	Price        *float64  `json:"price"`
+	IsAvailable  *bool     `json:"is_available"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type CreateBrandRequest struct {
+	Name        string `json:"name" validate:"required,max=150"`
+	Description string `json:"description" validate:"max=2000"`
+	LogoURL     string `json:"logo_url" validate:"max=2048"`
+}
+
+type UpdateBrandRequest struct {
+	Name        string `json:"name" validate:"required,max=150"`
+	Description string `json:"description" validate:"max=2000"`
+	LogoURL     string `json:"logo_url" validate:"max=2048"`
+}
+
+type CreateBrandCategoryRequest struct {
+	Name         string `json:"name" validate:"required,max=100"`
+	DisplayOrder int    `json:"display_order" validate:"min=0"`
+}
+
+type CreateBrandMenuItemRequest struct {
+	CategoryID  string  `json:"category_id" validate:"required,uuid"`
+	Name        string  `json:"name" validate:"required,max=150"`
+	Description string  `json:"description" validate:"max=2000"`
+	Price       float64 `json:"price" validate:"gt=0"`
+	ImageURL    string  `json:"image_url" validate:"max=2048"`
+	IsVeg       bool    `json:"is_veg"`
+}
+
+type UpdateBrandMenuItemRequest struct {
+	CategoryID  string  `json:"category_id" validate:"required,uuid"`
+	Name        string  `json:"name" validate:"required,max=150"`
+	Description string  `json:"description" validate:"max=2000"`
+	Price       float64 `json:"price" validate:"gt=0"`
+	ImageURL    string  `json:"image_url" validate:"max=2048"`
+	IsAvailable bool    `json:"is_available"`
+	IsVeg       bool    `json:"is_veg"`
+}
+
+type AttachOutletRequest struct {
+	RestaurantID string `json:"restaurant_id" validate:"required,uuid"`
+}
+
+// Sending null for both fields is the same as deleting the override
+type SetOverrideRequest struct {
+	Price       *float64 `json:"price" validate:"gt=0"`
+	IsAvailable *bool    `json:"is_available"`
+}
+
+// OutletStats is one outlet's line on the brand dashboard
+type OutletStats struct {
+	RestaurantID    string  `json:"restaurant_id"`
+	Name            string  `json:"name"`
+	City            string  `json:"city"`
+	Orders          int     `json:"orders"` // delivered
+	Revenue         float64 `json:"revenue"`
+	ActiveOrders    int     `json:"active_orders"`
+	CancelledOrders int     `json:"cancelled_orders"`
+}
+
+// BrandDashboard aggregates orders placed across a brand's outlets between
+// From and To; either may be nil for no bound
+type BrandDashboard struct {
+	BrandID         string        `json:"brand_id"`
+	From            *time.Time    `json:"from,omitempty"`
+	To              *time.Time    `json:"to,omitempty"`
+	Orders          int           `json:"orders"`
+	Revenue         float64       `json:"revenue"`
+	ActiveOrders    int           `json:"active_orders"`
+	CancelledOrders int           `json:"cancelled_orders"`
+	Outlets         []OutletStats `json:"outlets"`
+}
@@ -0,0 +1,78 @@
+package model
+
+import "time"
+
+// DeliveryPartner is a delivery_partner account's availability and last
+// reported position
+type DeliveryPartner struct {
+	UserID            string     `json:"user_id"`
+	IsOnline          bool       `json:"is_online"`
+	Latitude          *float64   `json:"latitude"`
+	Longitude         *float64   `json:"longitude"`
+	LocationUpdatedAt *time.Time `json:"location_updated_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// DeliveryAssignment is an order offered to a partner. Once accepted, the
+// partner picks it up and delivers it.
+type DeliveryAssignment struct {
+	ID          string     `json:"id"`
+	OrderID     string     `json:"order_id"`
+	PartnerID   string     `json:"partner_id"`
+	Status      string     `json:"status"`      // offered, accepted, rejected, expired, delivered or released
+	DistanceKm  float64    `json:"distance_km"` // partner to restaurant when offered
+	OfferedAt   time.Time  `json:"offered_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	RespondedAt *time.Time `json:"responded_at"`
+	PickedUpAt  *time.Time `json:"picked_up_at"`
+	DeliveredAt *time.Time `json:"delivered_at"`
+}
+
+// DeliveryTask is an open assignment as the partner sees it: where to
+// collect the order and where to take it
+type DeliveryTask struct {
+	DeliveryAssignment
+	RestaurantName    string  `json:"restaurant_name"`
+	RestaurantAddress string  `json:"restaurant_address"`
+	DeliveryAddress   string  `json:"delivery_address"`
+	OrderStatus       string  `json:"order_status"`
+	TotalAmount       float64 `json:"total_amount"`
+	PaymentMethod     string  `json:"payment_method"`
+}
+
+// OrderDelivery is the partner carrying an order, for the customer and the
+// restaurant
+type OrderDelivery struct {
+	OrderID           string     `json:"order_id"`
+	Status            string     `json:"status"` // the assignment's: accepted or delivered
+	PartnerName       string     `json:"partner_name"`
+	PartnerPhone      string     `json:"partner_phone"`
+	Latitude          *float64   `json:"latitude"` // the partner's last position
+	Longitude         *float64   `json:"longitude"`
+	LocationUpdatedAt *time.Time `json:"location_updated_at"`
+	PickedUpAt        *time.Time `json:"picked_up_at"`
+	DeliveredAt       *time.Time `json:"delivered_at"`
+}
+
+type UpdatePartnerStatusRequest struct {
+	Online *bool `json:"online" validate:"required"`
+}
+
+// LocationRequest is a position, for a partner's ping or a restaurant's address
+type LocationRequest struct {
+	Latitude  *float64 `json:"latitude" validate:"required,min=-90,max=90"`
+	Longitude *float64 `json:"longitude" validate:"required,min=-180,max=180"`
+}
+
+type CompleteDeliveryRequest struct {
+	DeliveryCode string `json:"delivery_code" validate:"required,max=4"`
+}
+
+// DeliveryCodeEvent is one entry in an order's delivery code audit trail
+type DeliveryCodeEvent struct {
+	ID        string    `json:"id"`
+	OrderID   string    `json:"order_id"`
+	UserID    string    `json:"user_id"` // empty once the account is deleted
+	Action    string    `json:"action"`  // issued, reissued, verified or failed
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// KitchenBoard is a restaurant's live queue for the kitchen display: the
+// orders it is working on, grouped by status, oldest first. It leaves out
+// prices, addresses and customers to stay small for tablets that poll it.
+type KitchenBoard struct {
+	RestaurantID      string          `json:"restaurant_id"`
+	PrepTargetMinutes int             `json:"prep_target_minutes"`
+	Late              int             `json:"late"` // tickets past their due time
+	Confirmed         []KitchenTicket `json:"confirmed"`
+	Preparing         []KitchenTicket `json:"preparing"`
+	Ready             []KitchenTicket `json:"ready"`
+}
+
+type KitchenTicket struct {
+	OrderID  string        `json:"order_id"`
+	Number   string        `json:"number"` // short id to call out across the kitchen
+	PlacedAt time.Time     `json:"placed_at"`
+	DueAt    time.Time     `json:"due_at"`         // the accepted prep estimate, else placed_at plus the prep target
+	Late     bool          `json:"late,omitempty"` // past due and not ready yet
+	Items    []KitchenItem `json:"items"`
+}
+
+type KitchenItem struct {
+	ID       string   `json:"id"` // the order item
+	Name     string   `json:"name"`
+	Quantity int      `json:"qty"`
+	Options  []string `json:"options,omitempty"`
+	Done     bool     `json:"done,omitempty"`
+}
+
+type UpdateKitchenItemRequest struct {
+	Done *bool `json:"done" validate:"required"`
+}
+
+type KitchenSettingsRequest struct {
+	PrepTargetMinutes int `json:"prep_target_minutes" validate:"gt=0,max=240"`
+}
@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+// RestaurantMember is a user's role at one restaurant. Name and Email are
+// the user's, filled in on reads.
+type RestaurantMember struct {
+	RestaurantID string    `json:"restaurant_id"`
+	UserID       string    `json:"user_id"`
+	Name         string    `json:"name"`
+	Email        string    `json:"email"`
+	Role         string    `json:"role"` // owner, manager, kitchen or viewer
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Invitation asks whoever holds Email to join a restaurant as Role.
+// The token is only ever in the email; we keep its hash.
+type Invitation struct {
+	ID           string     `json:"id"`
+	RestaurantID string     `json:"restaurant_id"`
+	Email        string     `json:"email"`
+	Role         string     `json:"role"`
+	TokenHash    string     `json:"-"`
+	InvitedBy    string     `json:"invited_by"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	AcceptedAt   *time.Time `json:"accepted_at,omitempty"`
+}
+
+// Owners are made, not invited: the role list leaves owner out
+type InviteMemberRequest struct {
+	Email string `json:"email" validate:"required,max=150,email"`
+	Role  string `json:"role" validate:"required,oneof=manager kitchen viewer"`
+}
+
+type UpdateMemberRequest struct {
+	Role string `json:"role" validate:"required,oneof=manager kitchen viewer"`
+}
+
+type AcceptInvitationRequest struct {
+	Token string `json:"token" validate:"required,max=100"`
+}
+
+// RevenueSummary totals a restaurant's delivered orders between From and
+// To; either may be nil for no bound
+type RevenueSummary struct {
+	RestaurantID string     `json:"restaurant_id"`
+	From         *time.Time `json:"from,omitempty"`
+	To           *time.Time `json:"to,omitempty"`
+	Orders       int        `json:"orders"`
+	Revenue      float64    `json:"revenue"`
+}
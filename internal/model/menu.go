@@ -3,46 +3,100 @@ package model
 import "time"
 
 type MenuCategory struct {
-	ID           string    `json:"id"`
-	RestaurantID string    `json:"restaurant_id"`
-	Name         string    `json:"name"`
-	DisplayOrder int       `json:"display_order"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID           string               `json:"id"`
+	RestaurantID string               `json:"restaurant_id"`
+	Name         string               `json:"name"`
+	DisplayOrder int                  `json:"display_order"`
+	Schedule     []AvailabilityWindow `json:"schedule,omitempty"`
+	Items        []MenuItem           `json:"items,omitempty"` // only set on the owner's full menu
+	CreatedAt    time.Time            `json:"created_at"`
+	DeletedAt    *time.Time           `json:"deleted_at,omitempty"`
+
+	// BrandCategoryID is set on an outlet's copy of a brand category
+	BrandCategoryID string `json:"brand_category_id,omitempty"`
 }
 
 type MenuItem struct {
-	ID          string    `json:"id"`
-	CategoryID  string    `json:"category_id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Price       float64   `json:"price"`
-	ImageURL    string    `json:"image_url"`
-	IsAvailable bool      `json:"is_available"`
-	IsVeg       bool      `json:"is_veg"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID            string               `json:"id"`
+	CategoryID    string               `json:"category_id"`
+	Name          string               `json:"name"`
+	Description   string               `json:"description"`
+	Price         float64              `json:"price"`
+	ImageURL      string               `json:"image_url"`
+	IsAvailable   bool                 `json:"is_available"`
+	IsVeg         bool                 `json:"is_veg"`
+	StockQuantity *int                 `json:"stock_quantity"` // nil = stock not tracked
+	DailyStock    *int                 `json:"daily_stock"`    // refill target applied at opening time
+	Schedule      []AvailabilityWindow `json:"schedule,omitempty"`
+	CreatedAt     time.Time            `json:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at"`
+	Version       int                  `json:"version"` // bumped by every owner edit; also sent as the ETag
+	DeletedAt     *time.Time           `json:"deleted_at,omitempty"`
+
+	// BrandItemID is set on an outlet's copy of a brand item. Its name,
+	// description, price, image and availability come from the brand menu
+	// and the outlet's override; the outlet still owns its stock, its
+	// schedule and whether it sells the item at all.
+	BrandItemID string `json:"brand_item_id,omitempty"`
 }
 
 type CreateCategoryRequest struct {
-	RestaurantID string `json:"restaurant_id"`
-	Name         string `json:"name"`
-	DisplayOrder int    `json:"display_order"`
+	RestaurantID string `json:"restaurant_id" validate:"required,uuid"`
+	Name         string `json:"name" validate:"required,max=100"`
+	DisplayOrder int    `json:"display_order" validate:"min=0"`
 }
 
 type CreateMenuItemRequest struct {
-	CategoryID  string  `json:"category_id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	ImageURL    string  `json:"image_url"`
+	CategoryID  string  `json:"category_id" validate:"required,uuid"`
+	Name        string  `json:"name" validate:"required,max=150"`
+	Description string  `json:"description" validate:"max=2000"`
+	Price       float64 `json:"price" validate:"gt=0"`
+	ImageURL    string  `json:"image_url" validate:"max=2048"`
 	IsVeg       bool    `json:"is_veg"`
+	DailyStock  *int    `json:"daily_stock" validate:"min=0"` // optional, enables stock tracking
 }
 
 type UpdateMenuItemRequest struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	ImageURL    string  `json:"image_url"`
+	Name        string  `json:"name" validate:"required,max=150"`
+	Description string  `json:"description" validate:"max=2000"`
+	Price       float64 `json:"price" validate:"gt=0"`
+	ImageURL    string  `json:"image_url" validate:"max=2048"`
 	IsAvailable bool    `json:"is_available"`
 	IsVeg       bool    `json:"is_veg"`
 }
+
+// PatchMenuItemRequest is a JSON merge patch: fields left out keep their
+// current value and null clears description or image_url. The other
+// fields can't be cleared, so null is rejected.
+type PatchMenuItemRequest struct {
+	Name        Optional[string]  `json:"name" validate:"notblank,max=150"`
+	Description Optional[string]  `json:"description" validate:"max=2000"`
+	Price       Optional[float64] `json:"price" validate:"nonnull,gt=0"`
+	ImageURL    Optional[string]  `json:"image_url" validate:"max=2048"`
+	IsAvailable Optional[bool]    `json:"is_available" validate:"nonnull"`
+	IsVeg       Optional[bool]    `json:"is_veg" validate:"nonnull"`
+}
+
+type UpdateAvailabilityRequest struct {
+	IsAvailable *bool `json:"is_available" validate:"required"`
+}
+
+// Sending null for both fields turns stock tracking off for the item
+type UpdateStockRequest struct {
+	StockQuantity *int `json:"stock_quantity" validate:"min=0"`
+	DailyStock    *int `json:"daily_stock" validate:"min=0"`
+}
+
+// AvailabilityWindow limits when a category or item can be ordered.
+// Days use 0 = Sunday; times are "HH:MM" in the restaurant timezone and
+// an end before the start runs past midnight (e.g. 22:00-02:00).
+type AvailabilityWindow struct {
+	Days      []int  `json:"days" validate:"required,max=7,dive,min=0,max=6"`
+	StartTime string `json:"start_time" validate:"required,hhmm"`
+	EndTime   string `json:"end_time" validate:"required,hhmm"`
+}
+
+// An empty windows list makes the category or item available all the time
+type UpdateScheduleRequest struct {
+	Windows []AvailabilityWindow `json:"windows" validate:"max=10,dive"`
+}
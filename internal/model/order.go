@@ -14,38 +14,63 @@ type Order struct {
 	PaymentStatus   string    `json:"payment_status"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
+
+	// DeliveryCode is set on confirmation and has to be entered to mark the
+	// order delivered. Only the customer is shown it, on GET /api/orders/{id}.
+	DeliveryCode         string `json:"delivery_code,omitempty"`
+	DeliveryCodeAttempts int    `json:"-"`
+
+	// Set when the restaurant accepts the order, with its estimate of the
+	// prep time, or rejects it. Also only loaded on GET /api/orders/{id},
+	// which works out the ETA from them.
+	AcceptedAt      *time.Time `json:"accepted_at,omitempty"`
+	PrepMinutes     *int       `json:"prep_minutes,omitempty"`
+	RejectionReason string     `json:"rejection_reason,omitempty"` // timeout when nobody answered in time
+	ETA             *time.Time `json:"eta,omitempty"`
 }
 
+// OrderItem is a snapshot of the dish at order time, so receipts stay
+// correct after the menu item is renamed, repriced or archived
 type OrderItem struct {
 	ID         string    `json:"id"`
 	OrderID    string    `json:"order_id"`
-	MenuItemID string    `json:"menu_item_id"`
+	MenuItemID string    `json:"menu_item_id"` // empty once the menu item is purged
 	Quantity   int       `json:"quantity"`
 	Price      float64   `json:"price"`
+	ItemName   string    `json:"item_name"`
+	ItemImage  string    `json:"item_image"`
+	IsVeg      bool      `json:"is_veg"`
+	Options    []string  `json:"options"`
 	CreatedAt  time.Time `json:"created_at"`
+
+	PreparedAt *time.Time `json:"prepared_at,omitempty"` // when the kitchen marked it done
 }
 
 type CreateOrderRequest struct {
-	RestaurantID    string           `json:"restaurant_id"`
-	Items           []OrderItemInput `json:"items"`
-	DeliveryAddress string           `json:"delivery_address"`
-	PaymentMethod   string           `json:"payment_method"`
+	RestaurantID    string           `json:"restaurant_id" validate:"required,uuid"`
+	Items           []OrderItemInput `json:"items" validate:"required,max=50,dive"`
+	DeliveryAddress string           `json:"delivery_address" validate:"required,max=500"`
+	PaymentMethod   string           `json:"payment_method" validate:"required,max=50"`
 }
 
 type OrderItemInput struct {
-	MenuItemID string `json:"menu_item_id"`
-	Quantity   int    `json:"quantity"`
+	MenuItemID string   `json:"menu_item_id" validate:"required,uuid"`
+	Quantity   int      `json:"quantity" validate:"gt=0,max=100"`
+	Options    []string `json:"options" validate:"max=10,dive,required,max=100"` // free-text customisations, e.g. "no onions"
 }
 
-type OrderWithDetails struct {
-	Order
-	RestaurantName string              `json:"restaurant_name"`
-	Items          []OrderItemWithMenu `json:"items"`
+// AcceptOrderRequest confirms a pending order; the prep estimate sets the
+// customer's ETA
+type AcceptOrderRequest struct {
+	PrepMinutes int `json:"prep_minutes" validate:"gt=0,max=240"`
 }
 
-type OrderItemWithMenu struct {
-	OrderItem
-	ItemName  string `json:"item_name"`
-	ItemImage string `json:"item_image"`
-	IsVeg     bool   `json:"is_veg"`
+type RejectOrderRequest struct {
+	Reason string `json:"reason" validate:"required,oneof=too_busy items_unavailable closing_soon outside_delivery_area other"`
+}
+
+type OrderWithDetails struct {
+	Order
+	RestaurantName string      `json:"restaurant_name"`
+	Items          []OrderItem `json:"items"`
 }
@@ -0,0 +1,44 @@
+package model
+
+import "encoding/json"
+
+// Optional is one field of a JSON merge patch (RFC 7396), which tells a
+// field left out apart from one sent as null. Present is whether the body
+// had the field; Value is nil when it was null.
+type Optional[T any] struct {
+	Present bool
+	Value   *T
+}
+
+// Set is a present, non-null Optional, for patches built in code
+func Set[T any](v T) Optional[T] {
+	return Optional[T]{Present: true, Value: &v}
+}
+
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Present = true
+	if string(data) == "null" {
+		o.Value = nil
+		return nil
+	}
+	return json.Unmarshal(data, &o.Value)
+}
+
+// Null reports whether the field was sent as null, which clears it
+func (o Optional[T]) Null() bool {
+	return o.Present && o.Value == nil
+}
+
+// Get returns the value the patch writes: the zero value for a null
+func (o Optional[T]) Get() T {
+	var zero T
+	if o.Value == nil {
+		return zero
+	}
+	return *o.Value
+}
+
+// Unwrap hands validate the value to check; nil when absent or null
+func (o Optional[T]) Unwrap() any {
+	return o.Value
+}
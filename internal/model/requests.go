@@ -0,0 +1,16 @@
+package model
+
+// Requests lists a value of every request body the services validate, so
+// startup can check their validate tags before serving
+var Requests = []any{
+	RegisterRequest{}, LoginRequest{},
+	CreateRestaurantRequest{}, UpdateRestaurantRequest{}, PatchRestaurantRequest{},
+	CreateCategoryRequest{}, CreateMenuItemRequest{}, UpdateMenuItemRequest{}, PatchMenuItemRequest{},
+	UpdateStockRequest{}, UpdateScheduleRequest{}, UpdateAvailabilityRequest{},
+	CreateOrderRequest{}, AcceptOrderRequest{}, RejectOrderRequest{},
+	InviteMemberRequest{}, AcceptInvitationRequest{}, UpdateMemberRequest{},
+	CreateBrandRequest{}, UpdateBrandRequest{}, CreateBrandCategoryRequest{},
+	CreateBrandMenuItemRequest{}, UpdateBrandMenuItemRequest{}, AttachOutletRequest{}, SetOverrideRequest{},
+	LocationRequest{}, UpdatePartnerStatusRequest{}, CompleteDeliveryRequest{},
+	KitchenSettingsRequest{}, UpdateKitchenItemRequest{},
+}
@@ -3,32 +3,67 @@ package model
 import "time"
 
 type Restaurant struct {
-	ID          string    `json:"id"`
-	OwnerID     string    `json:"owner_id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Address     string    `json:"address"`
-	City        string    `json:"city"`
-	ImageURL    string    `json:"image_url"`
-	IsActive    bool      `json:"is_active"`
-	Rating      float64   `json:"rating"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string     `json:"id"`
+	OwnerID     string     `json:"owner_id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Address     string     `json:"address"`
+	City        string     `json:"city"`
+	ImageURL    string     `json:"image_url"`
+	IsActive    bool       `json:"is_active"`
+	Rating      float64    `json:"rating"`
+	OpensAt     string     `json:"opens_at"` // "HH:MM" in the restaurant timezone
+	Timezone    string     `json:"timezone"` // IANA name, e.g. Asia/Kolkata
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Version     int        `json:"version"` // bumped by every owner edit; also sent as the ETag
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	BrandID     string     `json:"brand_id,omitempty"` // set on a brand's outlets
+
+	// MyRole is the caller's role here; only set when listing their own restaurants
+	MyRole string `json:"my_role,omitempty"`
 }
 
 type CreateRestaurantRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Address     string `json:"address"`
-	City        string `json:"city"`
-	ImageURL    string `json:"image_url"`
+	Name        string `json:"name" validate:"required,max=150"`
+	Description string `json:"description" validate:"max=2000"`
+	Address     string `json:"address" validate:"required,max=500"`
+	City        string `json:"city" validate:"required,max=100"`
+	ImageURL    string `json:"image_url" validate:"max=2048"`
+	OpensAt     string `json:"opens_at" validate:"hhmm"`
+	Timezone    string `json:"timezone" validate:"timezone"`
 }
 
 type UpdateRestaurantRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Address     string `json:"address"`
-	City        string `json:"city"`
-	ImageURL    string `json:"image_url"`
-	IsActive    bool   `json:"is_active"`
+	Name        string `json:"name" validate:"required,max=150"`
+	Description string `json:"description" validate:"max=2000"`
+	Address     string `json:"address" validate:"required,max=500"`
+	City        string `json:"city" validate:"required,max=100"`
+	ImageURL    string `json:"image_url" validate:"max=2048"`
+	IsActive    *bool  `json:"is_active" validate:"required"`
+	OpensAt     string `json:"opens_at" validate:"hhmm"`
+	Timezone    string `json:"timezone" validate:"timezone"`
+}
+
+// PatchRestaurantRequest is a JSON merge patch: fields left out keep their
+// current value and null clears description or image_url. The other
+// fields can't be cleared, so null is rejected.
+type PatchRestaurantRequest struct {
+	Name        Optional[string] `json:"name" validate:"notblank,max=150"`
+	Description Optional[string] `json:"description" validate:"max=2000"`
+	Address     Optional[string] `json:"address" validate:"notblank,max=500"`
+	City        Optional[string] `json:"city" validate:"notblank,max=100"`
+	ImageURL    Optional[string] `json:"image_url" validate:"max=2048"`
+	IsActive    Optional[bool]   `json:"is_active" validate:"nonnull"`
+	OpensAt     Optional[string] `json:"opens_at" validate:"notblank,hhmm"`
+	Timezone    Optional[string] `json:"timezone" validate:"notblank,timezone"`
+}
+
+// Trash lists what a user's roles let them restore.
+// Categories and items deleted along with their parent are restored with it.
+type Trash struct {
+	RetentionDays int            `json:"retention_days"`
+	Restaurants   []Restaurant   `json:"restaurants"`
+	Categories    []MenuCategory `json:"categories"`
+	MenuItems     []MenuItem     `json:"menu_items"`
 }
@@ -16,17 +16,17 @@ type User struct {
 
 // What we receive from the client on register
 type RegisterRequest struct {
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	Phone    string `json:"phone"`
-	Role     string `json:"role"` // customer | restaurant_owner
+	Name     string `json:"name" validate:"required,max=100"`
+	Email    string `json:"email" validate:"required,max=150,email"`
+	Password string `json:"password" validate:"required,password"`
+	Phone    string `json:"phone" validate:"phone"`
+	Role     string `json:"role" validate:"oneof=customer restaurant_owner delivery_partner"` // empty means customer
 }
 
 // What we receive from the client on login
 type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
 }
 
 // What we send back after successful login/register
@@ -0,0 +1,36 @@
+// Package payment moves money back to customers. No payment provider is
+// integrated yet, so refunds are logged and treated as done.
+package payment
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Prepaid reports whether an order paid by method is charged when it is
+// placed. With no provider to capture payments, every method but cash on
+// delivery counts as charged up front, so those orders get refunded if
+// they are cancelled.
+func Prepaid(method string) bool {
+	return method != "cash"
+}
+
+// Refund returns what the customer paid for a cancelled order
+type Refund struct {
+	OrderID string // also the idempotency key: an order is refunded at most once
+	Amount  float64
+	Method  string // the order's payment method
+}
+
+type Refunder interface {
+	Refund(ctx context.Context, refund Refund) error
+}
+
+// LogRefunder writes refunds to the log instead of sending them to a provider
+type LogRefunder struct{}
+
+func (LogRefunder) Refund(ctx context.Context, refund Refund) error {
+	slog.InfoContext(ctx, "refund not sent, no payment provider configured",
+		"order_id", refund.OrderID, "amount", refund.Amount, "method", refund.Method)
+	return nil
+}
@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresStore keeps buckets in rate_limit_buckets so every instance
+// draws from the same tokens. The row lock serialises concurrent takes.
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{pool: pool}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	var result Result
+
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		// A new key starts with a full bucket
+		_, err := tx.Exec(ctx, `
+			INSERT INTO rate_limit_buckets (key, tokens, updated_at) VALUES ($1, $2, clock_timestamp())
+			ON CONFLICT (key) DO NOTHING
+		`, key, float64(limit.Burst))
+		if err != nil {
+			return err
+		}
+
+		// Elapsed time comes from the database clock, which all instances share.
+		// clock_timestamp, not NOW: NOW is frozen at transaction start, which can
+		// be before the write of a transaction we waited on for the row lock.
+		var tokens float64
+		var elapsed time.Duration
+		err = tx.QueryRow(ctx, `
+			SELECT tokens, clock_timestamp() - updated_at
+			FROM rate_limit_buckets
+			WHERE key = $1
+			FOR UPDATE
+		`, key).Scan(&tokens, &elapsed)
+		if err != nil {
+			return err
+		}
+
+		tokens, result = take(tokens, elapsed, limit)
+
+		_, err = tx.Exec(ctx, `
+			UPDATE rate_limit_buckets SET tokens = $2, updated_at = clock_timestamp() WHERE key = $1
+		`, key, tokens)
+		return err
+	})
+
+	return result, err
+}
+
+// PurgeStale deletes buckets untouched for longer than olderThan.
+// Pass at least the longest Limit.Window in use: older buckets are full
+// again and a missing row behaves exactly like a full one.
+func (s *PostgresStore) PurgeStale(ctx context.Context, olderThan time.Duration) (int64, error) {
+	tag, err := s.pool.Exec(ctx, `
+		DELETE FROM rate_limit_buckets WHERE updated_at < NOW() - $1::interval
+	`, olderThan)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,52 @@
+// Package ratelimit implements token buckets with an in-memory backend for
+// single instances and a Postgres one shared across instances.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit allows bursts of Burst requests, refilled at one token per Every
+type Limit struct {
+	Burst int
+	Every time.Duration
+}
+
+// Window is how long an empty bucket takes to fill back up
+func (l Limit) Window() time.Duration {
+	return time.Duration(l.Burst) * l.Every
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the next token, when not allowed
+}
+
+// Store takes one token from the bucket at key
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// take refills a bucket that held tokens elapsed ago and tries to spend one.
+// Both backends use it so they agree on the arithmetic.
+func take(tokens float64, elapsed time.Duration, limit Limit) (float64, Result) {
+	elapsed = max(elapsed, 0)
+	tokens = math.Min(float64(limit.Burst), tokens+float64(elapsed)/float64(limit.Every))
+
+	result := Result{Limit: limit.Burst}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - tokens) * float64(limit.Every))
+	}
+
+	result.Remaining = int(math.Floor(tokens))
+	result.ResetAfter = time.Duration((float64(limit.Burst) - tokens) * float64(limit.Every))
+	return tokens, result
+}
@@ -0,0 +1,612 @@
+package repository
+
+import (
+	"context"
+	"quickbite/internal/model"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// BrandRepository is the pgx-backed BrandStore
+type BrandRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewBrandRepository(pool *pgxpool.Pool) *BrandRepository {
+	return &BrandRepository{pool: pool}
+}
+
+// ====== BRANDS ======
+
+func (repo *BrandRepository) CreateBrand(ctx context.Context, brand *model.Brand) error {
+	query := `
+		INSERT INTO brands (owner_id, name, description, logo_url)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at, version
+	`
+
+	return repo.pool.QueryRow(
+		ctx,
+		query,
+		brand.OwnerID,
+		brand.Name,
+		brand.Description,
+		brand.LogoURL,
+	).Scan(&brand.ID, &brand.CreatedAt, &brand.UpdatedAt, &brand.Version)
+}
+
+func (repo *BrandRepository) GetBrandByID(ctx context.Context, id string) (*model.Brand, error) {
+	query := `
+		SELECT id, owner_id, name, description, logo_url, created_at, updated_at, version
+		FROM brands
+		WHERE id = $1
+	`
+
+	brand := &model.Brand{}
+	err := repo.pool.QueryRow(ctx, query, id).Scan(
+		&brand.ID,
+		&brand.OwnerID,
+		&brand.Name,
+		&brand.Description,
+		&brand.LogoURL,
+		&brand.CreatedAt,
+		&brand.UpdatedAt,
+		&brand.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return brand, nil
+}
+
+func (repo *BrandRepository) GetBrandsByOwner(ctx context.Context, ownerID string) ([]model.Brand, error) {
+	query := `
+		SELECT id, owner_id, name, description, logo_url, created_at, updated_at, version
+		FROM brands
+		WHERE owner_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := repo.pool.Query(ctx, query, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	brands := []model.Brand{}
+	for rows.Next() {
+		var b model.Brand
+		err := rows.Scan(&b.ID, &b.OwnerID, &b.Name, &b.Description, &b.LogoURL, &b.CreatedAt, &b.UpdatedAt, &b.Version)
+		if err != nil {
+			return nil, err
+		}
+		brands = append(brands, b)
+	}
+	return brands, rows.Err()
+}
+
+// UpdateBrand overwrites the brand if it is still at version (0 skips the
+// check) and returns ErrStale otherwise
+func (repo *BrandRepository) UpdateBrand(ctx context.Context, id string, version int, req *model.UpdateBrandRequest) error {
+	tag, err := repo.pool.Exec(ctx, `
+		UPDATE brands
+		SET name = $1, description = $2, logo_url = $3, version = version + 1, updated_at = NOW()
+		WHERE id = $4 AND ($5 = 0 OR version = $5)
+	`, req.Name, req.Description, req.LogoURL, id, version)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStale
+	}
+	return nil
+}
+
+// ====== OUTLETS ======
+
+func (repo *BrandRepository) GetOutlets(ctx context.Context, brandID string) ([]model.Restaurant, error) {
+	query := `
+		SELECT id, owner_id, name, description, address, city, image_url, is_active, rating,
+		       to_char(opens_at, 'HH24:MI'), timezone, created_at, updated_at, version, brand_id::text
+		FROM restaurants
+		WHERE brand_id = $1 AND deleted_at IS NULL
+		ORDER BY name ASC
+	`
+
+	rows, err := repo.pool.Query(ctx, query, brandID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	outlets := []model.Restaurant{}
+	for rows.Next() {
+		var r model.Restaurant
+		err := rows.Scan(
+			&r.ID,
+			&r.OwnerID,
+			&r.Name,
+			&r.Description,
+			&r.Address,
+			&r.City,
+			&r.ImageURL,
+			&r.IsActive,
+			&r.Rating,
+			&r.OpensAt,
+			&r.Timezone,
+			&r.CreatedAt,
+			&r.UpdatedAt,
+			&r.Version,
+			&r.BrandID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		outlets = append(outlets, r)
+	}
+	return outlets, rows.Err()
+}
+
+// AttachOutlet makes the restaurant an outlet of the brand. It returns
+// ErrStale if the restaurant already belongs to a brand.
+func (repo *BrandRepository) AttachOutlet(ctx context.Context, brandID, restaurantID string) error {
+	tag, err := repo.pool.Exec(ctx, `
+		UPDATE restaurants SET brand_id = $1, version = version + 1, updated_at = NOW()
+		WHERE id = $2 AND brand_id IS NULL AND deleted_at IS NULL
+	`, brandID, restaurantID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStale
+	}
+	return nil
+}
+
+// DetachOutlet takes the restaurant out of the brand. Its copies of the
+// master menu stay on its menu as its own categories and items, at the
+// prices it was last selling them for; its overrides go.
+func (repo *BrandRepository) DetachOutlet(ctx context.Context, brandID, restaurantID string) error {
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE restaurants SET brand_id = NULL, version = version + 1, updated_at = NOW()
+		WHERE id = $1 AND brand_id = $2
+	`, restaurantID, brandID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStale
+	}
+
+	queries := []string{
+		`UPDATE menu_items SET brand_item_id = NULL
+		 WHERE brand_item_id IS NOT NULL
+		   AND category_id IN (SELECT id FROM menu_categories WHERE restaurant_id = $1)`,
+		`UPDATE menu_categories SET brand_category_id = NULL
+		 WHERE restaurant_id = $1 AND brand_category_id IS NOT NULL`,
+		`DELETE FROM outlet_item_overrides WHERE restaurant_id = $1`,
+	}
+	for _, query := range queries {
+		if _, err := tx.Exec(ctx, query, restaurantID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ====== MASTER MENU ======
+
+func (repo *BrandRepository) CreateBrandCategory(ctx context.Context, category *model.BrandCategory) error {
+	query := `
+		INSERT INTO brand_categories (brand_id, name, display_order)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+
+	return repo.pool.QueryRow(ctx, query, category.BrandID, category.Name, category.DisplayOrder).
+		Scan(&category.ID, &category.CreatedAt)
+}
+
+func (repo *BrandRepository) GetBrandCategoryByID(ctx context.Context, id string) (*model.BrandCategory, error) {
+	query := `
+		SELECT id, brand_id, name, display_order, created_at
+		FROM brand_categories
+		WHERE id = $1
+	`
+
+	c := &model.BrandCategory{}
+	err := repo.pool.QueryRow(ctx, query, id).Scan(&c.ID, &c.BrandID, &c.Name, &c.DisplayOrder, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// DeleteBrandCategory removes the category and its items from the master
+// menu and archives the outlets' copies, which can be restored from each
+// outlet's trash as its own
+func (repo *BrandRepository) DeleteBrandCategory(ctx context.Context, id string) error {
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := []string{
+		`UPDATE menu_categories SET deleted_at = NOW()
+		 WHERE brand_category_id = $1 AND deleted_at IS NULL`,
+		`UPDATE menu_items mi SET deleted_at = mc.deleted_at, updated_at = NOW()
+		 FROM menu_categories mc
+		 WHERE mi.category_id = mc.id AND mc.brand_category_id = $1 AND mi.deleted_at IS NULL`,
+		`DELETE FROM brand_categories WHERE id = $1`,
+	}
+	for _, query := range queries {
+		if _, err := tx.Exec(ctx, query, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (repo *BrandRepository) CreateBrandItem(ctx context.Context, item *model.BrandMenuItem) error {
+	query := `
+		INSERT INTO brand_menu_items (brand_category_id, name, description, price, image_url, is_available, is_veg)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at, version
+	`
+
+	return repo.pool.QueryRow(
+		ctx,
+		query,
+		item.CategoryID,
+		item.Name,
+		item.Description,
+		item.Price,
+		item.ImageURL,
+		item.IsAvailable,
+		item.IsVeg,
+	).Scan(&item.ID, &item.CreatedAt, &item.UpdatedAt, &item.Version)
+}
+
+func (repo *BrandRepository) GetBrandItemByID(ctx context.Context, id string) (*model.BrandMenuItem, error) {
+	query := `
+		SELECT id, brand_category_id, name, description, price::float8, image_url, is_available, is_veg,
+		       created_at, updated_at, version
+		FROM brand_menu_items
+		WHERE id = $1
+	`
+
+	item := &model.BrandMenuItem{}
+	err := repo.pool.QueryRow(ctx, query, id).Scan(
+		&item.ID,
+		&item.CategoryID,
+		&item.Name,
+		&item.Description,
+		&item.Price,
+		&item.ImageURL,
+		&item.IsAvailable,
+		&item.IsVeg,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+		&item.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// UpdateBrandItem overwrites the master item if it is still at version
+// (0 skips the check) and returns ErrStale otherwise
+func (repo *BrandRepository) UpdateBrandItem(ctx context.Context, id string, version int, req *model.UpdateBrandMenuItemRequest) error {
+	query := `
+		UPDATE brand_menu_items
+		SET brand_category_id = $1, name = $2, description = $3, price = $4, image_url = $5,
+		    is_available = $6, is_veg = $7, version = version + 1, updated_at = NOW()
+		WHERE id = $8 AND ($9 = 0 OR version = $9)
+	`
+
+	tag, err := repo.pool.Exec(
+		ctx,
+		query,
+		req.CategoryID,
+		req.Name,
+		req.Description,
+		req.Price,
+		req.ImageURL,
+		req.IsAvailable,
+		req.IsVeg,
+		id,
+		version,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStale
+	}
+	return nil
+}
+
+// DeleteBrandItem removes the item from the master menu and archives the
+// outlets' copies
+func (repo *BrandRepository) DeleteBrandItem(ctx context.Context, id string) error {
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE menu_items SET deleted_at = NOW(), updated_at = NOW()
+		WHERE brand_item_id = $1 AND deleted_at IS NULL
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM brand_menu_items WHERE id = $1`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetBrandMenu lists the master categories in display order, each with its items
+func (repo *BrandRepository) GetBrandMenu(ctx context.Context, brandID string) ([]model.BrandCategory, error) {
+	rows, err := repo.pool.Query(ctx, `
+		SELECT id, brand_id, name, display_order, created_at
+		FROM brand_categories
+		WHERE brand_id = $1
+		ORDER BY display_order ASC, created_at ASC
+	`, brandID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []model.BrandCategory{}
+	index := make(map[string]int)
+	for rows.Next() {
+		var c model.BrandCategory
+		if err := rows.Scan(&c.ID, &c.BrandID, &c.Name, &c.DisplayOrder, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		index[c.ID] = len(categories)
+		categories = append(categories, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	itemRows, err := repo.pool.Query(ctx, `
+		SELECT bi.id, bi.brand_category_id, bi.name, bi.description, bi.price::float8, bi.image_url,
+		       bi.is_available, bi.is_veg, bi.created_at, bi.updated_at, bi.version
+		FROM brand_menu_items bi
+		JOIN brand_categories bc ON bc.id = bi.brand_category_id
+		WHERE bc.brand_id = $1
+		ORDER BY bi.created_at ASC
+	`, brandID)
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var item model.BrandMenuItem
+		err := itemRows.Scan(
+			&item.ID,
+			&item.CategoryID,
+			&item.Name,
+			&item.Description,
+			&item.Price,
+			&item.ImageURL,
+			&item.IsAvailable,
+			&item.IsVeg,
+			&item.CreatedAt,
+			&item.UpdatedAt,
+			&item.Version,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if i, ok := index[item.CategoryID]; ok {
+			categories[i].Items = append(categories[i].Items, item)
+		}
+	}
+	return categories, itemRows.Err()
+}
+
+// ====== OVERRIDES ======
+
+func (repo *BrandRepository) GetOutletOverrides(ctx context.Context, restaurantID string) ([]model.OutletOverride, error) {
+	rows, err := repo.pool.Query(ctx, `
+		SELECT restaurant_id, brand_item_id, price::float8, is_available, updated_at
+		FROM outlet_item_overrides
+		WHERE restaurant_id = $1
+		ORDER BY updated_at DESC
+	`, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	overrides := []model.OutletOverride{}
+	for rows.Next() {
+		var o model.OutletOverride
+		if err := rows.Scan(&o.RestaurantID, &o.BrandItemID, &o.Price, &o.IsAvailable, &o.UpdatedAt); err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, o)
+	}
+	return overrides, rows.Err()
+}
+
+// SaveOutletOverride creates or replaces the outlet's override of an item
+func (repo *BrandRepository) SaveOutletOverride(ctx context.Context, o *model.OutletOverride) error {
+	query := `
+		INSERT INTO outlet_item_overrides (restaurant_id, brand_item_id, price, is_available)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (restaurant_id, brand_item_id) DO UPDATE
+		SET price = EXCLUDED.price, is_available = EXCLUDED.is_available, updated_at = NOW()
+		RETURNING updated_at
+	`
+
+	return repo.pool.QueryRow(ctx, query, o.RestaurantID, o.BrandItemID, o.Price, o.IsAvailable).Scan(&o.UpdatedAt)
+}
+
+func (repo *BrandRepository) DeleteOutletOverride(ctx context.Context, restaurantID, brandItemID string) error {
+	_, err := repo.pool.Exec(ctx, `
+		DELETE FROM outlet_item_overrides WHERE restaurant_id = $1 AND brand_item_id = $2
+	`, restaurantID, brandItemID)
+	return err
+}
+
+// ====== SYNC ======
+
+// The sync statements below take the brand id as $1 and, as $2, one outlet
+// to limit the sync to or NULL for all of them. Deleted outlets are skipped.
+const syncOutlets = `r.brand_id = $1 AND r.deleted_at IS NULL AND ($2::uuid IS NULL OR r.id = $2::uuid)`
+
+var syncQueries = []string{
+	// Rename and reorder the outlets' copies of master categories
+	`UPDATE menu_categories mc
+	 SET name = bc.name, display_order = bc.display_order
+	 FROM brand_categories bc, restaurants r
+	 WHERE mc.brand_category_id = bc.id
+	   AND mc.restaurant_id = r.id
+	   AND ` + syncOutlets + `
+	   AND (mc.name, mc.display_order) IS DISTINCT FROM (bc.name, bc.display_order)`,
+
+	// Copy master categories an outlet doesn't have yet
+	`INSERT INTO menu_categories (restaurant_id, name, display_order, brand_category_id)
+	 SELECT r.id, bc.name, bc.display_order, bc.id
+	 FROM restaurants r
+	 JOIN brand_categories bc ON bc.brand_id = r.brand_id
+	 WHERE ` + syncOutlets + `
+	 ON CONFLICT (restaurant_id, brand_category_id) WHERE brand_category_id IS NOT NULL DO NOTHING`,
+
+	// Rewrite the outlets' copies of master items, applying overrides. An
+	// item that sold out stays off until its stock is refilled. Only rows
+	// that actually change get a new version.
+	`UPDATE menu_items mi
+	 SET category_id = src.category_id,
+	     name = src.name,
+	     description = src.description,
+	     price = src.price,
+	     image_url = src.image_url,
+	     is_veg = src.is_veg,
+	     is_available = src.is_available AND COALESCE(mi.stock_quantity, 1) > 0,
+	     sold_out = mi.sold_out AND src.is_available,
+	     version = mi.version + 1,
+	     updated_at = NOW()
+	 FROM (
+	     SELECT copy.id, target.id AS category_id, bi.name, bi.description,
+	            COALESCE(o.price, bi.price) AS price, bi.image_url, bi.is_veg,
+	            COALESCE(o.is_available, bi.is_available) AS is_available
+	     FROM menu_items copy
+	     JOIN menu_categories cur ON cur.id = copy.category_id
+	     JOIN restaurants r ON r.id = cur.restaurant_id
+	     JOIN brand_menu_items bi ON bi.id = copy.brand_item_id
+	     JOIN menu_categories target ON target.restaurant_id = r.id AND target.brand_category_id = bi.brand_category_id
+	     LEFT JOIN outlet_item_overrides o ON o.restaurant_id = r.id AND o.brand_item_id = bi.id
+	     WHERE ` + syncOutlets + `
+	 ) src
+	 WHERE mi.id = src.id
+	   AND (mi.category_id, mi.name, mi.description, mi.price, mi.image_url, mi.is_veg, mi.is_available)
+	       IS DISTINCT FROM
+	       (src.category_id, src.name, src.description, src.price, src.image_url, src.is_veg,
+	        src.is_available AND COALESCE(mi.stock_quantity, 1) > 0)`,
+
+	// Copy master items an outlet doesn't have yet. One the outlet deleted
+	// still counts as had, so it stays off its menu; an item landing in a
+	// category the outlet deleted is archived along with it.
+	`INSERT INTO menu_items (category_id, name, description, price, image_url, is_available, is_veg, brand_item_id, deleted_at)
+	 SELECT target.id, bi.name, bi.description, COALESCE(o.price, bi.price), bi.image_url,
+	        COALESCE(o.is_available, bi.is_available), bi.is_veg, bi.id, target.deleted_at
+	 FROM restaurants r
+	 JOIN brand_categories bc ON bc.brand_id = r.brand_id
+	 JOIN brand_menu_items bi ON bi.brand_category_id = bc.id
+	 JOIN menu_categories target ON target.restaurant_id = r.id AND target.brand_category_id = bc.id
+	 LEFT JOIN outlet_item_overrides o ON o.restaurant_id = r.id AND o.brand_item_id = bi.id
+	 WHERE ` + syncOutlets + `
+	   AND NOT EXISTS (
+	       SELECT 1 FROM menu_items x
+	       JOIN menu_categories xc ON xc.id = x.category_id
+	       WHERE x.brand_item_id = bi.id AND xc.restaurant_id = r.id
+	   )`,
+}
+
+// SyncBrandMenu brings the outlets' linked categories and items in line
+// with the master menu and their overrides. restaurantID limits it to one
+// outlet; "" syncs them all. Syncs of the same brand run one at a time.
+func (repo *BrandRepository) SyncBrandMenu(ctx context.Context, brandID, restaurantID string) error {
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM brands WHERE id = $1 FOR UPDATE`, brandID); err != nil {
+		return err
+	}
+
+	var outlet any
+	if restaurantID != "" {
+		outlet = restaurantID
+	}
+	for _, query := range syncQueries {
+		if _, err := tx.Exec(ctx, query, brandID, outlet); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ====== DASHBOARD ======
+
+// GetOutletStats counts each live outlet's orders placed in [from, to),
+// busiest first; nil bounds are open
+func (repo *BrandRepository) GetOutletStats(ctx context.Context, brandID string, from, to *time.Time) ([]model.OutletStats, error) {
+	rows, err := repo.pool.Query(ctx, `
+		SELECT r.id, r.name, r.city,
+		       COUNT(o.id) FILTER (WHERE o.status = 'delivered'),
+		       COALESCE(SUM(o.total_amount) FILTER (WHERE o.status = 'delivered'), 0)::float8 AS revenue,
+		       COUNT(o.id) FILTER (WHERE o.status NOT IN ('delivered', 'cancelled')),
+		       COUNT(o.id) FILTER (WHERE o.status = 'cancelled')
+		FROM restaurants r
+		LEFT JOIN orders o ON o.restaurant_id = r.id
+		      AND ($2::timestamp IS NULL OR o.created_at >= $2)
+		      AND ($3::timestamp IS NULL OR o.created_at < $3)
+		WHERE r.brand_id = $1 AND r.deleted_at IS NULL
+		GROUP BY r.id, r.name, r.city
+		ORDER BY revenue DESC, r.name ASC
+	`, brandID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	stats := []model.OutletStats{}
+	for rows.Next() {
+		var s model.OutletStats
+		err := rows.Scan(&s.RestaurantID, &s.Name, &s.City, &s.Orders, &s.Revenue, &s.ActiveOrders, &s.CancelledOrders)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Postgres cancels a statement with query_canceled when it runs past
+// statement_timeout or the client asks it to stop
+const pgQueryCanceled = "57014"
+
+// ContextError reports why a store call was abandoned: context.Canceled when
+// the caller went away, context.DeadlineExceeded when it ran out of time
+// (including a statement timeout on the server), nil for any other error
+func ContextError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, context.Canceled):
+		return context.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return context.DeadlineExceeded
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgQueryCanceled {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
@@ -0,0 +1,373 @@
+package repository
+
+import (
+	"context"
+	"quickbite/internal/model"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DispatchRepository is the pgx-backed DispatchStore
+type DispatchRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewDispatchRepository(pool *pgxpool.Pool) *DispatchRepository {
+	return &DispatchRepository{pool: pool}
+}
+
+// ====== PARTNERS ======
+
+const partnerColumns = `user_id, is_online, latitude, longitude, location_updated_at, updated_at`
+
+func scanPartner(row pgx.Row) (*model.DeliveryPartner, error) {
+	p := &model.DeliveryPartner{}
+	err := row.Scan(&p.UserID, &p.IsOnline, &p.Latitude, &p.Longitude, &p.LocationUpdatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (repo *DispatchRepository) GetPartner(ctx context.Context, userID string) (*model.DeliveryPartner, error) {
+	return scanPartner(repo.pool.QueryRow(ctx, `
+		SELECT `+partnerColumns+` FROM delivery_partners WHERE user_id = $1
+	`, userID))
+}
+
+func (repo *DispatchRepository) SetPartnerOnline(ctx context.Context, userID string, online bool) (*model.DeliveryPartner, error) {
+	return scanPartner(repo.pool.QueryRow(ctx, `
+		INSERT INTO delivery_partners (user_id, is_online)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET is_online = EXCLUDED.is_online, updated_at = NOW()
+		RETURNING `+partnerColumns,
+		userID, online))
+}
+
+func (repo *DispatchRepository) UpdatePartnerLocation(ctx context.Context, userID string, lat, lng float64) (*model.DeliveryPartner, error) {
+	return scanPartner(repo.pool.QueryRow(ctx, `
+		INSERT INTO delivery_partners (user_id, latitude, longitude, location_updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (user_id) DO UPDATE
+		SET latitude = EXCLUDED.latitude,
+		    longitude = EXCLUDED.longitude,
+		    location_updated_at = NOW(),
+		    updated_at = NOW()
+		RETURNING `+partnerColumns,
+		userID, lat, lng))
+}
+
+func (repo *DispatchRepository) SetRestaurantLocation(ctx context.Context, restaurantID string, lat, lng float64) error {
+	_, err := repo.pool.Exec(ctx, `
+		UPDATE restaurants SET latitude = $2, longitude = $3, updated_at = NOW() WHERE id = $1
+	`, restaurantID, lat, lng)
+	return err
+}
+
+// ====== DISPATCH ======
+
+func (repo *DispatchRepository) GetUndispatchedOrders(ctx context.Context, limit int) ([]model.Order, error) {
+	query := `
+		SELECT o.id, o.user_id, o.restaurant_id, o.status, o.total_amount, o.delivery_fee,
+		       o.delivery_address, o.payment_method, o.payment_status, o.created_at, o.updated_at
+		FROM orders o
+		JOIN restaurants r ON r.id = o.restaurant_id
+		WHERE o.status = 'ready'
+		  AND r.latitude IS NOT NULL
+		  AND NOT EXISTS (
+			SELECT 1 FROM delivery_assignments a
+			WHERE a.order_id = o.id AND a.status IN ('offered', 'accepted')
+		  )
+		ORDER BY o.updated_at
+		LIMIT $1
+	`
+
+	rows, err := repo.pool.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []model.Order
+	for rows.Next() {
+		var o model.Order
+		err := rows.Scan(
+			&o.ID, &o.UserID, &o.RestaurantID, &o.Status, &o.TotalAmount, &o.DeliveryFee,
+			&o.DeliveryAddress, &o.PaymentMethod, &o.PaymentStatus, &o.CreatedAt, &o.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
+
+// FindNearestPartner measures great-circle distance (haversine, in km) from
+// the restaurant to each candidate's last ping
+func (repo *DispatchRepository) FindNearestPartner(ctx context.Context, orderID string, maxKm float64, fresh time.Duration) (string, float64, error) {
+	query := `
+		SELECT partner_id, distance_km
+		FROM (
+			SELECT dp.user_id AS partner_id,
+			       6371 * 2 * ASIN(SQRT(
+			           POWER(SIN(RADIANS(dp.latitude - r.latitude) / 2), 2) +
+			           COS(RADIANS(r.latitude)) * COS(RADIANS(dp.latitude)) *
+			           POWER(SIN(RADIANS(dp.longitude - r.longitude) / 2), 2)
+			       )) AS distance_km,
+			       EXISTS (
+			           SELECT 1 FROM delivery_assignments a
+			           WHERE a.partner_id = dp.user_id AND a.order_id = o.id
+			       ) AS missed
+			FROM orders o
+			JOIN restaurants r ON r.id = o.restaurant_id
+			CROSS JOIN delivery_partners dp
+			WHERE o.id = $1
+			  AND dp.is_online
+			  AND dp.location_updated_at > NOW() - $3::interval
+			  AND NOT EXISTS (
+				SELECT 1 FROM delivery_assignments a
+				WHERE a.partner_id = dp.user_id
+				  AND (a.status IN ('offered', 'accepted') OR (a.order_id = o.id AND a.status = 'rejected'))
+			  )
+		) candidates
+		WHERE distance_km <= $2
+		ORDER BY missed, distance_km
+		LIMIT 1
+	`
+
+	var partnerID string
+	var distance float64
+	err := repo.pool.QueryRow(ctx, query, orderID, maxKm, fresh).Scan(&partnerID, &distance)
+	if err != nil {
+		return "", 0, err
+	}
+	return partnerID, distance, nil
+}
+
+func (repo *DispatchRepository) CreateOffer(ctx context.Context, a *model.DeliveryAssignment) error {
+	err := repo.pool.QueryRow(ctx, `
+		INSERT INTO delivery_assignments (order_id, partner_id, distance_km, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, status, offered_at
+	`, a.OrderID, a.PartnerID, a.DistanceKm, a.ExpiresAt).Scan(&a.ID, &a.Status, &a.OfferedAt)
+	if IsUniqueViolation(err) {
+		return ErrStale
+	}
+	return err
+}
+
+func (repo *DispatchRepository) ReleaseStaleAssignments(ctx context.Context) (int64, error) {
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	expired, err := tx.Exec(ctx, `
+		UPDATE delivery_assignments
+		SET status = 'expired'
+		WHERE status = 'offered' AND expires_at <= NOW()
+	`)
+	if err != nil {
+		return 0, err
+	}
+
+	released, err := tx.Exec(ctx, `
+		UPDATE delivery_assignments a
+		SET status = 'released'
+		FROM orders o
+		WHERE o.id = a.order_id
+		  AND a.status IN ('offered', 'accepted')
+		  AND o.status IN ('delivered', 'cancelled')
+	`)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return expired.RowsAffected() + released.RowsAffected(), nil
+}
+
+// ====== ASSIGNMENTS ======
+
+const assignmentColumns = `
+	a.id, a.order_id, a.partner_id, a.status, a.distance_km,
+	a.offered_at, a.expires_at, a.responded_at, a.picked_up_at, a.delivered_at`
+
+func assignmentFields(a *model.DeliveryAssignment) []any {
+	return []any{
+		&a.ID, &a.OrderID, &a.PartnerID, &a.Status, &a.DistanceKm,
+		&a.OfferedAt, &a.ExpiresAt, &a.RespondedAt, &a.PickedUpAt, &a.DeliveredAt,
+	}
+}
+
+func (repo *DispatchRepository) GetAssignment(ctx context.Context, id string) (*model.DeliveryAssignment, error) {
+	a := &model.DeliveryAssignment{}
+	err := repo.pool.QueryRow(ctx, `
+		SELECT `+assignmentColumns+` FROM delivery_assignments a WHERE a.id = $1
+	`, id).Scan(assignmentFields(a)...)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (repo *DispatchRepository) GetPartnerTasks(ctx context.Context, partnerID string) ([]model.DeliveryTask, error) {
+	query := `
+		SELECT ` + assignmentColumns + `,
+		       r.name, r.address, o.delivery_address, o.status, o.total_amount, o.payment_method
+		FROM delivery_assignments a
+		JOIN orders o ON o.id = a.order_id
+		JOIN restaurants r ON r.id = o.restaurant_id
+		WHERE a.partner_id = $1 AND a.status IN ('offered', 'accepted')
+		ORDER BY a.offered_at
+	`
+
+	rows, err := repo.pool.Query(ctx, query, partnerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []model.DeliveryTask{}
+	for rows.Next() {
+		var t model.DeliveryTask
+		fields := append(assignmentFields(&t.DeliveryAssignment),
+			&t.RestaurantName, &t.RestaurantAddress, &t.DeliveryAddress, &t.OrderStatus, &t.TotalAmount, &t.PaymentMethod)
+		if err := rows.Scan(fields...); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, rows.Err()
+}
+
+func (repo *DispatchRepository) GetOrderDelivery(ctx context.Context, orderID string) (*model.OrderDelivery, error) {
+	query := `
+		SELECT a.order_id, a.status, u.name, COALESCE(u.phone, ''),
+		       dp.latitude, dp.longitude, dp.location_updated_at,
+		       a.picked_up_at, a.delivered_at
+		FROM delivery_assignments a
+		JOIN users u ON u.id = a.partner_id
+		LEFT JOIN delivery_partners dp ON dp.user_id = a.partner_id
+		WHERE a.order_id = $1 AND a.status IN ('accepted', 'delivered')
+		ORDER BY a.offered_at DESC
+		LIMIT 1
+	`
+
+	d := &model.OrderDelivery{}
+	err := repo.pool.QueryRow(ctx, query, orderID).Scan(
+		&d.OrderID,
+		&d.Status,
+		&d.PartnerName,
+		&d.PartnerPhone,
+		&d.Latitude,
+		&d.Longitude,
+		&d.LocationUpdatedAt,
+		&d.PickedUpAt,
+		&d.DeliveredAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (repo *DispatchRepository) AcceptOffer(ctx context.Context, id string) error {
+	return repo.respond(ctx, id, "accepted")
+}
+
+func (repo *DispatchRepository) RejectOffer(ctx context.Context, id string) error {
+	return repo.respond(ctx, id, "rejected")
+}
+
+func (repo *DispatchRepository) respond(ctx context.Context, id, status string) error {
+	tag, err := repo.pool.Exec(ctx, `
+		UPDATE delivery_assignments
+		SET status = $2, responded_at = NOW()
+		WHERE id = $1 AND status = 'offered' AND expires_at > NOW()
+	`, id, status)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStale
+	}
+	return nil
+}
+
+func (repo *DispatchRepository) PickUp(ctx context.Context, id string) error {
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE delivery_assignments
+		SET picked_up_at = NOW()
+		WHERE id = $1 AND status = 'accepted' AND picked_up_at IS NULL
+	`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStale
+	}
+
+	tag, err = tx.Exec(ctx, `
+		UPDATE orders o
+		SET status = 'out_for_delivery', updated_at = NOW()
+		FROM delivery_assignments a
+		WHERE a.id = $1 AND o.id = a.order_id AND o.status = 'ready'
+	`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStale
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (repo *DispatchRepository) CompleteDelivery(ctx context.Context, id string) error {
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE delivery_assignments
+		SET status = 'delivered', delivered_at = NOW()
+		WHERE id = $1 AND status = 'accepted' AND picked_up_at IS NOT NULL
+	`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStale
+	}
+
+	tag, err = tx.Exec(ctx, `
+		UPDATE orders o
+		SET status = 'delivered', updated_at = NOW()
+		FROM delivery_assignments a
+		WHERE a.id = $1 AND o.id = a.order_id AND o.status = 'out_for_delivery'
+	`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStale
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const pgUniqueViolation = "23505"
+
+// ErrStale means a conditional write matched no row: the version or status
+// the caller read has since been changed by someone else
+var ErrStale = errors.New("row was changed by another request")
+
+// IsNotFound reports whether a lookup matched no row
+func IsNotFound(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
+
+// IsUniqueViolation reports whether a write hit a unique constraint
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"quickbite/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// KitchenRepository is the pgx-backed KitchenStore
+type KitchenRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewKitchenRepository(pool *pgxpool.Pool) *KitchenRepository {
+	return &KitchenRepository{pool: pool}
+}
+
+// GetActiveOrders loads the orders, then all their items in one query, since
+// tablets poll this far more often than anything reads order history
+func (repo *KitchenRepository) GetActiveOrders(ctx context.Context, restaurantID string) ([]model.OrderWithDetails, error) {
+	query := `
+		SELECT o.id, o.user_id, o.restaurant_id, o.status, o.total_amount,
+		       o.delivery_fee, o.delivery_address, o.payment_method, o.payment_status,
+		       o.created_at, o.updated_at, r.name, o.accepted_at, o.prep_minutes
+		FROM orders o
+		JOIN restaurants r ON o.restaurant_id = r.id
+		WHERE o.restaurant_id = $1
+		  AND o.status IN ('confirmed', 'preparing', 'ready')
+		ORDER BY o.created_at
+	`
+
+	rows, err := repo.pool.Query(ctx, query, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []model.OrderWithDetails
+	index := make(map[string]int)
+	for rows.Next() {
+		var o model.OrderWithDetails
+		err := rows.Scan(
+			&o.ID, &o.UserID, &o.RestaurantID, &o.Status, &o.TotalAmount,
+			&o.DeliveryFee, &o.DeliveryAddress, &o.PaymentMethod, &o.PaymentStatus,
+			&o.CreatedAt, &o.UpdatedAt, &o.RestaurantName, &o.AcceptedAt, &o.PrepMinutes,
+		)
+		if err != nil {
+			return nil, err
+		}
+		index[o.ID] = len(orders)
+		orders = append(orders, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return orders, nil
+	}
+
+	ids := make([]string, len(orders))
+	for i, o := range orders {
+		ids[i] = o.ID
+	}
+
+	itemRows, err := repo.pool.Query(ctx, `
+		SELECT id, order_id, COALESCE(menu_item_id::text, ''), quantity, price,
+		       item_name, item_image, is_veg, options, created_at, prepared_at
+		FROM order_items
+		WHERE order_id = ANY($1::uuid[])
+		ORDER BY created_at ASC
+	`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var item model.OrderItem
+		err := itemRows.Scan(
+			&item.ID, &item.OrderID, &item.MenuItemID, &item.Quantity, &item.Price,
+			&item.ItemName, &item.ItemImage, &item.IsVeg, &item.Options, &item.CreatedAt, &item.PreparedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		i := index[item.OrderID]
+		orders[i].Items = append(orders[i].Items, item)
+	}
+	return orders, itemRows.Err()
+}
+
+func (repo *KitchenRepository) SetItemPrepared(ctx context.Context, orderID, itemID string, done bool) error {
+	tag, err := repo.pool.Exec(ctx, `
+		UPDATE order_items
+		SET prepared_at = CASE WHEN $3 THEN COALESCE(prepared_at, NOW()) END
+		WHERE id = $2 AND order_id = $1
+	`, orderID, itemID, done)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (repo *KitchenRepository) GetPrepTarget(ctx context.Context, restaurantID string) (int, error) {
+	var minutes int
+	err := repo.pool.QueryRow(ctx, `
+		SELECT prep_target_minutes FROM kitchen_settings WHERE restaurant_id = $1
+	`, restaurantID).Scan(&minutes)
+	return minutes, err
+}
+
+func (repo *KitchenRepository) SetPrepTarget(ctx context.Context, restaurantID string, minutes int) error {
+	_, err := repo.pool.Exec(ctx, `
+		INSERT INTO kitchen_settings (restaurant_id, prep_target_minutes)
+		VALUES ($1, $2)
+		ON CONFLICT (restaurant_id) DO UPDATE
+		SET prep_target_minutes = EXCLUDED.prep_target_minutes, updated_at = NOW()
+	`, restaurantID, minutes)
+	return err
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// LoginAttemptRepository is the pgx-backed LoginAttemptStore
+type LoginAttemptRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewLoginAttemptRepository(pool *pgxpool.Pool) *LoginAttemptRepository {
+	return &LoginAttemptRepository{pool: pool}
+}
+
+func (repo *LoginAttemptRepository) GetLoginLock(ctx context.Context, email string) (time.Duration, error) {
+	query := `
+		SELECT COALESCE(GREATEST(locked_until - NOW(), INTERVAL '0'), INTERVAL '0')
+		FROM login_failures
+		WHERE email = $1
+	`
+
+	var remaining time.Duration
+	err := repo.pool.QueryRow(ctx, query, email).Scan(&remaining)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return remaining, nil
+}
+
+func (repo *LoginAttemptRepository) RecordLoginFailure(ctx context.Context, email string, window time.Duration) (int, error) {
+	query := `
+		INSERT INTO login_failures (email, failures, last_failed_at)
+		VALUES ($1, 1, NOW())
+		ON CONFLICT (email) DO UPDATE SET
+			failures = CASE
+				WHEN login_failures.last_failed_at < NOW() - $2::interval THEN 1
+				ELSE login_failures.failures + 1
+			END,
+			last_failed_at = NOW()
+		RETURNING failures
+	`
+
+	var failures int
+	err := repo.pool.QueryRow(ctx, query, email, window).Scan(&failures)
+	return failures, err
+}
+
+func (repo *LoginAttemptRepository) LockLogin(ctx context.Context, email string, d time.Duration) error {
+	query := `UPDATE login_failures SET locked_until = NOW() + $2::interval WHERE email = $1`
+
+	_, err := repo.pool.Exec(ctx, query, email, d)
+	return err
+}
+
+func (repo *LoginAttemptRepository) ClearLoginFailures(ctx context.Context, email string) error {
+	_, err := repo.pool.Exec(ctx, `DELETE FROM login_failures WHERE email = $1`, email)
+	return err
+}
+
+// PurgeLoginFailures drops rows whose last failure and lock are both older than olderThan
+func (repo *LoginAttemptRepository) PurgeLoginFailures(ctx context.Context, olderThan time.Duration) (int64, error) {
+	query := `
+		DELETE FROM login_failures
+		WHERE last_failed_at < NOW() - $1::interval
+		  AND (locked_until IS NULL OR locked_until < NOW())
+	`
+
+	tag, err := repo.pool.Exec(ctx, query, olderThan)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"context"
+	"quickbite/internal/model"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// MemberRepository is the pgx-backed MemberStore
+type MemberRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewMemberRepository(pool *pgxpool.Pool) *MemberRepository {
+	return &MemberRepository{pool: pool}
+}
+
+// ====== MEMBERS ======
+
+func (repo *MemberRepository) GetMember(ctx context.Context, restaurantID, userID string) (*model.RestaurantMember, error) {
+	query := `
+		SELECT m.restaurant_id, m.user_id, u.name, u.email, m.role, m.created_at
+		FROM restaurant_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.restaurant_id = $1 AND m.user_id = $2
+	`
+
+	m := &model.RestaurantMember{}
+	err := repo.pool.QueryRow(ctx, query, restaurantID, userID).Scan(
+		&m.RestaurantID,
+		&m.UserID,
+		&m.Name,
+		&m.Email,
+		&m.Role,
+		&m.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (repo *MemberRepository) GetMembers(ctx context.Context, restaurantID string) ([]model.RestaurantMember, error) {
+	query := `
+		SELECT m.restaurant_id, m.user_id, u.name, u.email, m.role, m.created_at
+		FROM restaurant_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.restaurant_id = $1
+		ORDER BY m.created_at
+	`
+
+	rows, err := repo.pool.Query(ctx, query, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []model.RestaurantMember{}
+	for rows.Next() {
+		var m model.RestaurantMember
+		if err := rows.Scan(&m.RestaurantID, &m.UserID, &m.Name, &m.Email, &m.Role, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+func (repo *MemberRepository) GetMembershipsByUser(ctx context.Context, userID string) ([]model.RestaurantMember, error) {
+	query := `
+		SELECT m.restaurant_id, m.user_id, u.name, u.email, m.role, m.created_at
+		FROM restaurant_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.user_id = $1
+		ORDER BY m.created_at
+	`
+
+	rows, err := repo.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []model.RestaurantMember{}
+	for rows.Next() {
+		var m model.RestaurantMember
+		if err := rows.Scan(&m.RestaurantID, &m.UserID, &m.Name, &m.Email, &m.Role, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+func (repo *MemberRepository) UpdateMemberRole(ctx context.Context, restaurantID, userID, role string) error {
+	_, err := repo.pool.Exec(ctx, `
+		UPDATE restaurant_members SET role = $3 WHERE restaurant_id = $1 AND user_id = $2
+	`, restaurantID, userID, role)
+	return err
+}
+
+func (repo *MemberRepository) RemoveMember(ctx context.Context, restaurantID, userID string) error {
+	_, err := repo.pool.Exec(ctx, `
+		DELETE FROM restaurant_members WHERE restaurant_id = $1 AND user_id = $2
+	`, restaurantID, userID)
+	return err
+}
+
+// ====== INVITATIONS ======
+
+// SaveInvitation inserts inv, replacing the token, role and expiry of an
+// invitation still pending for the same email
+func (repo *MemberRepository) SaveInvitation(ctx context.Context, inv *model.Invitation) error {
+	query := `
+		INSERT INTO restaurant_invitations (restaurant_id, email, role, token_hash, invited_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (restaurant_id, email) WHERE accepted_at IS NULL DO UPDATE
+		SET role = EXCLUDED.role,
+		    token_hash = EXCLUDED.token_hash,
+		    invited_by = EXCLUDED.invited_by,
+		    created_at = NOW(),
+		    expires_at = EXCLUDED.expires_at
+		RETURNING id, created_at
+	`
+
+	return repo.pool.QueryRow(ctx, query,
+		inv.RestaurantID,
+		inv.Email,
+		inv.Role,
+		inv.TokenHash,
+		inv.InvitedBy,
+		inv.ExpiresAt,
+	).Scan(&inv.ID, &inv.CreatedAt)
+}
+
+func (repo *MemberRepository) GetInvitationByToken(ctx context.Context, tokenHash string) (*model.Invitation, error) {
+	query := `
+		SELECT id, restaurant_id, email, role, token_hash, COALESCE(invited_by::text, ''),
+		       created_at, expires_at, accepted_at
+		FROM restaurant_invitations
+		WHERE token_hash = $1
+	`
+
+	inv := &model.Invitation{}
+	err := repo.pool.QueryRow(ctx, query, tokenHash).Scan(
+		&inv.ID,
+		&inv.RestaurantID,
+		&inv.Email,
+		&inv.Role,
+		&inv.TokenHash,
+		&inv.InvitedBy,
+		&inv.CreatedAt,
+		&inv.ExpiresAt,
+		&inv.AcceptedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+func (repo *MemberRepository) GetPendingInvitations(ctx context.Context, restaurantID string) ([]model.Invitation, error) {
+	query := `
+		SELECT id, restaurant_id, email, role, token_hash, COALESCE(invited_by::text, ''),
+		       created_at, expires_at, accepted_at
+		FROM restaurant_invitations
+		WHERE restaurant_id = $1 AND accepted_at IS NULL
+		ORDER BY created_at DESC
+	`
+
+	rows, err := repo.pool.Query(ctx, query, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []model.Invitation{}
+	for rows.Next() {
+		var inv model.Invitation
+		err := rows.Scan(
+			&inv.ID,
+			&inv.RestaurantID,
+			&inv.Email,
+			&inv.Role,
+			&inv.TokenHash,
+			&inv.InvitedBy,
+			&inv.CreatedAt,
+			&inv.ExpiresAt,
+			&inv.AcceptedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, inv)
+	}
+	return invitations, rows.Err()
+}
+
+// DeleteInvitation revokes a pending invitation; accepted ones stay as a record
+func (repo *MemberRepository) DeleteInvitation(ctx context.Context, restaurantID, id string) error {
+	tag, err := repo.pool.Exec(ctx, `
+		DELETE FROM restaurant_invitations
+		WHERE id = $1 AND restaurant_id = $2 AND accepted_at IS NULL
+	`, id, restaurantID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStale
+	}
+	return nil
+}
+
+// AcceptInvitation marks the invitation accepted and gives userID its role.
+// An existing owner keeps the owner role.
+func (repo *MemberRepository) AcceptInvitation(ctx context.Context, id, userID string) error {
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var restaurantID, role string
+	err = tx.QueryRow(ctx, `
+		UPDATE restaurant_invitations
+		SET accepted_at = NOW()
+		WHERE id = $1 AND accepted_at IS NULL AND expires_at > NOW()
+		RETURNING restaurant_id, role
+	`, id).Scan(&restaurantID, &role)
+	if IsNotFound(err) {
+		return ErrStale
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO restaurant_members (restaurant_id, user_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (restaurant_id, user_id) DO UPDATE
+		SET role = EXCLUDED.role
+		WHERE restaurant_members.role <> 'owner'
+	`, restaurantID, userID, role)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
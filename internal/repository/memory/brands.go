@@ -0,0 +1,510 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"quickbite/internal/model"
+	"quickbite/internal/repository"
+)
+
+type overrideKey struct {
+	restaurantID string
+	brandItemID  string
+}
+
+// ====== BRANDS ======
+
+func (s *Store) CreateBrand(ctx context.Context, brand *model.Brand) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	brand.ID = newID()
+	brand.CreatedAt = now
+	brand.UpdatedAt = now
+	brand.Version = 1
+	brand.Outlets = nil
+	s.brands[brand.ID] = *brand
+
+	return nil
+}
+
+func (s *Store) GetBrandByID(ctx context.Context, id string) (*model.Brand, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.brands[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	return &b, nil
+}
+
+func (s *Store) GetBrandsByOwner(ctx context.Context, ownerID string) ([]model.Brand, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	brands := []model.Brand{}
+	for _, b := range s.brands {
+		if b.OwnerID == ownerID {
+			brands = append(brands, b)
+		}
+	}
+	sort.Slice(brands, func(i, j int) bool {
+		return brands[i].CreatedAt.After(brands[j].CreatedAt)
+	})
+	return brands, nil
+}
+
+func (s *Store) UpdateBrand(ctx context.Context, id string, version int, req *model.UpdateBrandRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.brands[id]
+	if !ok || !versionMatches(b.Version, version) {
+		return repository.ErrStale
+	}
+
+	b.Name = req.Name
+	b.Description = req.Description
+	b.LogoURL = req.LogoURL
+	b.Version++
+	b.UpdatedAt = time.Now()
+	s.brands[id] = b
+
+	return nil
+}
+
+// ====== OUTLETS ======
+
+func (s *Store) GetOutlets(ctx context.Context, brandID string) ([]model.Restaurant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	outlets := []model.Restaurant{}
+	for _, r := range s.restaurants {
+		if r.BrandID == brandID && r.DeletedAt == nil {
+			outlets = append(outlets, r)
+		}
+	}
+	sort.Slice(outlets, func(i, j int) bool {
+		return outlets[i].Name < outlets[j].Name
+	})
+	return outlets, nil
+}
+
+func (s *Store) AttachOutlet(ctx context.Context, brandID, restaurantID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.restaurants[restaurantID]
+	if !ok || r.BrandID != "" || r.DeletedAt != nil {
+		return repository.ErrStale
+	}
+	r.BrandID = brandID
+	r.Version++
+	r.UpdatedAt = time.Now()
+	s.restaurants[restaurantID] = r
+
+	return nil
+}
+
+func (s *Store) DetachOutlet(ctx context.Context, brandID, restaurantID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.restaurants[restaurantID]
+	if !ok || r.BrandID != brandID {
+		return repository.ErrStale
+	}
+	r.BrandID = ""
+	r.Version++
+	r.UpdatedAt = time.Now()
+	s.restaurants[restaurantID] = r
+
+	for cid, c := range s.categories {
+		if c.RestaurantID != restaurantID {
+			continue
+		}
+		for iid, item := range s.items {
+			if item.CategoryID == cid && item.BrandItemID != "" {
+				item.BrandItemID = ""
+				s.items[iid] = item
+			}
+		}
+		c.BrandCategoryID = ""
+		s.categories[cid] = c
+	}
+	for key := range s.overrides {
+		if key.restaurantID == restaurantID {
+			delete(s.overrides, key)
+		}
+	}
+
+	return nil
+}
+
+// ====== MASTER MENU ======
+
+func (s *Store) CreateBrandCategory(ctx context.Context, category *model.BrandCategory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	category.ID = newID()
+	category.CreatedAt = time.Now()
+	category.Items = nil
+	s.brandCategories[category.ID] = *category
+
+	return nil
+}
+
+func (s *Store) GetBrandCategoryByID(ctx context.Context, id string) (*model.BrandCategory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.brandCategories[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	return &c, nil
+}
+
+func (s *Store) DeleteBrandCategory(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for cid, c := range s.categories {
+		if c.BrandCategoryID != id {
+			continue
+		}
+		for iid, item := range s.items {
+			if item.CategoryID == cid && item.DeletedAt == nil {
+				item.DeletedAt = timePtr(now)
+				item.UpdatedAt = now
+				s.items[iid] = item
+			}
+		}
+		if c.DeletedAt == nil {
+			c.DeletedAt = timePtr(now)
+		}
+		c.BrandCategoryID = ""
+		s.categories[cid] = c
+	}
+
+	for bid, bi := range s.brandItems {
+		if bi.CategoryID == id {
+			s.deleteBrandItem(bid)
+		}
+	}
+	delete(s.brandCategories, id)
+
+	return nil
+}
+
+func (s *Store) CreateBrandItem(ctx context.Context, item *model.BrandMenuItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	item.ID = newID()
+	item.CreatedAt = now
+	item.UpdatedAt = now
+	item.Version = 1
+	s.brandItems[item.ID] = *item
+
+	return nil
+}
+
+func (s *Store) GetBrandItemByID(ctx context.Context, id string) (*model.BrandMenuItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.brandItems[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	return &item, nil
+}
+
+func (s *Store) UpdateBrandItem(ctx context.Context, id string, version int, req *model.UpdateBrandMenuItemRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.brandItems[id]
+	if !ok || !versionMatches(item.Version, version) {
+		return repository.ErrStale
+	}
+
+	item.CategoryID = req.CategoryID
+	item.Name = req.Name
+	item.Description = req.Description
+	item.Price = req.Price
+	item.ImageURL = req.ImageURL
+	item.IsAvailable = req.IsAvailable
+	item.IsVeg = req.IsVeg
+	item.Version++
+	item.UpdatedAt = time.Now()
+	s.brandItems[id] = item
+
+	return nil
+}
+
+func (s *Store) DeleteBrandItem(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for iid, item := range s.items {
+		if item.BrandItemID == id && item.DeletedAt == nil {
+			item.DeletedAt = timePtr(now)
+			item.UpdatedAt = now
+			s.items[iid] = item
+		}
+	}
+	s.deleteBrandItem(id)
+
+	return nil
+}
+
+// deleteBrandItem mimics the foreign keys on brand_menu_items: overrides
+// go with the item and outlet copies lose their link
+func (s *Store) deleteBrandItem(id string) {
+	for iid, item := range s.items {
+		if item.BrandItemID == id {
+			item.BrandItemID = ""
+			s.items[iid] = item
+		}
+	}
+	for key := range s.overrides {
+		if key.brandItemID == id {
+			delete(s.overrides, key)
+		}
+	}
+	delete(s.brandItems, id)
+}
+
+func (s *Store) GetBrandMenu(ctx context.Context, brandID string) ([]model.BrandCategory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	categories := []model.BrandCategory{}
+	for _, c := range s.brandCategories {
+		if c.BrandID != brandID {
+			continue
+		}
+		for _, item := range s.brandItems {
+			if item.CategoryID == c.ID {
+				c.Items = append(c.Items, item)
+			}
+		}
+		sort.Slice(c.Items, func(i, j int) bool {
+			return c.Items[i].CreatedAt.Before(c.Items[j].CreatedAt)
+		})
+		categories = append(categories, c)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].DisplayOrder != categories[j].DisplayOrder {
+			return categories[i].DisplayOrder < categories[j].DisplayOrder
+		}
+		return categories[i].CreatedAt.Before(categories[j].CreatedAt)
+	})
+	return categories, nil
+}
+
+// ====== OVERRIDES ======
+
+func (s *Store) GetOutletOverrides(ctx context.Context, restaurantID string) ([]model.OutletOverride, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	overrides := []model.OutletOverride{}
+	for key, o := range s.overrides {
+		if key.restaurantID == restaurantID {
+			overrides = append(overrides, o)
+		}
+	}
+	sort.Slice(overrides, func(i, j int) bool {
+		return overrides[i].UpdatedAt.After(overrides[j].UpdatedAt)
+	})
+	return overrides, nil
+}
+
+func (s *Store) SaveOutletOverride(ctx context.Context, override *model.OutletOverride) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	override.UpdatedAt = time.Now()
+	s.overrides[overrideKey{override.RestaurantID, override.BrandItemID}] = *override
+
+	return nil
+}
+
+func (s *Store) DeleteOutletOverride(ctx context.Context, restaurantID, brandItemID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.overrides, overrideKey{restaurantID, brandItemID})
+	return nil
+}
+
+// ====== SYNC ======
+
+// SyncBrandMenu follows the same rules as the Postgres statements: copies
+// the outlet deleted stay deleted, and a sold-out item stays off
+func (s *Store) SyncBrandMenu(ctx context.Context, brandID, restaurantID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.restaurants {
+		if r.BrandID != brandID || r.DeletedAt != nil || (restaurantID != "" && r.ID != restaurantID) {
+			continue
+		}
+		s.syncOutlet(r.ID, brandID)
+	}
+	return nil
+}
+
+func (s *Store) syncOutlet(restaurantID, brandID string) {
+	now := time.Now()
+
+	categoryCopies := make(map[string]string) // brand category id -> outlet category id
+	for cid, c := range s.categories {
+		if c.RestaurantID == restaurantID && c.BrandCategoryID != "" {
+			categoryCopies[c.BrandCategoryID] = cid
+		}
+	}
+	for _, bc := range s.brandCategories {
+		if bc.BrandID != brandID {
+			continue
+		}
+		if cid, ok := categoryCopies[bc.ID]; ok {
+			c := s.categories[cid]
+			c.Name = bc.Name
+			c.DisplayOrder = bc.DisplayOrder
+			s.categories[cid] = c
+			continue
+		}
+		c := model.MenuCategory{
+			ID:              newID(),
+			RestaurantID:    restaurantID,
+			Name:            bc.Name,
+			DisplayOrder:    bc.DisplayOrder,
+			CreatedAt:       now,
+			BrandCategoryID: bc.ID,
+		}
+		s.categories[c.ID] = c
+		categoryCopies[bc.ID] = c.ID
+	}
+
+	itemCopies := make(map[string]string) // brand item id -> outlet item id
+	for iid, item := range s.items {
+		if item.BrandItemID != "" && s.categories[item.CategoryID].RestaurantID == restaurantID {
+			itemCopies[item.BrandItemID] = iid
+		}
+	}
+	for _, bi := range s.brandItems {
+		target, ok := categoryCopies[bi.CategoryID]
+		if !ok {
+			continue // a category of another brand
+		}
+
+		price, available := bi.Price, bi.IsAvailable
+		if o, ok := s.overrides[overrideKey{restaurantID, bi.ID}]; ok {
+			if o.Price != nil {
+				price = *o.Price
+			}
+			if o.IsAvailable != nil {
+				available = *o.IsAvailable
+			}
+		}
+
+		iid, ok := itemCopies[bi.ID]
+		if !ok {
+			item := model.MenuItem{
+				ID:          newID(),
+				CategoryID:  target,
+				Name:        bi.Name,
+				Description: bi.Description,
+				Price:       price,
+				ImageURL:    bi.ImageURL,
+				IsAvailable: available,
+				IsVeg:       bi.IsVeg,
+				CreatedAt:   now,
+				UpdatedAt:   now,
+				Version:     1,
+				DeletedAt:   s.categories[target].DeletedAt,
+				BrandItemID: bi.ID,
+			}
+			s.items[item.ID] = item
+			continue
+		}
+
+		item := s.items[iid]
+		if !available {
+			delete(s.soldOut, iid)
+		}
+		available = available && (item.StockQuantity == nil || *item.StockQuantity > 0)
+		if item.CategoryID == target && item.Name == bi.Name && item.Description == bi.Description &&
+			item.Price == price && item.ImageURL == bi.ImageURL && item.IsVeg == bi.IsVeg && item.IsAvailable == available {
+			continue
+		}
+		item.CategoryID = target
+		item.Name = bi.Name
+		item.Description = bi.Description
+		item.Price = price
+		item.ImageURL = bi.ImageURL
+		item.IsVeg = bi.IsVeg
+		item.IsAvailable = available
+		item.Version++
+		item.UpdatedAt = now
+		s.items[iid] = item
+	}
+}
+
+// ====== DASHBOARD ======
+
+func (s *Store) GetOutletStats(ctx context.Context, brandID string, from, to *time.Time) ([]model.OutletStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byOutlet := make(map[string]*model.OutletStats)
+	stats := []model.OutletStats{}
+	for _, r := range s.restaurants {
+		if r.BrandID == brandID && r.DeletedAt == nil {
+			byOutlet[r.ID] = &model.OutletStats{RestaurantID: r.ID, Name: r.Name, City: r.City}
+		}
+	}
+
+	for _, o := range s.orders {
+		line, ok := byOutlet[o.RestaurantID]
+		if !ok {
+			continue
+		}
+		if (from != nil && o.CreatedAt.Before(*from)) || (to != nil && !o.CreatedAt.Before(*to)) {
+			continue
+		}
+		switch o.Status {
+		case "delivered":
+			line.Orders++
+			line.Revenue += o.TotalAmount
+		case "cancelled":
+			line.CancelledOrders++
+		default:
+			line.ActiveOrders++
+		}
+	}
+
+	for _, line := range byOutlet {
+		stats = append(stats, *line)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Revenue != stats[j].Revenue {
+			return stats[i].Revenue > stats[j].Revenue
+		}
+		return stats[i].Name < stats[j].Name
+	})
+	return stats, nil
+}
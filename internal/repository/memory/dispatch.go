@@ -0,0 +1,347 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"quickbite/internal/model"
+	"quickbite/internal/repository"
+)
+
+type location struct {
+	lat, lng float64
+}
+
+// ====== PARTNERS ======
+
+func (s *Store) GetPartner(ctx context.Context, userID string) (*model.DeliveryPartner, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.partners[userID]
+	if !ok {
+		return nil, errNotFound
+	}
+	return &p, nil
+}
+
+func (s *Store) SetPartnerOnline(ctx context.Context, userID string, online bool) (*model.DeliveryPartner, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.partners[userID]
+	p.UserID = userID
+	p.IsOnline = online
+	p.UpdatedAt = time.Now()
+	s.partners[userID] = p
+
+	return &p, nil
+}
+
+func (s *Store) UpdatePartnerLocation(ctx context.Context, userID string, lat, lng float64) (*model.DeliveryPartner, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	p := s.partners[userID]
+	p.UserID = userID
+	p.Latitude = &lat
+	p.Longitude = &lng
+	p.LocationUpdatedAt = timePtr(now)
+	p.UpdatedAt = now
+	s.partners[userID] = p
+
+	return &p, nil
+}
+
+func (s *Store) SetRestaurantLocation(ctx context.Context, restaurantID string, lat, lng float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.locations[restaurantID] = location{lat: lat, lng: lng}
+	return nil
+}
+
+// ====== DISPATCH ======
+
+func (s *Store) GetUndispatchedOrders(ctx context.Context, limit int) ([]model.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var orders []model.Order
+	for _, o := range s.orders {
+		if _, ok := s.locations[o.RestaurantID]; !ok || o.Status != "ready" {
+			continue
+		}
+		if _, open := s.openAssignment(func(a model.DeliveryAssignment) bool { return a.OrderID == o.ID }); open {
+			continue
+		}
+		orders = append(orders, o)
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].UpdatedAt.Before(orders[j].UpdatedAt)
+	})
+	if len(orders) > limit {
+		orders = orders[:limit]
+	}
+
+	return orders, nil
+}
+
+func (s *Store) FindNearestPartner(ctx context.Context, orderID string, maxKm float64, fresh time.Duration) (string, float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[orderID]
+	if !ok {
+		return "", 0, errNotFound
+	}
+	restaurant, ok := s.locations[o.RestaurantID]
+	if !ok {
+		return "", 0, errNotFound
+	}
+
+	busy := make(map[string]bool)
+	missed := make(map[string]bool)
+	for _, a := range s.assignments {
+		switch {
+		case a.Status == "offered" || a.Status == "accepted":
+			busy[a.PartnerID] = true
+		case a.OrderID == orderID && a.Status == "rejected":
+			busy[a.PartnerID] = true
+		case a.OrderID == orderID:
+			missed[a.PartnerID] = true
+		}
+	}
+
+	bestID, bestKm, bestMissed := "", math.Inf(1), true
+	since := time.Now().Add(-fresh)
+	for _, p := range s.partners {
+		if !p.IsOnline || p.LocationUpdatedAt == nil || !p.LocationUpdatedAt.After(since) || busy[p.UserID] {
+			continue
+		}
+		km := haversineKm(restaurant, location{lat: *p.Latitude, lng: *p.Longitude})
+		if km > maxKm {
+			continue
+		}
+		// Same order as the SQL: partners who missed it last, then by distance
+		if bestID == "" || (bestMissed && !missed[p.UserID]) || (bestMissed == missed[p.UserID] && km < bestKm) {
+			bestID, bestKm, bestMissed = p.UserID, km, missed[p.UserID]
+		}
+	}
+	if bestID == "" {
+		return "", 0, errNotFound
+	}
+	return bestID, bestKm, nil
+}
+
+func (s *Store) CreateOffer(ctx context.Context, a *model.DeliveryAssignment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, taken := s.openAssignment(func(open model.DeliveryAssignment) bool {
+		return open.OrderID == a.OrderID || open.PartnerID == a.PartnerID
+	})
+	if taken {
+		return repository.ErrStale
+	}
+
+	a.ID = newID()
+	a.Status = "offered"
+	a.OfferedAt = time.Now()
+	s.assignments[a.ID] = *a
+
+	return nil
+}
+
+func (s *Store) ReleaseStaleAssignments(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	now := time.Now()
+	for id, a := range s.assignments {
+		switch {
+		case a.Status == "offered" && !a.ExpiresAt.After(now):
+			a.Status = "expired"
+		case a.Status == "offered" || a.Status == "accepted":
+			if status := s.orders[a.OrderID].Status; status != "delivered" && status != "cancelled" {
+				continue
+			}
+			a.Status = "released"
+		default:
+			continue
+		}
+		s.assignments[id] = a
+		count++
+	}
+
+	return count, nil
+}
+
+// ====== ASSIGNMENTS ======
+
+func (s *Store) GetAssignment(ctx context.Context, id string) (*model.DeliveryAssignment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.assignments[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	return &a, nil
+}
+
+func (s *Store) GetPartnerTasks(ctx context.Context, partnerID string) ([]model.DeliveryTask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks := []model.DeliveryTask{}
+	for _, a := range s.assignments {
+		if a.PartnerID != partnerID || (a.Status != "offered" && a.Status != "accepted") {
+			continue
+		}
+		o := s.orders[a.OrderID]
+		r := s.restaurants[o.RestaurantID]
+		tasks = append(tasks, model.DeliveryTask{
+			DeliveryAssignment: a,
+			RestaurantName:     r.Name,
+			RestaurantAddress:  r.Address,
+			DeliveryAddress:    o.DeliveryAddress,
+			OrderStatus:        o.Status,
+			TotalAmount:        o.TotalAmount,
+			PaymentMethod:      o.PaymentMethod,
+		})
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].OfferedAt.Before(tasks[j].OfferedAt)
+	})
+
+	return tasks, nil
+}
+
+func (s *Store) GetOrderDelivery(ctx context.Context, orderID string) (*model.OrderDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var latest *model.DeliveryAssignment
+	for _, a := range s.assignments {
+		if a.OrderID != orderID || (a.Status != "accepted" && a.Status != "delivered") {
+			continue
+		}
+		if latest == nil || a.OfferedAt.After(latest.OfferedAt) {
+			latest = &a
+		}
+	}
+	if latest == nil {
+		return nil, errNotFound
+	}
+
+	partner := s.partners[latest.PartnerID]
+	user := s.users[latest.PartnerID]
+	return &model.OrderDelivery{
+		OrderID:           latest.OrderID,
+		Status:            latest.Status,
+		PartnerName:       user.Name,
+		PartnerPhone:      user.Phone,
+		Latitude:          partner.Latitude,
+		Longitude:         partner.Longitude,
+		LocationUpdatedAt: partner.LocationUpdatedAt,
+		PickedUpAt:        latest.PickedUpAt,
+		DeliveredAt:       latest.DeliveredAt,
+	}, nil
+}
+
+func (s *Store) AcceptOffer(ctx context.Context, id string) error {
+	return s.respond(id, "accepted")
+}
+
+func (s *Store) RejectOffer(ctx context.Context, id string) error {
+	return s.respond(id, "rejected")
+}
+
+func (s *Store) respond(id, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.assignments[id]
+	if !ok || a.Status != "offered" || !a.ExpiresAt.After(time.Now()) {
+		return repository.ErrStale
+	}
+	a.Status = status
+	a.RespondedAt = timePtr(time.Now())
+	s.assignments[id] = a
+
+	return nil
+}
+
+func (s *Store) PickUp(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.assignments[id]
+	if !ok || a.Status != "accepted" || a.PickedUpAt != nil {
+		return repository.ErrStale
+	}
+	o := s.orders[a.OrderID]
+	if o.Status != "ready" {
+		return repository.ErrStale
+	}
+
+	now := time.Now()
+	a.PickedUpAt = timePtr(now)
+	s.assignments[id] = a
+	o.Status = "out_for_delivery"
+	o.UpdatedAt = now
+	s.orders[o.ID] = o
+
+	return nil
+}
+
+func (s *Store) CompleteDelivery(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.assignments[id]
+	if !ok || a.Status != "accepted" || a.PickedUpAt == nil {
+		return repository.ErrStale
+	}
+	o := s.orders[a.OrderID]
+	if o.Status != "out_for_delivery" {
+		return repository.ErrStale
+	}
+
+	now := time.Now()
+	a.Status = "delivered"
+	a.DeliveredAt = timePtr(now)
+	s.assignments[id] = a
+	o.Status = "delivered"
+	o.UpdatedAt = now
+	s.orders[o.ID] = o
+
+	return nil
+}
+
+// openAssignment finds an offered or accepted assignment matching match
+func (s *Store) openAssignment(match func(model.DeliveryAssignment) bool) (model.DeliveryAssignment, bool) {
+	for _, a := range s.assignments {
+		if (a.Status == "offered" || a.Status == "accepted") && match(a) {
+			return a, true
+		}
+	}
+	return model.DeliveryAssignment{}, false
+}
+
+// haversineKm is the great-circle distance, as the Postgres query computes it
+func haversineKm(a, b location) float64 {
+	const earthRadiusKm = 6371
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := rad(b.lat - a.lat)
+	dLng := rad(b.lng - a.lng)
+	h := math.Pow(math.Sin(dLat/2), 2) +
+		math.Cos(rad(a.lat))*math.Cos(rad(b.lat))*math.Pow(math.Sin(dLng/2), 2)
+	return earthRadiusKm * 2 * math.Asin(math.Sqrt(h))
+}
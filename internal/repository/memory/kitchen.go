@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"quickbite/internal/model"
+)
+
+func (s *Store) GetActiveOrders(ctx context.Context, restaurantID string) ([]model.OrderWithDetails, error) {
+	orders := s.listOrders(func(o model.Order) bool {
+		if o.RestaurantID != restaurantID {
+			return false
+		}
+		return o.Status == "confirmed" || o.Status == "preparing" || o.Status == "ready"
+	})
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].CreatedAt.Before(orders[j].CreatedAt)
+	})
+	return orders, nil
+}
+
+func (s *Store) SetItemPrepared(ctx context.Context, orderID, itemID string, done bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := s.orderItems[orderID]
+	for i := range items {
+		if items[i].ID != itemID {
+			continue
+		}
+		switch {
+		case !done:
+			items[i].PreparedAt = nil
+		case items[i].PreparedAt == nil:
+			items[i].PreparedAt = timePtr(time.Now())
+		}
+		return nil
+	}
+	return errNotFound
+}
+
+func (s *Store) GetPrepTarget(ctx context.Context, restaurantID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	minutes, ok := s.prepTargets[restaurantID]
+	if !ok {
+		return 0, errNotFound
+	}
+	return minutes, nil
+}
+
+func (s *Store) SetPrepTarget(ctx context.Context, restaurantID string, minutes int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prepTargets[restaurantID] = minutes
+	return nil
+}
@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"context"
+	"time"
+)
+
+type loginFailure struct {
+	failures     int
+	lastFailedAt time.Time
+	lockedUntil  time.Time
+}
+
+func (s *Store) GetLoginLock(ctx context.Context, email string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.loginFailures[email]
+	if !ok {
+		return 0, nil
+	}
+	return max(time.Until(f.lockedUntil), 0), nil
+}
+
+func (s *Store) RecordLoginFailure(ctx context.Context, email string, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	f := s.loginFailures[email]
+	if f.lastFailedAt.Before(now.Add(-window)) {
+		f.failures = 0
+	}
+	f.failures++
+	f.lastFailedAt = now
+	s.loginFailures[email] = f
+
+	return f.failures, nil
+}
+
+func (s *Store) LockLogin(ctx context.Context, email string, d time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.loginFailures[email]
+	if !ok {
+		return nil
+	}
+	f.lockedUntil = time.Now().Add(d)
+	s.loginFailures[email] = f
+
+	return nil
+}
+
+func (s *Store) ClearLoginFailures(ctx context.Context, email string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.loginFailures, email)
+	return nil
+}
+
+func (s *Store) PurgeLoginFailures(ctx context.Context, olderThan time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var purged int64
+	for email, f := range s.loginFailures {
+		if f.lastFailedAt.Before(now.Add(-olderThan)) && f.lockedUntil.Before(now) {
+			delete(s.loginFailures, email)
+			purged++
+		}
+	}
+	return purged, nil
+}
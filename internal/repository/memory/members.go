@@ -0,0 +1,181 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"quickbite/internal/model"
+	"quickbite/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type memberKey struct {
+	restaurantID string
+	userID       string
+}
+
+// ====== MEMBERS ======
+
+func (s *Store) GetMember(ctx context.Context, restaurantID, userID string) (*model.RestaurantMember, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.members[memberKey{restaurantID, userID}]
+	if !ok {
+		return nil, errNotFound
+	}
+	m = s.withUser(m)
+	return &m, nil
+}
+
+func (s *Store) GetMembers(ctx context.Context, restaurantID string) ([]model.RestaurantMember, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	members := []model.RestaurantMember{}
+	for key, m := range s.members {
+		if key.restaurantID == restaurantID {
+			members = append(members, s.withUser(m))
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].CreatedAt.Before(members[j].CreatedAt)
+	})
+	return members, nil
+}
+
+func (s *Store) GetMembershipsByUser(ctx context.Context, userID string) ([]model.RestaurantMember, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	members := []model.RestaurantMember{}
+	for key, m := range s.members {
+		if key.userID == userID {
+			members = append(members, s.withUser(m))
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].CreatedAt.Before(members[j].CreatedAt)
+	})
+	return members, nil
+}
+
+func (s *Store) UpdateMemberRole(ctx context.Context, restaurantID, userID, role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := memberKey{restaurantID, userID}
+	if m, ok := s.members[key]; ok {
+		m.Role = role
+		s.members[key] = m
+	}
+	return nil
+}
+
+func (s *Store) RemoveMember(ctx context.Context, restaurantID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.members, memberKey{restaurantID, userID})
+	return nil
+}
+
+// withUser fills in the name and email the Postgres query joins from users
+func (s *Store) withUser(m model.RestaurantMember) model.RestaurantMember {
+	u := s.users[m.UserID]
+	m.Name = u.Name
+	m.Email = u.Email
+	return m
+}
+
+// ====== INVITATIONS ======
+
+func (s *Store) SaveInvitation(ctx context.Context, inv *model.Invitation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, existing := range s.invitations {
+		if existing.TokenHash == inv.TokenHash {
+			return &pgconn.PgError{Code: "23505", ConstraintName: "restaurant_invitations_token_hash_key"}
+		}
+		// The pending one for this email is refreshed in place, as ON CONFLICT does
+		if existing.RestaurantID == inv.RestaurantID && existing.Email == inv.Email && existing.AcceptedAt == nil {
+			inv.ID = id
+		}
+	}
+
+	if inv.ID == "" {
+		inv.ID = newID()
+	}
+	inv.CreatedAt = time.Now()
+	inv.AcceptedAt = nil
+	s.invitations[inv.ID] = *inv
+
+	return nil
+}
+
+func (s *Store) GetInvitationByToken(ctx context.Context, tokenHash string) (*model.Invitation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, inv := range s.invitations {
+		if inv.TokenHash == tokenHash {
+			return &inv, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (s *Store) GetPendingInvitations(ctx context.Context, restaurantID string) ([]model.Invitation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	invitations := []model.Invitation{}
+	for _, inv := range s.invitations {
+		if inv.RestaurantID == restaurantID && inv.AcceptedAt == nil {
+			invitations = append(invitations, inv)
+		}
+	}
+	sort.Slice(invitations, func(i, j int) bool {
+		return invitations[i].CreatedAt.After(invitations[j].CreatedAt)
+	})
+	return invitations, nil
+}
+
+func (s *Store) DeleteInvitation(ctx context.Context, restaurantID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inv, ok := s.invitations[id]
+	if !ok || inv.RestaurantID != restaurantID || inv.AcceptedAt != nil {
+		return repository.ErrStale
+	}
+	delete(s.invitations, id)
+	return nil
+}
+
+func (s *Store) AcceptInvitation(ctx context.Context, id, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inv, ok := s.invitations[id]
+	now := time.Now()
+	if !ok || inv.AcceptedAt != nil || !now.Before(inv.ExpiresAt) {
+		return repository.ErrStale
+	}
+	inv.AcceptedAt = timePtr(now)
+	s.invitations[id] = inv
+
+	key := memberKey{inv.RestaurantID, userID}
+	m, exists := s.members[key]
+	switch {
+	case !exists:
+		s.members[key] = model.RestaurantMember{RestaurantID: inv.RestaurantID, UserID: userID, Role: inv.Role, CreatedAt: now}
+	case m.Role != "owner":
+		m.Role = inv.Role
+		s.members[key] = m
+	}
+	return nil
+}
@@ -0,0 +1,369 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"quickbite/internal/model"
+	"quickbite/internal/repository"
+)
+
+// ====== MENU CATEGORIES ======
+
+func (s *Store) CreateCategory(ctx context.Context, category *model.MenuCategory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	category.ID = newID()
+	category.CreatedAt = time.Now()
+	s.categories[category.ID] = *category
+
+	return nil
+}
+
+func (s *Store) GetCategoriesByRestaurant(ctx context.Context, restaurantID string) ([]model.MenuCategory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var categories []model.MenuCategory
+	for _, c := range s.categories {
+		if c.RestaurantID == restaurantID && c.DeletedAt == nil {
+			categories = append(categories, c)
+		}
+	}
+	sort.SliceStable(categories, func(i, j int) bool {
+		return categories[i].DisplayOrder < categories[j].DisplayOrder
+	})
+
+	return categories, nil
+}
+
+func (s *Store) GetCategoryByID(ctx context.Context, id string) (*model.MenuCategory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.categories[id]
+	if !ok || c.DeletedAt != nil {
+		return nil, errNotFound
+	}
+	return &c, nil
+}
+
+func (s *Store) DeleteCategory(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.categories[id]
+	if !ok || c.DeletedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	for iid, item := range s.items {
+		if item.CategoryID == id && item.DeletedAt == nil {
+			item.DeletedAt = timePtr(now)
+			item.UpdatedAt = now
+			s.items[iid] = item
+		}
+	}
+	c.DeletedAt = timePtr(now)
+	s.categories[id] = c
+
+	return nil
+}
+
+func (s *Store) GetDeletedCategoryByID(ctx context.Context, id string, retention time.Duration) (*model.MenuCategory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.categories[id]
+	if !ok || !deletedWithin(c.DeletedAt, retention) {
+		return nil, errNotFound
+	}
+	return &c, nil
+}
+
+func (s *Store) RestoreCategory(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.categories[id]
+	if !ok || c.DeletedAt == nil {
+		return nil
+	}
+
+	for iid, item := range s.items {
+		if item.CategoryID == id && item.DeletedAt != nil && item.DeletedAt.Equal(*c.DeletedAt) {
+			item.DeletedAt = nil
+			item.UpdatedAt = time.Now()
+			s.items[iid] = item
+		}
+	}
+	c.DeletedAt = nil
+	s.categories[id] = c
+
+	return nil
+}
+
+// ====== MENU ITEMS ======
+
+func (s *Store) CreateMenuItem(ctx context.Context, item *model.MenuItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	item.ID = newID()
+	item.CreatedAt = now
+	item.UpdatedAt = now
+	item.Version = 1
+
+	stored := *item
+	stored.StockQuantity = intPtr(item.StockQuantity)
+	stored.DailyStock = intPtr(item.DailyStock)
+	s.items[item.ID] = stored
+
+	return nil
+}
+
+func (s *Store) GetMenuItemsByCategory(ctx context.Context, categoryID string) ([]model.MenuItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []model.MenuItem
+	for _, item := range s.items {
+		if item.CategoryID == categoryID && item.DeletedAt == nil {
+			items = append(items, s.copyItem(item))
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].CreatedAt.Before(items[j].CreatedAt)
+	})
+
+	return items, nil
+}
+
+func (s *Store) GetMenuItemByID(ctx context.Context, id string) (*model.MenuItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[id]
+	if !ok || item.DeletedAt != nil {
+		return nil, errNotFound
+	}
+	item = s.copyItem(item)
+	return &item, nil
+}
+
+func (s *Store) UpdateMenuItem(ctx context.Context, id string, version int, req *model.UpdateMenuItemRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[id]
+	if !ok || item.DeletedAt != nil || !versionMatches(item.Version, version) {
+		return repository.ErrStale
+	}
+
+	item.Name = req.Name
+	item.Description = req.Description
+	item.Price = req.Price
+	item.ImageURL = req.ImageURL
+	item.IsAvailable = req.IsAvailable
+	item.IsVeg = req.IsVeg
+	item.Version++
+	delete(s.soldOut, id)
+	item.UpdatedAt = time.Now()
+	s.items[id] = item
+
+	return nil
+}
+
+func (s *Store) PatchMenuItem(ctx context.Context, id string, version int, req *model.PatchMenuItemRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[id]
+	if !ok || item.DeletedAt != nil || !versionMatches(item.Version, version) {
+		return repository.ErrStale
+	}
+
+	patch(&item.Name, req.Name)
+	patch(&item.Description, req.Description)
+	patch(&item.Price, req.Price)
+	patch(&item.ImageURL, req.ImageURL)
+	patch(&item.IsAvailable, req.IsAvailable)
+	patch(&item.IsVeg, req.IsVeg)
+	if req.IsAvailable.Present {
+		delete(s.soldOut, id)
+	}
+	item.Version++
+	item.UpdatedAt = time.Now()
+	s.items[id] = item
+
+	return nil
+}
+
+func (s *Store) DeleteMenuItem(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[id]
+	if !ok || item.DeletedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	item.DeletedAt = timePtr(now)
+	item.UpdatedAt = now
+	s.items[id] = item
+
+	return nil
+}
+
+func (s *Store) GetDeletedMenuItemByID(ctx context.Context, id string, retention time.Duration) (*model.MenuItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[id]
+	if !ok || !deletedWithin(item.DeletedAt, retention) {
+		return nil, errNotFound
+	}
+	item = s.copyItem(item)
+	return &item, nil
+}
+
+func (s *Store) RestoreMenuItem(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[id]
+	if !ok {
+		return nil
+	}
+	item.DeletedAt = nil
+	item.UpdatedAt = time.Now()
+	s.items[id] = item
+
+	return nil
+}
+
+// ====== STOCK ======
+
+func (s *Store) UpdateMenuItemStock(ctx context.Context, id string, req *model.UpdateStockRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[id]
+	if !ok {
+		return nil
+	}
+
+	item.StockQuantity = intPtr(req.StockQuantity)
+	item.DailyStock = intPtr(req.DailyStock)
+	if req.StockQuantity != nil {
+		item.IsAvailable = *req.StockQuantity > 0
+		s.soldOut[id] = *req.StockQuantity == 0
+	}
+	item.UpdatedAt = time.Now()
+	s.items[id] = item
+
+	return nil
+}
+
+func (s *Store) ResetDailyStock(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	for id, item := range s.items {
+		if item.DailyStock == nil || item.DeletedAt != nil {
+			continue
+		}
+
+		restaurant := s.restaurants[s.categories[item.CategoryID].RestaurantID]
+		loc, err := time.LoadLocation(restaurant.Timezone)
+		if err != nil {
+			continue
+		}
+		now := time.Now().In(loc)
+		today := now.Format("2006-01-02")
+
+		if now.Format("15:04") < restaurant.OpensAt || s.stockResetOn[id] >= today {
+			continue
+		}
+
+		if s.soldOut[id] && *item.DailyStock > 0 {
+			item.IsAvailable = true
+			delete(s.soldOut, id)
+		}
+		item.StockQuantity = intPtr(item.DailyStock)
+		item.UpdatedAt = time.Now()
+		s.items[id] = item
+		s.stockResetOn[id] = today
+		count++
+	}
+
+	return count, nil
+}
+
+// ====== AVAILABILITY WINDOWS ======
+
+func (s *Store) GetWindowsByCategories(ctx context.Context, categoryIDs []string) (map[string][]model.AvailabilityWindow, error) {
+	return s.getWindows(categoryIDs), nil
+}
+
+func (s *Store) GetWindowsByMenuItems(ctx context.Context, itemIDs []string) (map[string][]model.AvailabilityWindow, error) {
+	return s.getWindows(itemIDs), nil
+}
+
+func (s *Store) ReplaceCategoryWindows(ctx context.Context, categoryID string, windows []model.AvailabilityWindow) error {
+	s.replaceWindows(categoryID, windows)
+	return nil
+}
+
+func (s *Store) ReplaceMenuItemWindows(ctx context.Context, itemID string, windows []model.AvailabilityWindow) error {
+	s.replaceWindows(itemID, windows)
+	return nil
+}
+
+func (s *Store) getWindows(ids []string) map[string][]model.AvailabilityWindow {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	windows := make(map[string][]model.AvailabilityWindow)
+	for _, id := range ids {
+		if w, ok := s.windows[id]; ok {
+			windows[id] = cloneWindows(w)
+		}
+	}
+	return windows
+}
+
+func (s *Store) replaceWindows(ownerID string, windows []model.AvailabilityWindow) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(windows) == 0 {
+		delete(s.windows, ownerID)
+		return
+	}
+	s.windows[ownerID] = cloneWindows(windows)
+}
+
+func cloneWindows(windows []model.AvailabilityWindow) []model.AvailabilityWindow {
+	cloned := make([]model.AvailabilityWindow, len(windows))
+	for i, w := range windows {
+		w.Days = slices.Clone(w.Days)
+		cloned[i] = w
+	}
+	sort.Slice(cloned, func(i, j int) bool { return cloned[i].StartTime < cloned[j].StartTime })
+	return cloned
+}
+
+// copyItem detaches the stock pointers so callers can't mutate the store
+func (s *Store) copyItem(item model.MenuItem) model.MenuItem {
+	item.StockQuantity = intPtr(item.StockQuantity)
+	item.DailyStock = intPtr(item.DailyStock)
+	return item
+}
@@ -0,0 +1,344 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"quickbite/internal/model"
+	"quickbite/internal/repository"
+)
+
+func (s *Store) CreateOrder(ctx context.Context, order *model.Order, items []model.OrderItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	quantities := make(map[string]int)
+	for _, item := range items {
+		quantities[item.MenuItemID] += item.Quantity
+	}
+
+	// Check everything before touching stock so a failure leaves no trace
+	for id, want := range quantities {
+		menuItem, ok := s.items[id]
+		if !ok {
+			return errNotFound
+		}
+		if !menuItem.IsAvailable || menuItem.DeletedAt != nil {
+			return &repository.OutOfStockError{ItemName: menuItem.Name}
+		}
+		if menuItem.StockQuantity != nil && *menuItem.StockQuantity < want {
+			return &repository.OutOfStockError{ItemName: menuItem.Name, Available: *menuItem.StockQuantity}
+		}
+	}
+
+	for id, want := range quantities {
+		menuItem := s.items[id]
+		if menuItem.StockQuantity == nil {
+			continue
+		}
+		left := *menuItem.StockQuantity - want
+		menuItem.StockQuantity = &left
+		menuItem.IsAvailable = left > 0
+		s.soldOut[id] = left == 0
+		menuItem.UpdatedAt = time.Now()
+		s.items[id] = menuItem
+	}
+
+	now := time.Now()
+	order.ID = newID()
+	order.CreatedAt = now
+	order.UpdatedAt = now
+	s.orders[order.ID] = *order
+
+	stored := make([]model.OrderItem, len(items))
+	for i := range items {
+		items[i].ID = newID()
+		items[i].OrderID = order.ID
+		items[i].CreatedAt = now
+		if items[i].Options == nil {
+			items[i].Options = []string{}
+		}
+		stored[i] = items[i]
+		stored[i].Options = slices.Clone(items[i].Options)
+	}
+	s.orderItems[order.ID] = stored
+
+	return nil
+}
+
+func (s *Store) GetOrderByID(ctx context.Context, id string) (*model.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	return &o, nil
+}
+
+func (s *Store) GetOrderWithDetails(ctx context.Context, id string) (*model.OrderWithDetails, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	details := s.details(o)
+	return &details, nil
+}
+
+func (s *Store) GetOrdersByUser(ctx context.Context, userID string) ([]model.OrderWithDetails, error) {
+	return s.listOrders(func(o model.Order) bool { return o.UserID == userID }), nil
+}
+
+func (s *Store) GetOrdersByRestaurant(ctx context.Context, restaurantID string) ([]model.OrderWithDetails, error) {
+	return s.listOrders(func(o model.Order) bool { return o.RestaurantID == restaurantID }), nil
+}
+
+func (s *Store) UpdateOrderStatus(ctx context.Context, orderID string, from, to string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[orderID]
+	if !ok || o.Status != from {
+		return repository.ErrStale
+	}
+	o.Status = to
+	o.UpdatedAt = time.Now()
+	s.orders[orderID] = o
+
+	return nil
+}
+
+func (s *Store) ConfirmOrder(ctx context.Context, orderID string, from, to, deliveryCode string, prepMinutes int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[orderID]
+	if !ok || o.Status != from {
+		return repository.ErrStale
+	}
+	now := time.Now()
+	o.Status = to
+	o.DeliveryCode = deliveryCode
+	o.DeliveryCodeAttempts = 0
+	o.AcceptedAt = timePtr(now)
+	o.PrepMinutes = nil
+	if prepMinutes != 0 {
+		o.PrepMinutes = &prepMinutes
+	}
+	o.UpdatedAt = now
+	s.orders[orderID] = o
+
+	return nil
+}
+
+func (s *Store) CancelOrder(ctx context.Context, orderID string, from string) error {
+	return s.cancel(orderID, from, "")
+}
+
+func (s *Store) RejectOrder(ctx context.Context, orderID string, reason string) error {
+	return s.cancel(orderID, "pending", reason)
+}
+
+func (s *Store) cancel(orderID string, from string, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[orderID]
+	if !ok || o.Status != from {
+		return repository.ErrStale
+	}
+	o.Status = "cancelled"
+	o.RejectionReason = reason
+	if o.PaymentStatus == "paid" {
+		o.PaymentStatus = "refund_pending"
+	}
+	o.UpdatedAt = time.Now()
+	s.orders[orderID] = o
+
+	for _, line := range s.orderItems[orderID] {
+		menuItem, ok := s.items[line.MenuItemID]
+		if !ok || menuItem.StockQuantity == nil {
+			continue
+		}
+		if s.soldOut[line.MenuItemID] {
+			menuItem.IsAvailable = true
+			delete(s.soldOut, line.MenuItemID)
+		}
+		restored := *menuItem.StockQuantity + line.Quantity
+		menuItem.StockQuantity = &restored
+		menuItem.UpdatedAt = time.Now()
+		s.items[line.MenuItemID] = menuItem
+	}
+
+	return nil
+}
+
+func (s *Store) GetUnansweredOrders(ctx context.Context, olderThan time.Duration, limit int) ([]model.Order, error) {
+	cutoff := time.Now().Add(-olderThan)
+	return s.findOrders(func(o model.Order) bool {
+		return o.Status == "pending" && o.CreatedAt.Before(cutoff)
+	}, func(o model.Order) time.Time { return o.CreatedAt }, limit), nil
+}
+
+func (s *Store) GetRevenue(ctx context.Context, restaurantID string, from, to *time.Time) (*model.RevenueSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	summary := &model.RevenueSummary{RestaurantID: restaurantID, From: from, To: to}
+	for _, o := range s.orders {
+		if o.RestaurantID != restaurantID || o.Status != "delivered" {
+			continue
+		}
+		if (from != nil && o.CreatedAt.Before(*from)) || (to != nil && !o.CreatedAt.Before(*to)) {
+			continue
+		}
+		summary.Orders++
+		summary.Revenue += o.TotalAmount
+	}
+	return summary, nil
+}
+
+// ====== REFUNDS ======
+
+func (s *Store) GetRefundsDue(ctx context.Context, limit int) ([]model.Order, error) {
+	return s.findOrders(func(o model.Order) bool {
+		return o.PaymentStatus == "refund_pending"
+	}, func(o model.Order) time.Time { return o.UpdatedAt }, limit), nil
+}
+
+func (s *Store) SetPaymentStatus(ctx context.Context, orderID string, from, to string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[orderID]
+	if !ok || o.PaymentStatus != from {
+		return repository.ErrStale
+	}
+	o.PaymentStatus = to
+	o.UpdatedAt = time.Now()
+	s.orders[orderID] = o
+
+	return nil
+}
+
+// ====== DELIVERY CODES ======
+
+func (s *Store) ReissueDeliveryCode(ctx context.Context, orderID, deliveryCode string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[orderID]
+	if !ok {
+		return nil
+	}
+	o.DeliveryCode = deliveryCode
+	o.DeliveryCodeAttempts = 0
+	o.UpdatedAt = time.Now()
+	s.orders[orderID] = o
+
+	return nil
+}
+
+func (s *Store) UseDeliveryCodeAttempt(ctx context.Context, orderID string, max int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[orderID]
+	if !ok || o.DeliveryCodeAttempts >= max {
+		return repository.ErrStale
+	}
+	o.DeliveryCodeAttempts++
+	s.orders[orderID] = o
+
+	return nil
+}
+
+func (s *Store) LogDeliveryCodeEvent(ctx context.Context, event *model.DeliveryCodeEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event.ID = newID()
+	event.CreatedAt = time.Now()
+	s.codeEvents[event.OrderID] = append(s.codeEvents[event.OrderID], *event)
+
+	return nil
+}
+
+func (s *Store) GetDeliveryCodeEvents(ctx context.Context, orderID string) ([]model.DeliveryCodeEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]model.DeliveryCodeEvent{}, s.codeEvents[orderID]...), nil
+}
+
+func (s *Store) listOrders(match func(model.Order) bool) []model.OrderWithDetails {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var orders []model.OrderWithDetails
+	for _, o := range s.orders {
+		if match(o) {
+			// The list queries load everything but the delivery code
+			o.DeliveryCode = ""
+			o.DeliveryCodeAttempts = 0
+			orders = append(orders, s.details(o))
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].CreatedAt.After(orders[j].CreatedAt)
+	})
+
+	return orders
+}
+
+// findOrders is the plain orders matching match, oldest by key first
+func (s *Store) findOrders(match func(model.Order) bool, key func(model.Order) time.Time, limit int) []model.Order {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var orders []model.Order
+	for _, o := range s.orders {
+		if match(o) {
+			orders = append(orders, unlisted(o))
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		return key(orders[i]).Before(key(orders[j]))
+	})
+	if len(orders) > limit {
+		orders = orders[:limit]
+	}
+
+	return orders
+}
+
+// unlisted blanks what the worker queries don't load: the delivery code
+// and acceptance fields
+func unlisted(o model.Order) model.Order {
+	o.DeliveryCode = ""
+	o.DeliveryCodeAttempts = 0
+	o.AcceptedAt = nil
+	o.PrepMinutes = nil
+	o.RejectionReason = ""
+	return o
+}
+
+func (s *Store) details(o model.Order) model.OrderWithDetails {
+	items := make([]model.OrderItem, len(s.orderItems[o.ID]))
+	for i, item := range s.orderItems[o.ID] {
+		item.Options = slices.Clone(item.Options)
+		items[i] = item
+	}
+
+	return model.OrderWithDetails{
+		Order:          o,
+		RestaurantName: s.restaurants[o.RestaurantID].Name,
+		Items:          items,
+	}
+}
@@ -0,0 +1,351 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"quickbite/internal/model"
+	"quickbite/internal/repository"
+)
+
+func (s *Store) CreateRestaurant(ctx context.Context, restaurant *model.Restaurant) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	restaurant.ID = newID()
+	restaurant.IsActive = true
+	restaurant.CreatedAt = now
+	restaurant.UpdatedAt = now
+	restaurant.Version = 1
+	if restaurant.OpensAt == "" {
+		restaurant.OpensAt = "09:00"
+	}
+	if restaurant.Timezone == "" {
+		restaurant.Timezone = "Asia/Kolkata"
+	}
+	s.restaurants[restaurant.ID] = *restaurant
+	s.members[memberKey{restaurant.ID, restaurant.OwnerID}] = model.RestaurantMember{
+		RestaurantID: restaurant.ID,
+		UserID:       restaurant.OwnerID,
+		Role:         "owner",
+		CreatedAt:    now,
+	}
+
+	return nil
+}
+
+func (s *Store) GetRestaurantByID(ctx context.Context, id string) (*model.Restaurant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.restaurants[id]
+	if !ok || r.DeletedAt != nil {
+		return nil, errNotFound
+	}
+	return &r, nil
+}
+
+func (s *Store) GetRestaurantsByMember(ctx context.Context, userID string) ([]model.Restaurant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var restaurants []model.Restaurant
+	for _, r := range s.restaurants {
+		m, ok := s.members[memberKey{r.ID, userID}]
+		if ok && r.DeletedAt == nil {
+			r.MyRole = m.Role
+			restaurants = append(restaurants, r)
+		}
+	}
+	sort.Slice(restaurants, func(i, j int) bool {
+		return restaurants[i].CreatedAt.After(restaurants[j].CreatedAt)
+	})
+
+	return restaurants, nil
+}
+
+func (s *Store) GetAllRestaurants(ctx context.Context, city string) ([]model.Restaurant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var restaurants []model.Restaurant
+	for _, r := range s.restaurants {
+		if !r.IsActive || r.DeletedAt != nil {
+			continue
+		}
+		if city != "" && r.City != city {
+			continue
+		}
+		restaurants = append(restaurants, r)
+	}
+	sort.Slice(restaurants, func(i, j int) bool {
+		if restaurants[i].Rating != restaurants[j].Rating {
+			return restaurants[i].Rating > restaurants[j].Rating
+		}
+		return restaurants[i].CreatedAt.After(restaurants[j].CreatedAt)
+	})
+
+	return restaurants, nil
+}
+
+func (s *Store) UpdateRestaurant(ctx context.Context, id string, version int, req *model.UpdateRestaurantRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.restaurants[id]
+	if !ok || r.DeletedAt != nil || !versionMatches(r.Version, version) {
+		return repository.ErrStale
+	}
+
+	r.Name = req.Name
+	r.Description = req.Description
+	r.Address = req.Address
+	r.City = req.City
+	r.ImageURL = req.ImageURL
+	r.IsActive = *req.IsActive
+	if req.OpensAt != "" {
+		r.OpensAt = req.OpensAt
+	}
+	if req.Timezone != "" {
+		r.Timezone = req.Timezone
+	}
+	r.Version++
+	r.UpdatedAt = time.Now()
+	s.restaurants[id] = r
+
+	return nil
+}
+
+func (s *Store) PatchRestaurant(ctx context.Context, id string, version int, req *model.PatchRestaurantRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.restaurants[id]
+	if !ok || r.DeletedAt != nil || !versionMatches(r.Version, version) {
+		return repository.ErrStale
+	}
+
+	patch(&r.Name, req.Name)
+	patch(&r.Description, req.Description)
+	patch(&r.Address, req.Address)
+	patch(&r.City, req.City)
+	patch(&r.ImageURL, req.ImageURL)
+	patch(&r.IsActive, req.IsActive)
+	patch(&r.OpensAt, req.OpensAt)
+	patch(&r.Timezone, req.Timezone)
+	r.Version++
+	r.UpdatedAt = time.Now()
+	s.restaurants[id] = r
+
+	return nil
+}
+
+func (s *Store) DeleteRestaurant(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.restaurants[id]
+	if !ok || r.DeletedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	for cid, c := range s.categories {
+		if c.RestaurantID != id {
+			continue
+		}
+		for iid, item := range s.items {
+			if item.CategoryID == cid && item.DeletedAt == nil {
+				item.DeletedAt = timePtr(now)
+				s.items[iid] = item
+			}
+		}
+		if c.DeletedAt == nil {
+			c.DeletedAt = timePtr(now)
+			s.categories[cid] = c
+		}
+	}
+
+	r.DeletedAt = timePtr(now)
+	r.UpdatedAt = now
+	s.restaurants[id] = r
+
+	return nil
+}
+
+func (s *Store) GetDeletedRestaurantByID(ctx context.Context, id string, retention time.Duration) (*model.Restaurant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.restaurants[id]
+	if !ok || !deletedWithin(r.DeletedAt, retention) {
+		return nil, errNotFound
+	}
+	return &r, nil
+}
+
+func (s *Store) RestoreRestaurant(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.restaurants[id]
+	if !ok || r.DeletedAt == nil {
+		return nil
+	}
+	deletedAt := *r.DeletedAt
+
+	for cid, c := range s.categories {
+		if c.RestaurantID != id {
+			continue
+		}
+		for iid, item := range s.items {
+			if item.CategoryID == cid && item.DeletedAt != nil && item.DeletedAt.Equal(deletedAt) {
+				item.DeletedAt = nil
+				s.items[iid] = item
+			}
+		}
+		if c.DeletedAt != nil && c.DeletedAt.Equal(deletedAt) {
+			c.DeletedAt = nil
+			s.categories[cid] = c
+		}
+	}
+
+	r.DeletedAt = nil
+	r.UpdatedAt = time.Now()
+	s.restaurants[id] = r
+
+	return nil
+}
+
+func (s *Store) GetTrash(ctx context.Context, restaurantIDs, menuRestaurantIDs []string, retention time.Duration) (*model.Trash, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	trash := &model.Trash{
+		Restaurants: []model.Restaurant{},
+		Categories:  []model.MenuCategory{},
+		MenuItems:   []model.MenuItem{},
+	}
+
+	for _, r := range s.restaurants {
+		if slices.Contains(restaurantIDs, r.ID) && deletedWithin(r.DeletedAt, retention) {
+			trash.Restaurants = append(trash.Restaurants, r)
+		}
+	}
+
+	for _, c := range s.categories {
+		r := s.restaurants[c.RestaurantID]
+		if slices.Contains(menuRestaurantIDs, r.ID) && r.DeletedAt == nil && deletedWithin(c.DeletedAt, retention) {
+			trash.Categories = append(trash.Categories, c)
+		}
+	}
+
+	for _, item := range s.items {
+		c := s.categories[item.CategoryID]
+		r := s.restaurants[c.RestaurantID]
+		if slices.Contains(menuRestaurantIDs, r.ID) && r.DeletedAt == nil && c.DeletedAt == nil && deletedWithin(item.DeletedAt, retention) {
+			trash.MenuItems = append(trash.MenuItems, item)
+		}
+	}
+
+	sort.Slice(trash.Restaurants, func(i, j int) bool {
+		return trash.Restaurants[i].DeletedAt.After(*trash.Restaurants[j].DeletedAt)
+	})
+	sort.Slice(trash.Categories, func(i, j int) bool {
+		return trash.Categories[i].DeletedAt.After(*trash.Categories[j].DeletedAt)
+	})
+	sort.Slice(trash.MenuItems, func(i, j int) bool {
+		return trash.MenuItems[i].DeletedAt.After(*trash.MenuItems[j].DeletedAt)
+	})
+
+	return trash, nil
+}
+
+func (s *Store) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+
+	for id, item := range s.items {
+		if deletedBefore(item.DeletedAt, retention) {
+			s.deleteItem(id)
+			purged++
+		}
+	}
+
+	for id, c := range s.categories {
+		if deletedBefore(c.DeletedAt, retention) {
+			purged += s.deleteCategory(id)
+		}
+	}
+
+	for id, r := range s.restaurants {
+		if !deletedBefore(r.DeletedAt, retention) || s.hasOrders(id) {
+			continue
+		}
+		for cid, c := range s.categories {
+			if c.RestaurantID == id {
+				s.deleteCategory(cid)
+			}
+		}
+		for key := range s.members {
+			if key.restaurantID == id {
+				delete(s.members, key)
+			}
+		}
+		for iid, inv := range s.invitations {
+			if inv.RestaurantID == id {
+				delete(s.invitations, iid)
+			}
+		}
+		for key := range s.overrides {
+			if key.restaurantID == id {
+				delete(s.overrides, key)
+			}
+		}
+		delete(s.restaurants, id)
+		purged++
+	}
+
+	return purged, nil
+}
+
+func (s *Store) hasOrders(restaurantID string) bool {
+	for _, o := range s.orders {
+		if o.RestaurantID == restaurantID {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteCategory mimics ON DELETE CASCADE: the category takes its items with it
+func (s *Store) deleteCategory(id string) int64 {
+	for iid, item := range s.items {
+		if item.CategoryID == id {
+			s.deleteItem(iid)
+		}
+	}
+	delete(s.categories, id)
+	delete(s.windows, id)
+	return 1
+}
+
+// deleteItem mimics ON DELETE SET NULL on order_items.menu_item_id
+func (s *Store) deleteItem(id string) {
+	for orderID, items := range s.orderItems {
+		for i := range items {
+			if items[i].MenuItemID == id {
+				items[i].MenuItemID = ""
+			}
+		}
+		s.orderItems[orderID] = items
+	}
+	delete(s.items, id)
+	delete(s.windows, id)
+	delete(s.stockResetOn, id)
+}
@@ -0,0 +1,150 @@
+// Package memory provides in-memory fakes of the repository stores so the
+// service layer can be exercised without a running Postgres.
+package memory
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"time"
+
+	"quickbite/internal/model"
+	"quickbite/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Lookups that miss return the same error pgx does
+var errNotFound = pgx.ErrNoRows
+
+// Store implements every repository store on plain maps guarded by one mutex,
+// which also stands in for the row locks the Postgres implementation takes
+type Store struct {
+	mu sync.Mutex
+
+	users       map[string]model.User
+	restaurants map[string]model.Restaurant
+	categories  map[string]model.MenuCategory
+	items       map[string]model.MenuItem
+	windows     map[string][]model.AvailabilityWindow // keyed by category or menu item id
+	orders      map[string]model.Order
+	orderItems  map[string][]model.OrderItem         // keyed by order id
+	codeEvents  map[string][]model.DeliveryCodeEvent // keyed by order id
+	members     map[memberKey]model.RestaurantMember
+	invitations map[string]model.Invitation
+
+	brands          map[string]model.Brand
+	brandCategories map[string]model.BrandCategory
+	brandItems      map[string]model.BrandMenuItem
+	overrides       map[overrideKey]model.OutletOverride
+
+	partners    map[string]model.DeliveryPartner
+	locations   map[string]location // restaurant id -> where it is
+	assignments map[string]model.DeliveryAssignment
+
+	prepTargets map[string]int // restaurant id -> minutes
+
+	stockResetOn  map[string]string // menu item id -> restaurant-local date of the last refill
+	soldOut       map[string]bool   // menu item ids switched off by running out of stock
+	loginFailures map[string]loginFailure
+}
+
+func New() *Store {
+	return &Store{
+		users:        make(map[string]model.User),
+		restaurants:  make(map[string]model.Restaurant),
+		categories:   make(map[string]model.MenuCategory),
+		items:        make(map[string]model.MenuItem),
+		windows:      make(map[string][]model.AvailabilityWindow),
+		orders:       make(map[string]model.Order),
+		orderItems:   make(map[string][]model.OrderItem),
+		codeEvents:   make(map[string][]model.DeliveryCodeEvent),
+		members:      make(map[memberKey]model.RestaurantMember),
+		invitations:  make(map[string]model.Invitation),
+		stockResetOn: make(map[string]string),
+		soldOut:      make(map[string]bool),
+
+		brands:          make(map[string]model.Brand),
+		brandCategories: make(map[string]model.BrandCategory),
+		brandItems:      make(map[string]model.BrandMenuItem),
+		overrides:       make(map[overrideKey]model.OutletOverride),
+
+		partners:    make(map[string]model.DeliveryPartner),
+		locations:   make(map[string]location),
+		assignments: make(map[string]model.DeliveryAssignment),
+
+		prepTargets: make(map[string]int),
+
+		loginFailures: make(map[string]loginFailure),
+	}
+}
+
+// NewStores returns a fresh in-memory Store behind every interface
+func NewStores() *repository.Stores {
+	s := New()
+	return &repository.Stores{
+		Users:         s,
+		LoginAttempts: s,
+		Restaurants:   s,
+		Menu:          s,
+		Orders:        s,
+		Members:       s,
+		Brands:        s,
+		Dispatch:      s,
+		Kitchen:       s,
+	}
+}
+
+var (
+	_ repository.UserStore         = (*Store)(nil)
+	_ repository.LoginAttemptStore = (*Store)(nil)
+	_ repository.RestaurantStore   = (*Store)(nil)
+	_ repository.MenuStore         = (*Store)(nil)
+	_ repository.OrderStore        = (*Store)(nil)
+	_ repository.MemberStore       = (*Store)(nil)
+	_ repository.BrandStore        = (*Store)(nil)
+	_ repository.DispatchStore     = (*Store)(nil)
+	_ repository.KitchenStore      = (*Store)(nil)
+)
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func intPtr(p *int) *int {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+// deletedWithin reports whether deletedAt is set and newer than now - retention
+func deletedWithin(deletedAt *time.Time, retention time.Duration) bool {
+	return deletedAt != nil && deletedAt.After(time.Now().Add(-retention))
+}
+
+func deletedBefore(deletedAt *time.Time, retention time.Duration) bool {
+	return deletedAt != nil && deletedAt.Before(time.Now().Add(-retention))
+}
+
+// patch copies a merge-patch field over dst when it was sent; a null
+// clears it
+func patch[T any](dst *T, value model.Optional[T]) {
+	if value.Present {
+		*dst = value.Get()
+	}
+}
+
+// versionMatches mirrors the SQL check: expected 0 means "any version"
+func versionMatches(current, expected int) bool {
+	return expected == 0 || current == expected
+}
@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"quickbite/internal/model"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func (s *Store) CreateUser(ctx context.Context, user *model.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if strings.EqualFold(u.Email, user.Email) {
+			return &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key", Message: "duplicate key value violates unique constraint \"users_email_key\""}
+		}
+	}
+
+	now := time.Now()
+	user.ID = newID()
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	s.users[user.ID] = *user
+
+	return nil
+}
+
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if strings.EqualFold(u.Email, email) {
+			return &u, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (s *Store) GetUserByID(ctx context.Context, id string) (*model.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	u.Password = "" // the Postgres query doesn't select it either
+	return &u, nil
+}
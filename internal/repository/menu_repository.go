@@ -2,21 +2,32 @@ package repository
 
 import (
 	"context"
-	"quickbite/db"
 	"quickbite/internal/model"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// MenuRepository is the pgx-backed MenuStore
+type MenuRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewMenuRepository(pool *pgxpool.Pool) *MenuRepository {
+	return &MenuRepository{pool: pool}
+}
+
 // ====== MENU CATEGORIES ======
 
-func CreateCategory(category *model.MenuCategory) error {
+func (repo *MenuRepository) CreateCategory(ctx context.Context, category *model.MenuCategory) error {
 	query := `
 		INSERT INTO menu_categories (restaurant_id, name, display_order)
 		VALUES ($1, $2, $3)
 		RETURNING id, created_at
 	`
 
-	return db.DB.QueryRow(
-		context.Background(),
+	return repo.pool.QueryRow(
+		ctx,
 		query,
 		category.RestaurantID,
 		category.Name,
@@ -24,15 +35,15 @@ func CreateCategory(category *model.MenuCategory) error {
 	).Scan(&category.ID, &category.CreatedAt)
 }
 
-func GetCategoriesByRestaurant(restaurantID string) ([]model.MenuCategory, error) {
+func (repo *MenuRepository) GetCategoriesByRestaurant(ctx context.Context, restaurantID string) ([]model.MenuCategory, error) {
 	query := `
-		SELECT id, restaurant_id, name, display_order, created_at
+		SELECT id, restaurant_id, name, display_order, created_at, COALESCE(brand_category_id::text, '')
 		FROM menu_categories
-		WHERE restaurant_id = $1
+		WHERE restaurant_id = $1 AND deleted_at IS NULL
 		ORDER BY display_order ASC
 	`
 
-	rows, err := db.DB.Query(context.Background(), query, restaurantID)
+	rows, err := repo.pool.Query(ctx, query, restaurantID)
 	if err != nil {
 		return nil, err
 	}
@@ -48,6 +59,7 @@ func GetCategoriesByRestaurant(restaurantID string) ([]model.MenuCategory, error
 			&c.Name,
 			&c.DisplayOrder,
 			&c.CreatedAt,
+			&c.BrandCategoryID,
 		)
 		if err != nil {
 			return nil, err
@@ -58,21 +70,22 @@ func GetCategoriesByRestaurant(restaurantID string) ([]model.MenuCategory, error
 	return categories, nil
 }
 
-func GetCategoryByID(id string) (*model.MenuCategory, error) {
+func (repo *MenuRepository) GetCategoryByID(ctx context.Context, id string) (*model.MenuCategory, error) {
 	query := `
-		SELECT id, restaurant_id, name, display_order, created_at
+		SELECT id, restaurant_id, name, display_order, created_at, COALESCE(brand_category_id::text, '')
 		FROM menu_categories
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	category := &model.MenuCategory{}
 
-	err := db.DB.QueryRow(context.Background(), query, id).Scan(
+	err := repo.pool.QueryRow(ctx, query, id).Scan(
 		&category.ID,
 		&category.RestaurantID,
 		&category.Name,
 		&category.DisplayOrder,
 		&category.CreatedAt,
+		&category.BrandCategoryID,
 	)
 	if err != nil {
 		return nil, err
@@ -81,42 +94,122 @@ func GetCategoryByID(id string) (*model.MenuCategory, error) {
 	return category, nil
 }
 
-func DeleteCategory(id string) error {
-	query := `DELETE FROM menu_categories WHERE id = $1`
-	_, err := db.DB.Exec(context.Background(), query, id)
-	return err
+// DeleteCategory archives the category together with its items.
+// Both get the same deleted_at so they can be restored as a unit.
+func (repo *MenuRepository) DeleteCategory(ctx context.Context, id string) error {
+
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE menu_categories SET deleted_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE menu_items SET deleted_at = NOW(), updated_at = NOW()
+		WHERE category_id = $1 AND deleted_at IS NULL
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetDeletedCategoryByID fetches a category only if it was deleted within the retention window
+func (repo *MenuRepository) GetDeletedCategoryByID(ctx context.Context, id string, retention time.Duration) (*model.MenuCategory, error) {
+	query := `
+		SELECT id, restaurant_id, name, display_order, created_at, deleted_at
+		FROM menu_categories
+		WHERE id = $1 AND deleted_at > NOW() - $2::interval
+	`
+
+	category := &model.MenuCategory{}
+
+	err := repo.pool.QueryRow(ctx, query, id, retention).Scan(
+		&category.ID,
+		&category.RestaurantID,
+		&category.Name,
+		&category.DisplayOrder,
+		&category.CreatedAt,
+		&category.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// RestoreCategory undoes DeleteCategory, including the items archived with it
+func (repo *MenuRepository) RestoreCategory(ctx context.Context, id string) error {
+
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE menu_items mi SET deleted_at = NULL, updated_at = NOW()
+		FROM menu_categories mc
+		WHERE mi.category_id = mc.id
+		  AND mc.id = $1
+		  AND mi.deleted_at = mc.deleted_at
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE menu_categories SET deleted_at = NULL WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
 // ====== MENU ITEMS ======
 
-func CreateMenuItem(item *model.MenuItem) error {
+func (repo *MenuRepository) CreateMenuItem(ctx context.Context, item *model.MenuItem) error {
 	query := `
-		INSERT INTO menu_items (category_id, name, description, price, image_url, is_veg)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, created_at, updated_at
+		INSERT INTO menu_items (category_id, name, description, price, image_url, is_available, is_veg, stock_quantity, daily_stock)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at, version
 	`
 
-	return db.DB.QueryRow(
-		context.Background(),
+	return repo.pool.QueryRow(
+		ctx,
 		query,
 		item.CategoryID,
 		item.Name,
 		item.Description,
 		item.Price,
 		item.ImageURL,
+		item.IsAvailable,
 		item.IsVeg,
-	).Scan(&item.ID, &item.CreatedAt, &item.UpdatedAt)
+		item.StockQuantity,
+		item.DailyStock,
+	).Scan(&item.ID, &item.CreatedAt, &item.UpdatedAt, &item.Version)
 }
 
-func GetMenuItemsByCategory(categoryID string) ([]model.MenuItem, error) {
+func (repo *MenuRepository) GetMenuItemsByCategory(ctx context.Context, categoryID string) ([]model.MenuItem, error) {
 	query := `
-		SELECT id, category_id, name, description, price, image_url, is_available, is_veg, created_at, updated_at
+		SELECT id, category_id, name, description, price, image_url, is_available, is_veg, stock_quantity, daily_stock, created_at, updated_at, version,
+		       COALESCE(brand_item_id::text, '')
 		FROM menu_items
-		WHERE category_id = $1
+		WHERE category_id = $1 AND deleted_at IS NULL
 		ORDER BY created_at ASC
 	`
 
-	rows, err := db.DB.Query(context.Background(), query, categoryID)
+	rows, err := repo.pool.Query(ctx, query, categoryID)
 	if err != nil {
 		return nil, err
 	}
@@ -135,8 +228,12 @@ func GetMenuItemsByCategory(categoryID string) ([]model.MenuItem, error) {
 			&item.ImageURL,
 			&item.IsAvailable,
 			&item.IsVeg,
+			&item.StockQuantity,
+			&item.DailyStock,
 			&item.CreatedAt,
 			&item.UpdatedAt,
+			&item.Version,
+			&item.BrandItemID,
 		)
 		if err != nil {
 			return nil, err
@@ -147,16 +244,17 @@ func GetMenuItemsByCategory(categoryID string) ([]model.MenuItem, error) {
 	return items, nil
 }
 
-func GetMenuItemByID(id string) (*model.MenuItem, error) {
+func (repo *MenuRepository) GetMenuItemByID(ctx context.Context, id string) (*model.MenuItem, error) {
 	query := `
-		SELECT id, category_id, name, description, price, image_url, is_available, is_veg, created_at, updated_at
+		SELECT id, category_id, name, description, price, image_url, is_available, is_veg, stock_quantity, daily_stock, created_at, updated_at, version,
+		       COALESCE(brand_item_id::text, '')
 		FROM menu_items
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	item := &model.MenuItem{}
 
-	err := db.DB.QueryRow(context.Background(), query, id).Scan(
+	err := repo.pool.QueryRow(ctx, query, id).Scan(
 		&item.ID,
 		&item.CategoryID,
 		&item.Name,
@@ -165,8 +263,12 @@ func GetMenuItemByID(id string) (*model.MenuItem, error) {
 		&item.ImageURL,
 		&item.IsAvailable,
 		&item.IsVeg,
+		&item.StockQuantity,
+		&item.DailyStock,
 		&item.CreatedAt,
 		&item.UpdatedAt,
+		&item.Version,
+		&item.BrandItemID,
 	)
 	if err != nil {
 		return nil, err
@@ -175,15 +277,18 @@ func GetMenuItemByID(id string) (*model.MenuItem, error) {
 	return item, nil
 }
 
-func UpdateMenuItem(id string, req *model.UpdateMenuItemRequest) error {
+// UpdateMenuItem overwrites the item if it is still at version (0 skips
+// the check) and returns ErrStale otherwise
+func (repo *MenuRepository) UpdateMenuItem(ctx context.Context, id string, version int, req *model.UpdateMenuItemRequest) error {
 	query := `
 		UPDATE menu_items
-		SET name = $1, description = $2, price = $3, image_url = $4, is_available = $5, is_veg = $6, updated_at = NOW()
-		WHERE id = $7
+		SET name = $1, description = $2, price = $3, image_url = $4, is_available = $5, is_veg = $6,
+		    sold_out = FALSE, version = version + 1, updated_at = NOW()
+		WHERE id = $7 AND ($8 = 0 OR version = $8) AND deleted_at IS NULL
 	`
 
-	_, err := db.DB.Exec(
-		context.Background(),
+	tag, err := repo.pool.Exec(
+		ctx,
 		query,
 		req.Name,
 		req.Description,
@@ -192,13 +297,219 @@ func UpdateMenuItem(id string, req *model.UpdateMenuItemRequest) error {
 		req.IsAvailable,
 		req.IsVeg,
 		id,
+		version,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStale
+	}
+	return nil
+}
+
+// GetDeletedMenuItemByID fetches an item only if it was archived within the retention window
+func (repo *MenuRepository) GetDeletedMenuItemByID(ctx context.Context, id string, retention time.Duration) (*model.MenuItem, error) {
+	query := `
+		SELECT id, category_id, name, description, price, image_url, is_available, is_veg, stock_quantity, daily_stock, created_at, updated_at, version, deleted_at
+		FROM menu_items
+		WHERE id = $1 AND deleted_at > NOW() - $2::interval
+	`
+
+	item := &model.MenuItem{}
+
+	err := repo.pool.QueryRow(ctx, query, id, retention).Scan(
+		&item.ID,
+		&item.CategoryID,
+		&item.Name,
+		&item.Description,
+		&item.Price,
+		&item.ImageURL,
+		&item.IsAvailable,
+		&item.IsVeg,
+		&item.StockQuantity,
+		&item.DailyStock,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+		&item.Version,
+		&item.DeletedAt,
 	)
+	if err != nil {
+		return nil, err
+	}
 
+	return item, nil
+}
+
+func (repo *MenuRepository) RestoreMenuItem(ctx context.Context, id string) error {
+	query := `UPDATE menu_items SET deleted_at = NULL, updated_at = NOW() WHERE id = $1`
+	_, err := repo.pool.Exec(ctx, query, id)
 	return err
 }
 
-func DeleteMenuItem(id string) error {
-	query := `DELETE FROM menu_items WHERE id = $1`
-	_, err := db.DB.Exec(context.Background(), query, id)
+// UpdateMenuItemStock sets the current and daily stock and keeps
+// is_available in line with the new count. A count of zero marks the item
+// sold out.
+func (repo *MenuRepository) UpdateMenuItemStock(ctx context.Context, id string, req *model.UpdateStockRequest) error {
+	query := `
+		UPDATE menu_items
+		SET stock_quantity = $1,
+		    daily_stock = $2,
+		    is_available = CASE
+		        WHEN $1::int IS NULL THEN is_available
+		        ELSE $1::int > 0
+		    END,
+		    sold_out = CASE
+		        WHEN $1::int IS NULL THEN sold_out
+		        ELSE $1::int = 0
+		    END,
+		    updated_at = NOW()
+		WHERE id = $3
+	`
+
+	_, err := repo.pool.Exec(ctx, query, req.StockQuantity, req.DailyStock, id)
 	return err
 }
+
+// ResetDailyStock refills daily_stock for every item whose restaurant has
+// opened today (in its own timezone) and hasn't been refilled yet.
+// Items that were switched off because they sold out are switched back on,
+// unless the new stock is zero too. Items switched off by hand stay off.
+func (repo *MenuRepository) ResetDailyStock(ctx context.Context) (int64, error) {
+	query := `
+		UPDATE menu_items mi
+		SET stock_quantity = mi.daily_stock,
+		    is_available = CASE WHEN mi.sold_out AND mi.daily_stock > 0 THEN TRUE ELSE mi.is_available END,
+		    sold_out = mi.sold_out AND mi.daily_stock = 0,
+		    stock_reset_on = (NOW() AT TIME ZONE r.timezone)::date,
+		    updated_at = NOW()
+		FROM menu_categories mc
+		JOIN restaurants r ON r.id = mc.restaurant_id
+		WHERE mi.category_id = mc.id
+		  AND mi.deleted_at IS NULL
+		  AND mi.daily_stock IS NOT NULL
+		  AND (NOW() AT TIME ZONE r.timezone)::time >= r.opens_at
+		  AND (mi.stock_reset_on IS NULL OR mi.stock_reset_on < (NOW() AT TIME ZONE r.timezone)::date)
+	`
+
+	tag, err := repo.pool.Exec(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// DeleteMenuItem archives the item; order history keeps its own snapshot
+func (repo *MenuRepository) DeleteMenuItem(ctx context.Context, id string) error {
+	query := `
+		UPDATE menu_items SET deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	_, err := repo.pool.Exec(ctx, query, id)
+	return err
+}
+
+// ====== AVAILABILITY WINDOWS ======
+
+func (repo *MenuRepository) GetWindowsByCategories(ctx context.Context, categoryIDs []string) (map[string][]model.AvailabilityWindow, error) {
+	return repo.getWindows(ctx, "category_id", categoryIDs)
+}
+
+func (repo *MenuRepository) GetWindowsByMenuItems(ctx context.Context, itemIDs []string) (map[string][]model.AvailabilityWindow, error) {
+	return repo.getWindows(ctx, "menu_item_id", itemIDs)
+}
+
+func (repo *MenuRepository) ReplaceCategoryWindows(ctx context.Context, categoryID string, windows []model.AvailabilityWindow) error {
+	return repo.replaceWindows(ctx, "category_id", categoryID, windows)
+}
+
+func (repo *MenuRepository) ReplaceMenuItemWindows(ctx context.Context, itemID string, windows []model.AvailabilityWindow) error {
+	return repo.replaceWindows(ctx, "menu_item_id", itemID, windows)
+}
+
+// getWindows loads windows keyed by owner id; column is category_id or menu_item_id
+func (repo *MenuRepository) getWindows(ctx context.Context, column string, ids []string) (map[string][]model.AvailabilityWindow, error) {
+	windows := make(map[string][]model.AvailabilityWindow)
+	if len(ids) == 0 {
+		return windows, nil
+	}
+
+	query := `
+		SELECT ` + column + `, days_of_week, to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI')
+		FROM availability_windows
+		WHERE ` + column + ` = ANY($1::uuid[])
+		ORDER BY start_time ASC
+	`
+
+	rows, err := repo.pool.Query(ctx, query, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ownerID string
+		var w model.AvailabilityWindow
+		if err := rows.Scan(&ownerID, &w.Days, &w.StartTime, &w.EndTime); err != nil {
+			return nil, err
+		}
+		windows[ownerID] = append(windows[ownerID], w)
+	}
+
+	return windows, rows.Err()
+}
+
+func (repo *MenuRepository) replaceWindows(ctx context.Context, column string, ownerID string, windows []model.AvailabilityWindow) error {
+
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM availability_windows WHERE `+column+` = $1`, ownerID); err != nil {
+		return err
+	}
+
+	insert := `
+		INSERT INTO availability_windows (` + column + `, days_of_week, start_time, end_time)
+		VALUES ($1, $2, $3::time, $4::time)
+	`
+	for _, w := range windows {
+		if _, err := tx.Exec(ctx, insert, ownerID, w.Days, w.StartTime, w.EndTime); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// PatchMenuItem updates only the fields present in req, under the same
+// version check as UpdateMenuItem
+func (repo *MenuRepository) PatchMenuItem(ctx context.Context, id string, version int, req *model.PatchMenuItemRequest) error {
+	var update updateBuilder
+	setIfPresent(&update, "name", req.Name)
+	setIfPresent(&update, "description", req.Description)
+	setIfPresent(&update, "price", req.Price)
+	setIfPresent(&update, "image_url", req.ImageURL)
+	setIfPresent(&update, "is_available", req.IsAvailable)
+	if req.IsAvailable.Present {
+		// Switching an item on or off by hand overrides selling out
+		update.set("sold_out", "", false)
+	}
+	setIfPresent(&update, "is_veg", req.IsVeg)
+
+	if update.empty() {
+		return nil
+	}
+
+	query, args := update.build("menu_items", id, version, "AND deleted_at IS NULL")
+	tag, err := repo.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStale
+	}
+	return nil
+}
@@ -2,21 +2,61 @@ package repository
 
 import (
 	"context"
-	"quickbite/db"
+	"fmt"
 	"quickbite/internal/model"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// CreateOrder inserts a new order and returns it with generated ID
-func CreateOrder(order *model.Order) error {
-	query := `
+// OrderRepository is the pgx-backed OrderStore
+type OrderRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewOrderRepository(pool *pgxpool.Pool) *OrderRepository {
+	return &OrderRepository{pool: pool}
+}
+
+// OutOfStockError is returned when a tracked item can't cover the ordered quantity
+type OutOfStockError struct {
+	ItemName  string
+	Available int
+}
+
+func (e *OutOfStockError) Error() string {
+	if e.Available == 0 {
+		return "item is out of stock: " + e.ItemName
+	}
+	return fmt.Sprintf("only %d left for item: %s", e.Available, e.ItemName)
+}
+
+// CreateOrder inserts the order and its items in one transaction.
+// Tracked menu items are locked with FOR UPDATE and their stock is decremented,
+// so concurrent orders can't oversell. Items that hit zero are switched off.
+func (repo *OrderRepository) CreateOrder(ctx context.Context, order *model.Order, items []model.OrderItem) error {
+
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := reserveStock(ctx, tx, items); err != nil {
+		return err
+	}
+
+	orderQuery := `
 		INSERT INTO orders (user_id, restaurant_id, status, total_amount, delivery_fee, delivery_address, payment_method, payment_status)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING id, created_at, updated_at
 	`
 
-	return db.DB.QueryRow(
-		context.Background(),
-		query,
+	err = tx.QueryRow(
+		ctx,
+		orderQuery,
 		order.UserID,
 		order.RestaurantID,
 		order.Status,
@@ -26,38 +66,111 @@ func CreateOrder(order *model.Order) error {
 		order.PaymentMethod,
 		order.PaymentStatus,
 	).Scan(&order.ID, &order.CreatedAt, &order.UpdatedAt)
-}
+	if err != nil {
+		return err
+	}
 
-// CreateOrderItem inserts an order item
-func CreateOrderItem(item *model.OrderItem) error {
-	query := `
-		INSERT INTO order_items (order_id, menu_item_id, quantity, price)
-		VALUES ($1, $2, $3, $4)
+	itemQuery := `
+		INSERT INTO order_items (order_id, menu_item_id, quantity, price, item_name, item_image, is_veg, options)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING id, created_at
 	`
 
-	return db.DB.QueryRow(
-		context.Background(),
-		query,
-		item.OrderID,
-		item.MenuItemID,
-		item.Quantity,
-		item.Price,
-	).Scan(&item.ID, &item.CreatedAt)
+	for i := range items {
+		items[i].OrderID = order.ID
+		if items[i].Options == nil {
+			items[i].Options = []string{}
+		}
+		err := tx.QueryRow(
+			ctx,
+			itemQuery,
+			items[i].OrderID,
+			items[i].MenuItemID,
+			items[i].Quantity,
+			items[i].Price,
+			items[i].ItemName,
+			items[i].ItemImage,
+			items[i].IsVeg,
+			items[i].Options,
+		).Scan(&items[i].ID, &items[i].CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// reserveStock locks every ordered menu item (in id order, to avoid deadlocks
+// between concurrent orders) and decrements stock for tracked items
+func reserveStock(ctx context.Context, tx pgx.Tx, items []model.OrderItem) error {
+	quantities := make(map[string]int)
+	for _, item := range items {
+		quantities[item.MenuItemID] += item.Quantity
+	}
+
+	ids := make([]string, 0, len(quantities))
+	for id := range quantities {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		var name string
+		var isAvailable, isArchived bool
+		var stock *int
+
+		err := tx.QueryRow(
+			ctx,
+			`SELECT name, is_available, deleted_at IS NOT NULL, stock_quantity FROM menu_items WHERE id = $1 FOR UPDATE`,
+			id,
+		).Scan(&name, &isAvailable, &isArchived, &stock)
+		if err != nil {
+			return err
+		}
+
+		if !isAvailable || isArchived {
+			return &OutOfStockError{ItemName: name}
+		}
+		if stock == nil {
+			continue
+		}
+
+		want := quantities[id]
+		if *stock < want {
+			return &OutOfStockError{ItemName: name, Available: *stock}
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE menu_items
+			SET stock_quantity = stock_quantity - $1,
+			    is_available = stock_quantity - $1 > 0,
+			    sold_out = stock_quantity - $1 = 0,
+			    updated_at = NOW()
+			WHERE id = $2
+		`, want, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // GetOrderByID fetches a single order by ID
-func GetOrderByID(id string) (*model.Order, error) {
+func (repo *OrderRepository) GetOrderByID(ctx context.Context, id string) (*model.Order, error) {
 	query := `
 		SELECT id, user_id, restaurant_id, status, total_amount, delivery_fee, 
-		       delivery_address, payment_method, payment_status, created_at, updated_at
+		       delivery_address, payment_method, payment_status, created_at, updated_at,
+		       COALESCE(delivery_code, ''), delivery_code_attempts,
+		       accepted_at, prep_minutes, COALESCE(rejection_reason, '')
 		FROM orders
 		WHERE id = $1::uuid
 	`
 
 	order := &model.Order{}
 
-	err := db.DB.QueryRow(context.Background(), query, id).Scan(
+	err := repo.pool.QueryRow(ctx, query, id).Scan(
 		&order.ID,
 		&order.UserID,
 		&order.RestaurantID,
@@ -69,6 +182,11 @@ func GetOrderByID(id string) (*model.Order, error) {
 		&order.PaymentStatus,
 		&order.CreatedAt,
 		&order.UpdatedAt,
+		&order.DeliveryCode,
+		&order.DeliveryCodeAttempts,
+		&order.AcceptedAt,
+		&order.PrepMinutes,
+		&order.RejectionReason,
 	)
 	if err != nil {
 		return nil, err
@@ -77,10 +195,10 @@ func GetOrderByID(id string) (*model.Order, error) {
 	return order, nil
 }
 
-// GetOrderWithDetails fetches order with restaurant name and items with menu details
-func GetOrderWithDetails(id string) (*model.OrderWithDetails, error) {
+// GetOrderWithDetails fetches order with restaurant name and its item snapshots
+func (repo *OrderRepository) GetOrderWithDetails(ctx context.Context, id string) (*model.OrderWithDetails, error) {
 	// First get the order
-	order, err := GetOrderByID(id)
+	order, err := repo.GetOrderByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -88,47 +206,16 @@ func GetOrderWithDetails(id string) (*model.OrderWithDetails, error) {
 	// Get restaurant name
 	restaurantQuery := `SELECT name FROM restaurants WHERE id = $1::uuid`
 	var restaurantName string
-	err = db.DB.QueryRow(context.Background(), restaurantQuery, order.RestaurantID).Scan(&restaurantName)
+	err = repo.pool.QueryRow(ctx, restaurantQuery, order.RestaurantID).Scan(&restaurantName)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get order items with menu details
-	itemsQuery := `
-		SELECT 
-			oi.id, oi.order_id, oi.menu_item_id, oi.quantity, oi.price, oi.created_at,
-			mi.name, mi.image_url, mi.is_veg
-		FROM order_items oi
-		JOIN menu_items mi ON oi.menu_item_id = mi.id
-		WHERE oi.order_id = $1::uuid
-	`
-
-	rows, err := db.DB.Query(context.Background(), itemsQuery, id)
+	// Get order items
+	items, err := repo.getOrderItems(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var items []model.OrderItemWithMenu
-
-	for rows.Next() {
-		var item model.OrderItemWithMenu
-		err := rows.Scan(
-			&item.ID,
-			&item.OrderID,
-			&item.MenuItemID,
-			&item.Quantity,
-			&item.Price,
-			&item.CreatedAt,
-			&item.ItemName,
-			&item.ItemImage,
-			&item.IsVeg,
-		)
-		if err != nil {
-			return nil, err
-		}
-		items = append(items, item)
-	}
 
 	return &model.OrderWithDetails{
 		Order:          *order,
@@ -138,18 +225,19 @@ func GetOrderWithDetails(id string) (*model.OrderWithDetails, error) {
 }
 
 // GetOrdersByUser fetches all orders for a specific user
-func GetOrdersByUser(userID string) ([]model.OrderWithDetails, error) {
+func (repo *OrderRepository) GetOrdersByUser(ctx context.Context, userID string) ([]model.OrderWithDetails, error) {
 	query := `
 		SELECT o.id, o.user_id, o.restaurant_id, o.status, o.total_amount, 
 		       o.delivery_fee, o.delivery_address, o.payment_method, o.payment_status,
-		       o.created_at, o.updated_at, r.name as restaurant_name
+		       o.created_at, o.updated_at, r.name as restaurant_name,
+		       o.accepted_at, o.prep_minutes, COALESCE(o.rejection_reason, '')
 		FROM orders o
 		JOIN restaurants r ON o.restaurant_id = r.id
 		WHERE o.user_id = $1::uuid
 		ORDER BY o.created_at DESC
 	`
 
-	rows, err := db.DB.Query(context.Background(), query, userID)
+	rows, err := repo.pool.Query(ctx, query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -172,13 +260,16 @@ func GetOrdersByUser(userID string) ([]model.OrderWithDetails, error) {
 			&orderDetail.CreatedAt,
 			&orderDetail.UpdatedAt,
 			&orderDetail.RestaurantName,
+			&orderDetail.AcceptedAt,
+			&orderDetail.PrepMinutes,
+			&orderDetail.RejectionReason,
 		)
 		if err != nil {
 			return nil, err
 		}
 
 		// Get items for this order
-		items, err := getOrderItems(orderDetail.ID)
+		items, err := repo.getOrderItems(ctx, orderDetail.ID)
 		if err != nil {
 			return nil, err
 		}
@@ -191,18 +282,19 @@ func GetOrdersByUser(userID string) ([]model.OrderWithDetails, error) {
 }
 
 // GetOrdersByRestaurant fetches all orders for a specific restaurant
-func GetOrdersByRestaurant(restaurantID string) ([]model.OrderWithDetails, error) {
+func (repo *OrderRepository) GetOrdersByRestaurant(ctx context.Context, restaurantID string) ([]model.OrderWithDetails, error) {
 	query := `
 		SELECT o.id, o.user_id, o.restaurant_id, o.status, o.total_amount, 
 		       o.delivery_fee, o.delivery_address, o.payment_method, o.payment_status,
-		       o.created_at, o.updated_at, r.name as restaurant_name
+		       o.created_at, o.updated_at, r.name as restaurant_name,
+		       o.accepted_at, o.prep_minutes, COALESCE(o.rejection_reason, '')
 		FROM orders o
 		JOIN restaurants r ON o.restaurant_id = r.id
 		WHERE o.restaurant_id = $1::uuid
 		ORDER BY o.created_at DESC
 	`
 
-	rows, err := db.DB.Query(context.Background(), query, restaurantID)
+	rows, err := repo.pool.Query(ctx, query, restaurantID)
 	if err != nil {
 		return nil, err
 	}
@@ -225,13 +317,16 @@ func GetOrdersByRestaurant(restaurantID string) ([]model.OrderWithDetails, error
 			&orderDetail.CreatedAt,
 			&orderDetail.UpdatedAt,
 			&orderDetail.RestaurantName,
+			&orderDetail.AcceptedAt,
+			&orderDetail.PrepMinutes,
+			&orderDetail.RejectionReason,
 		)
 		if err != nil {
 			return nil, err
 		}
 
 		// Get items for this order
-		items, err := getOrderItems(orderDetail.ID)
+		items, err := repo.getOrderItems(ctx, orderDetail.ID)
 		if err != nil {
 			return nil, err
 		}
@@ -243,49 +338,291 @@ func GetOrdersByRestaurant(restaurantID string) ([]model.OrderWithDetails, error
 	return orders, nil
 }
 
-// UpdateOrderStatus updates only the status of an order
-func UpdateOrderStatus(orderID string, status string) error {
+// UpdateOrderStatus moves the order from one status to another. It is a
+// compare-and-swap: if the status is no longer from, nothing changes and
+// ErrStale is returned.
+func (repo *OrderRepository) UpdateOrderStatus(ctx context.Context, orderID string, from, to string) error {
 	query := `
 		UPDATE orders
 		SET status = $1, updated_at = NOW()
-		WHERE id = $2::uuid
+		WHERE id = $2::uuid AND status = $3
+	`
+
+	tag, err := repo.pool.Exec(ctx, query, to, orderID, from)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStale
+	}
+	return nil
+}
+
+// ConfirmOrder is UpdateOrderStatus for an order leaving pending; it also
+// sets the delivery code and records when it was accepted
+func (repo *OrderRepository) ConfirmOrder(ctx context.Context, orderID string, from, to, deliveryCode string, prepMinutes int) error {
+	query := `
+		UPDATE orders
+		SET status = $1, delivery_code = $4, delivery_code_attempts = 0,
+		    accepted_at = NOW(), prep_minutes = NULLIF($5, 0), updated_at = NOW()
+		WHERE id = $2::uuid AND status = $3
 	`
 
-	_, err := db.DB.Exec(context.Background(), query, status, orderID)
+	tag, err := repo.pool.Exec(ctx, query, to, orderID, from, deliveryCode, prepMinutes)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStale
+	}
+	return nil
+}
+
+// CancelOrder marks the order cancelled and puts its items back into stock.
+// Items that had sold out are switched back on, but not ones switched off
+// by hand. Like UpdateOrderStatus it
+// only applies while the status is still from, so two cancels racing can't
+// restock twice. A paid order is left for the refund worker.
+func (repo *OrderRepository) CancelOrder(ctx context.Context, orderID string, from string) error {
+	return repo.cancel(ctx, orderID, from, "")
+}
+
+// RejectOrder cancels a pending order, recording why the restaurant
+// turned it down
+func (repo *OrderRepository) RejectOrder(ctx context.Context, orderID string, reason string) error {
+	return repo.cancel(ctx, orderID, "pending", reason)
+}
+
+func (repo *OrderRepository) cancel(ctx context.Context, orderID string, from string, reason string) error {
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE orders
+		SET status = 'cancelled',
+		    rejection_reason = NULLIF($3, ''),
+		    payment_status = CASE WHEN payment_status = 'paid' THEN 'refund_pending' ELSE payment_status END,
+		    updated_at = NOW()
+		WHERE id = $1::uuid AND status = $2
+	`, orderID, from, reason)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStale
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE menu_items mi
+		SET stock_quantity = mi.stock_quantity + oi.quantity,
+		    is_available = mi.is_available OR mi.sold_out,
+		    sold_out = FALSE,
+		    updated_at = NOW()
+		FROM (
+			SELECT menu_item_id, SUM(quantity) AS quantity
+			FROM order_items
+			WHERE order_id = $1::uuid
+			GROUP BY menu_item_id
+		) oi
+		WHERE mi.id = oi.menu_item_id
+		  AND mi.stock_quantity IS NOT NULL
+	`, orderID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetUnansweredOrders lists orders still pending more than olderThan after
+// they were placed, oldest first
+func (repo *OrderRepository) GetUnansweredOrders(ctx context.Context, olderThan time.Duration, limit int) ([]model.Order, error) {
+	return repo.listOrders(ctx, `
+		SELECT id, user_id, restaurant_id, status, total_amount, delivery_fee,
+		       delivery_address, payment_method, payment_status, created_at, updated_at
+		FROM orders
+		WHERE status = 'pending' AND created_at < NOW() - $1::interval
+		ORDER BY created_at
+		LIMIT $2
+	`, olderThan, limit)
+}
+
+// ====== REFUNDS ======
+
+// GetRefundsDue lists cancelled orders whose payment is waiting to be
+// refunded, oldest first
+func (repo *OrderRepository) GetRefundsDue(ctx context.Context, limit int) ([]model.Order, error) {
+	return repo.listOrders(ctx, `
+		SELECT id, user_id, restaurant_id, status, total_amount, delivery_fee,
+		       delivery_address, payment_method, payment_status, created_at, updated_at
+		FROM orders
+		WHERE payment_status = 'refund_pending'
+		ORDER BY updated_at
+		LIMIT $1
+	`, limit)
+}
+
+func (repo *OrderRepository) SetPaymentStatus(ctx context.Context, orderID string, from, to string) error {
+	tag, err := repo.pool.Exec(ctx, `
+		UPDATE orders
+		SET payment_status = $3, updated_at = NOW()
+		WHERE id = $1::uuid AND payment_status = $2
+	`, orderID, from, to)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStale
+	}
+	return nil
+}
+
+// listOrders runs a query selecting the plain order columns, in the order
+// GetOrderByID scans them without the delivery code and acceptance fields
+func (repo *OrderRepository) listOrders(ctx context.Context, query string, args ...any) ([]model.Order, error) {
+	rows, err := repo.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []model.Order
+	for rows.Next() {
+		var order model.Order
+		err := rows.Scan(
+			&order.ID,
+			&order.UserID,
+			&order.RestaurantID,
+			&order.Status,
+			&order.TotalAmount,
+			&order.DeliveryFee,
+			&order.DeliveryAddress,
+			&order.PaymentMethod,
+			&order.PaymentStatus,
+			&order.CreatedAt,
+			&order.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, rows.Err()
+}
+
+// GetRevenue totals the restaurant's delivered orders placed in [from, to);
+// nil bounds are open
+func (repo *OrderRepository) GetRevenue(ctx context.Context, restaurantID string, from, to *time.Time) (*model.RevenueSummary, error) {
+	summary := &model.RevenueSummary{RestaurantID: restaurantID, From: from, To: to}
+
+	err := repo.pool.QueryRow(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(total_amount), 0)::float8
+		FROM orders
+		WHERE restaurant_id = $1
+		  AND status = 'delivered'
+		  AND ($2::timestamp IS NULL OR created_at >= $2)
+		  AND ($3::timestamp IS NULL OR created_at < $3)
+	`, restaurantID, from, to).Scan(&summary.Orders, &summary.Revenue)
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// ====== DELIVERY CODES ======
+
+func (repo *OrderRepository) ReissueDeliveryCode(ctx context.Context, orderID, deliveryCode string) error {
+	_, err := repo.pool.Exec(ctx, `
+		UPDATE orders
+		SET delivery_code = $2, delivery_code_attempts = 0, updated_at = NOW()
+		WHERE id = $1::uuid
+	`, orderID, deliveryCode)
 	return err
 }
 
-// Helper function to get order items with menu details
-func getOrderItems(orderID string) ([]model.OrderItemWithMenu, error) {
+// UseDeliveryCodeAttempt takes one attempt in a single conditional update,
+// so concurrent guesses can't get past the limit between check and count
+func (repo *OrderRepository) UseDeliveryCodeAttempt(ctx context.Context, orderID string, max int) error {
+	tag, err := repo.pool.Exec(ctx, `
+		UPDATE orders
+		SET delivery_code_attempts = delivery_code_attempts + 1
+		WHERE id = $1::uuid AND delivery_code_attempts < $2
+	`, orderID, max)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStale
+	}
+	return nil
+}
+
+func (repo *OrderRepository) LogDeliveryCodeEvent(ctx context.Context, event *model.DeliveryCodeEvent) error {
+	return repo.pool.QueryRow(ctx, `
+		INSERT INTO delivery_code_events (order_id, user_id, action)
+		VALUES ($1, NULLIF($2, '')::uuid, $3)
+		RETURNING id, created_at
+	`, event.OrderID, event.UserID, event.Action).Scan(&event.ID, &event.CreatedAt)
+}
+
+func (repo *OrderRepository) GetDeliveryCodeEvents(ctx context.Context, orderID string) ([]model.DeliveryCodeEvent, error) {
+	rows, err := repo.pool.Query(ctx, `
+		SELECT id, order_id, COALESCE(user_id::text, ''), action, created_at
+		FROM delivery_code_events
+		WHERE order_id = $1
+		ORDER BY created_at
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []model.DeliveryCodeEvent{}
+	for rows.Next() {
+		var e model.DeliveryCodeEvent
+		if err := rows.Scan(&e.ID, &e.OrderID, &e.UserID, &e.Action, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// Helper function to get the item snapshots of an order
+func (repo *OrderRepository) getOrderItems(ctx context.Context, orderID string) ([]model.OrderItem, error) {
 	query := `
-		SELECT 
-			oi.id, oi.order_id, oi.menu_item_id, oi.quantity, oi.price, oi.created_at,
-			mi.name, mi.image_url, mi.is_veg
-		FROM order_items oi
-		JOIN menu_items mi ON oi.menu_item_id = mi.id
-		WHERE oi.order_id = $1::uuid
+		SELECT id, order_id, COALESCE(menu_item_id::text, ''), quantity, price,
+		       item_name, item_image, is_veg, options, created_at, prepared_at
+		FROM order_items
+		WHERE order_id = $1::uuid
+		ORDER BY created_at ASC
 	`
 
-	rows, err := db.DB.Query(context.Background(), query, orderID)
+	rows, err := repo.pool.Query(ctx, query, orderID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var items []model.OrderItemWithMenu
+	var items []model.OrderItem
 
 	for rows.Next() {
-		var item model.OrderItemWithMenu
+		var item model.OrderItem
 		err := rows.Scan(
 			&item.ID,
 			&item.OrderID,
 			&item.MenuItemID,
 			&item.Quantity,
 			&item.Price,
-			&item.CreatedAt,
 			&item.ItemName,
 			&item.ItemImage,
 			&item.IsVeg,
+			&item.Options,
+			&item.CreatedAt,
+			&item.PreparedAt,
 		)
 		if err != nil {
 			return nil, err
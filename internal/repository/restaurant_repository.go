@@ -2,19 +2,37 @@ package repository
 
 import (
 	"context"
-	"quickbite/db"
 	"quickbite/internal/model"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func CreateRestaurant(restaurant *model.Restaurant) error {
+// RestaurantRepository is the pgx-backed RestaurantStore
+type RestaurantRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRestaurantRepository(pool *pgxpool.Pool) *RestaurantRepository {
+	return &RestaurantRepository{pool: pool}
+}
+
+// CreateRestaurant also makes the creator its owner member, in the same transaction
+func (repo *RestaurantRepository) CreateRestaurant(ctx context.Context, restaurant *model.Restaurant) error {
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
-		INSERT INTO restaurants (owner_id, name, description, address, city, image_url)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, created_at, updated_at
+		INSERT INTO restaurants (owner_id, name, description, address, city, image_url, opens_at, timezone)
+		VALUES ($1, $2, $3, $4, $5, $6, $7::time, $8)
+		RETURNING id, created_at, updated_at, version
 	`
 
-	return db.DB.QueryRow(
-		context.Background(),
+	err = tx.QueryRow(
+		ctx,
 		query,
 		restaurant.OwnerID,
 		restaurant.Name,
@@ -22,19 +40,35 @@ func CreateRestaurant(restaurant *model.Restaurant) error {
 		restaurant.Address,
 		restaurant.City,
 		restaurant.ImageURL,
-	).Scan(&restaurant.ID, &restaurant.CreatedAt, &restaurant.UpdatedAt)
+		restaurant.OpensAt,
+		restaurant.Timezone,
+	).Scan(&restaurant.ID, &restaurant.CreatedAt, &restaurant.UpdatedAt, &restaurant.Version)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO restaurant_members (restaurant_id, user_id, role, created_at)
+		VALUES ($1, $2, 'owner', $3)
+	`, restaurant.ID, restaurant.OwnerID, restaurant.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
-func GetRestaurantByID(id string) (*model.Restaurant, error) {
+func (repo *RestaurantRepository) GetRestaurantByID(ctx context.Context, id string) (*model.Restaurant, error) {
 	query := `
-		SELECT id, owner_id, name, description, address, city, image_url, is_active, rating, created_at, updated_at
+		SELECT id, owner_id, name, description, address, city, image_url, is_active, rating,
+		       to_char(opens_at, 'HH24:MI'), timezone, created_at, updated_at, version, COALESCE(brand_id::text, '')
 		FROM restaurants
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	restaurant := &model.Restaurant{}
 
-	err := db.DB.QueryRow(context.Background(), query, id).Scan(
+	err := repo.pool.QueryRow(ctx, query, id).Scan(
 		&restaurant.ID,
 		&restaurant.OwnerID,
 		&restaurant.Name,
@@ -44,8 +78,12 @@ func GetRestaurantByID(id string) (*model.Restaurant, error) {
 		&restaurant.ImageURL,
 		&restaurant.IsActive,
 		&restaurant.Rating,
+		&restaurant.OpensAt,
+		&restaurant.Timezone,
 		&restaurant.CreatedAt,
 		&restaurant.UpdatedAt,
+		&restaurant.Version,
+		&restaurant.BrandID,
 	)
 	if err != nil {
 		return nil, err
@@ -54,15 +92,19 @@ func GetRestaurantByID(id string) (*model.Restaurant, error) {
 	return restaurant, nil
 }
 
-func GetRestaurantsByOwner(ownerID string) ([]model.Restaurant, error) {
+// GetRestaurantsByMember lists the restaurants userID works at, with their role in MyRole
+func (repo *RestaurantRepository) GetRestaurantsByMember(ctx context.Context, userID string) ([]model.Restaurant, error) {
 	query := `
-		SELECT id, owner_id, name, description, address, city, image_url, is_active, rating, created_at, updated_at
-		FROM restaurants
-		WHERE owner_id = $1
-		ORDER BY created_at DESC
+		SELECT r.id, r.owner_id, r.name, r.description, r.address, r.city, r.image_url, r.is_active, r.rating,
+		       to_char(r.opens_at, 'HH24:MI'), r.timezone, r.created_at, r.updated_at, r.version,
+		       COALESCE(r.brand_id::text, ''), m.role
+		FROM restaurants r
+		JOIN restaurant_members m ON m.restaurant_id = r.id
+		WHERE m.user_id = $1 AND r.deleted_at IS NULL
+		ORDER BY r.created_at DESC
 	`
 
-	rows, err := db.DB.Query(context.Background(), query, ownerID)
+	rows, err := repo.pool.Query(ctx, query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -82,8 +124,13 @@ func GetRestaurantsByOwner(ownerID string) ([]model.Restaurant, error) {
 			&r.ImageURL,
 			&r.IsActive,
 			&r.Rating,
+			&r.OpensAt,
+			&r.Timezone,
 			&r.CreatedAt,
 			&r.UpdatedAt,
+			&r.Version,
+			&r.BrandID,
+			&r.MyRole,
 		)
 		if err != nil {
 			return nil, err
@@ -94,27 +141,29 @@ func GetRestaurantsByOwner(ownerID string) ([]model.Restaurant, error) {
 	return restaurants, nil
 }
 
-func GetAllRestaurants(city string) ([]model.Restaurant, error) {
+func (repo *RestaurantRepository) GetAllRestaurants(ctx context.Context, city string) ([]model.Restaurant, error) {
 	var query string
 	var rows interface{ Close() }
 	var err error
 
 	if city != "" {
 		query = `
-			SELECT id, owner_id, name, description, address, city, image_url, is_active, rating, created_at, updated_at
+			SELECT id, owner_id, name, description, address, city, image_url, is_active, rating,
+			       to_char(opens_at, 'HH24:MI'), timezone, created_at, updated_at, version, COALESCE(brand_id::text, '')
 			FROM restaurants
-			WHERE is_active = true AND city = $1
+			WHERE is_active = true AND deleted_at IS NULL AND city = $1
 			ORDER BY rating DESC, created_at DESC
 		`
-		rows, err = db.DB.Query(context.Background(), query, city)
+		rows, err = repo.pool.Query(ctx, query, city)
 	} else {
 		query = `
-			SELECT id, owner_id, name, description, address, city, image_url, is_active, rating, created_at, updated_at
+			SELECT id, owner_id, name, description, address, city, image_url, is_active, rating,
+			       to_char(opens_at, 'HH24:MI'), timezone, created_at, updated_at, version, COALESCE(brand_id::text, '')
 			FROM restaurants
-			WHERE is_active = true
+			WHERE is_active = true AND deleted_at IS NULL
 			ORDER BY rating DESC, created_at DESC
 		`
-		rows, err = db.DB.Query(context.Background(), query)
+		rows, err = repo.pool.Query(ctx, query)
 	}
 
 	if err != nil {
@@ -141,8 +190,12 @@ func GetAllRestaurants(city string) ([]model.Restaurant, error) {
 			&r.ImageURL,
 			&r.IsActive,
 			&r.Rating,
+			&r.OpensAt,
+			&r.Timezone,
 			&r.CreatedAt,
 			&r.UpdatedAt,
+			&r.Version,
+			&r.BrandID,
 		)
 		if err != nil {
 			return nil, err
@@ -153,15 +206,21 @@ func GetAllRestaurants(city string) ([]model.Restaurant, error) {
 	return restaurants, nil
 }
 
-func UpdateRestaurant(id string, req *model.UpdateRestaurantRequest) error {
+// UpdateRestaurant overwrites the restaurant if it is still at version
+// (0 skips the check) and returns ErrStale otherwise
+func (repo *RestaurantRepository) UpdateRestaurant(ctx context.Context, id string, version int, req *model.UpdateRestaurantRequest) error {
 	query := `
 		UPDATE restaurants
-		SET name = $1, description = $2, address = $3, city = $4, image_url = $5, is_active = $6, updated_at = NOW()
-		WHERE id = $7
+		SET name = $1, description = $2, address = $3, city = $4, image_url = $5, is_active = $6,
+		    opens_at = COALESCE(NULLIF($7, '')::time, opens_at),
+		    timezone = COALESCE(NULLIF($8, ''), timezone),
+		    version = version + 1,
+		    updated_at = NOW()
+		WHERE id = $9 AND ($10 = 0 OR version = $10) AND deleted_at IS NULL
 	`
 
-	_, err := db.DB.Exec(
-		context.Background(),
+	tag, err := repo.pool.Exec(
+		ctx,
 		query,
 		req.Name,
 		req.Description,
@@ -169,14 +228,297 @@ func UpdateRestaurant(id string, req *model.UpdateRestaurantRequest) error {
 		req.City,
 		req.ImageURL,
 		req.IsActive,
+		req.OpensAt,
+		req.Timezone,
 		id,
+		version,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStale
+	}
+	return nil
+}
+
+// DeleteRestaurant soft-deletes the restaurant and its live categories and items.
+// They all share one deleted_at so RestoreRestaurant can bring back exactly this set.
+func (repo *RestaurantRepository) DeleteRestaurant(ctx context.Context, id string) error {
+
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE menu_items SET deleted_at = NOW(), updated_at = NOW()
+		WHERE deleted_at IS NULL
+		  AND category_id IN (SELECT id FROM menu_categories WHERE restaurant_id = $1)
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE menu_categories SET deleted_at = NOW()
+		WHERE restaurant_id = $1 AND deleted_at IS NULL
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE restaurants SET deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetDeletedRestaurantByID fetches a restaurant only if it was deleted within the retention window
+func (repo *RestaurantRepository) GetDeletedRestaurantByID(ctx context.Context, id string, retention time.Duration) (*model.Restaurant, error) {
+	query := `
+		SELECT id, owner_id, name, description, address, city, image_url, is_active, rating,
+		       to_char(opens_at, 'HH24:MI'), timezone, created_at, updated_at, version, deleted_at
+		FROM restaurants
+		WHERE id = $1 AND deleted_at > NOW() - $2::interval
+	`
+
+	restaurant := &model.Restaurant{}
+
+	err := repo.pool.QueryRow(ctx, query, id, retention).Scan(
+		&restaurant.ID,
+		&restaurant.OwnerID,
+		&restaurant.Name,
+		&restaurant.Description,
+		&restaurant.Address,
+		&restaurant.City,
+		&restaurant.ImageURL,
+		&restaurant.IsActive,
+		&restaurant.Rating,
+		&restaurant.OpensAt,
+		&restaurant.Timezone,
+		&restaurant.CreatedAt,
+		&restaurant.UpdatedAt,
+		&restaurant.Version,
+		&restaurant.DeletedAt,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	return restaurant, nil
+}
+
+// RestoreRestaurant undoes DeleteRestaurant, including the categories and
+// items that were deleted together with it
+func (repo *RestaurantRepository) RestoreRestaurant(ctx context.Context, id string) error {
+
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE menu_items mi SET deleted_at = NULL, updated_at = NOW()
+		FROM menu_categories mc, restaurants r
+		WHERE mi.category_id = mc.id
+		  AND mc.restaurant_id = r.id
+		  AND r.id = $1
+		  AND mi.deleted_at = r.deleted_at
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE menu_categories mc SET deleted_at = NULL
+		FROM restaurants r
+		WHERE mc.restaurant_id = r.id
+		  AND r.id = $1
+		  AND mc.deleted_at = r.deleted_at
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE restaurants SET deleted_at = NULL, updated_at = NOW()
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetTrash lists restaurants, categories and items deleted within the retention window.
+// Categories and items are only listed when their parent is still live.
+func (repo *RestaurantRepository) GetTrash(ctx context.Context, restaurantIDs, menuRestaurantIDs []string, retention time.Duration) (*model.Trash, error) {
+	trash := &model.Trash{
+		Restaurants: []model.Restaurant{},
+		Categories:  []model.MenuCategory{},
+		MenuItems:   []model.MenuItem{},
+	}
+
+	restaurantRows, err := repo.pool.Query(ctx, `
+		SELECT id, owner_id, name, description, address, city, image_url, is_active, rating,
+		       to_char(opens_at, 'HH24:MI'), timezone, created_at, updated_at, version, deleted_at
+		FROM restaurants
+		WHERE id = ANY($1::uuid[]) AND deleted_at > NOW() - $2::interval
+		ORDER BY deleted_at DESC
+	`, restaurantIDs, retention)
+	if err != nil {
+		return nil, err
+	}
+	defer restaurantRows.Close()
+
+	for restaurantRows.Next() {
+		var r model.Restaurant
+		err := restaurantRows.Scan(
+			&r.ID,
+			&r.OwnerID,
+			&r.Name,
+			&r.Description,
+			&r.Address,
+			&r.City,
+			&r.ImageURL,
+			&r.IsActive,
+			&r.Rating,
+			&r.OpensAt,
+			&r.Timezone,
+			&r.CreatedAt,
+			&r.UpdatedAt,
+			&r.Version,
+			&r.DeletedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		trash.Restaurants = append(trash.Restaurants, r)
+	}
+	restaurantRows.Close()
+
+	categoryRows, err := repo.pool.Query(ctx, `
+		SELECT mc.id, mc.restaurant_id, mc.name, mc.display_order, mc.created_at, mc.deleted_at
+		FROM menu_categories mc
+		JOIN restaurants r ON r.id = mc.restaurant_id
+		WHERE r.id = ANY($1::uuid[]) AND r.deleted_at IS NULL AND mc.deleted_at > NOW() - $2::interval
+		ORDER BY mc.deleted_at DESC
+	`, menuRestaurantIDs, retention)
+	if err != nil {
+		return nil, err
+	}
+	defer categoryRows.Close()
+
+	for categoryRows.Next() {
+		var c model.MenuCategory
+		err := categoryRows.Scan(&c.ID, &c.RestaurantID, &c.Name, &c.DisplayOrder, &c.CreatedAt, &c.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+		trash.Categories = append(trash.Categories, c)
+	}
+	categoryRows.Close()
+
+	itemRows, err := repo.pool.Query(ctx, `
+		SELECT mi.id, mi.category_id, mi.name, mi.description, mi.price, mi.image_url, mi.is_available, mi.is_veg,
+		       mi.stock_quantity, mi.daily_stock, mi.created_at, mi.updated_at, mi.version, mi.deleted_at
+		FROM menu_items mi
+		JOIN menu_categories mc ON mc.id = mi.category_id
+		JOIN restaurants r ON r.id = mc.restaurant_id
+		WHERE r.id = ANY($1::uuid[]) AND r.deleted_at IS NULL AND mc.deleted_at IS NULL AND mi.deleted_at > NOW() - $2::interval
+		ORDER BY mi.deleted_at DESC
+	`, menuRestaurantIDs, retention)
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var item model.MenuItem
+		err := itemRows.Scan(
+			&item.ID,
+			&item.CategoryID,
+			&item.Name,
+			&item.Description,
+			&item.Price,
+			&item.ImageURL,
+			&item.IsAvailable,
+			&item.IsVeg,
+			&item.StockQuantity,
+			&item.DailyStock,
+			&item.CreatedAt,
+			&item.UpdatedAt,
+			&item.Version,
+			&item.DeletedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		trash.MenuItems = append(trash.MenuItems, item)
+	}
 
-	return err
+	return trash, nil
 }
 
-func DeleteRestaurant(id string) error {
-	query := `DELETE FROM restaurants WHERE id = $1`
-	_, err := db.DB.Exec(context.Background(), query, id)
-	return err
+// PurgeDeleted hard-deletes rows that have been in the trash longer than retention.
+// Restaurants that have orders are kept so revenue history stays intact.
+func (repo *RestaurantRepository) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	var purged int64
+
+	queries := []string{
+		`DELETE FROM menu_items WHERE deleted_at < NOW() - $1::interval`,
+		`DELETE FROM menu_categories WHERE deleted_at < NOW() - $1::interval`,
+		`DELETE FROM restaurants r
+		 WHERE r.deleted_at < NOW() - $1::interval
+		   AND NOT EXISTS (SELECT 1 FROM orders o WHERE o.restaurant_id = r.id)`,
+	}
+
+	for _, query := range queries {
+		tag, err := repo.pool.Exec(ctx, query, retention)
+		if err != nil {
+			return purged, err
+		}
+		purged += tag.RowsAffected()
+	}
+
+	return purged, nil
+}
+
+// PatchRestaurant updates only the fields present in req, under the same
+// version check as UpdateRestaurant
+func (repo *RestaurantRepository) PatchRestaurant(ctx context.Context, id string, version int, req *model.PatchRestaurantRequest) error {
+	var update updateBuilder
+	setIfPresent(&update, "name", req.Name)
+	setIfPresent(&update, "description", req.Description)
+	setIfPresent(&update, "address", req.Address)
+	setIfPresent(&update, "city", req.City)
+	setIfPresent(&update, "image_url", req.ImageURL)
+	setIfPresent(&update, "is_active", req.IsActive)
+	if req.OpensAt.Present {
+		update.set("opens_at", "::time", req.OpensAt.Get())
+	}
+	setIfPresent(&update, "timezone", req.Timezone)
+
+	if update.empty() {
+		return nil
+	}
+
+	query, args := update.build("restaurants", id, version, "AND deleted_at IS NULL")
+	tag, err := repo.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStale
+	}
+	return nil
 }
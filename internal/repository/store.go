@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"quickbite/internal/model"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// The service layer depends on these interfaces only. The *Repository types
+// in this package implement them on top of pgx; internal/repository/memory
+// has in-memory fakes for running services without Postgres.
+
+type UserStore interface {
+	CreateUser(ctx context.Context, user *model.User) error
+	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
+	GetUserByID(ctx context.Context, id string) (*model.User, error)
+}
+
+// LoginAttemptStore tracks failed logins per email for progressive lockout
+type LoginAttemptStore interface {
+	// GetLoginLock returns how much longer the email is locked out; 0 if it isn't
+	GetLoginLock(ctx context.Context, email string) (time.Duration, error)
+	// RecordLoginFailure counts a failure and returns the running total.
+	// Failures further apart than window start the count over.
+	RecordLoginFailure(ctx context.Context, email string, window time.Duration) (int, error)
+	LockLogin(ctx context.Context, email string, d time.Duration) error
+	ClearLoginFailures(ctx context.Context, email string) error
+	PurgeLoginFailures(ctx context.Context, olderThan time.Duration) (int64, error)
+}
+
+// Owner edits to restaurants and menu items take the version the caller
+// read; 0 skips the check. A mismatch returns ErrStale.
+type RestaurantStore interface {
+	// CreateRestaurant also makes restaurant.OwnerID its owner member
+	CreateRestaurant(ctx context.Context, restaurant *model.Restaurant) error
+	GetRestaurantByID(ctx context.Context, id string) (*model.Restaurant, error)
+	// GetRestaurantsByMember lists where userID works, with their role in MyRole
+	GetRestaurantsByMember(ctx context.Context, userID string) ([]model.Restaurant, error)
+	GetAllRestaurants(ctx context.Context, city string) ([]model.Restaurant, error)
+	UpdateRestaurant(ctx context.Context, id string, version int, req *model.UpdateRestaurantRequest) error
+	PatchRestaurant(ctx context.Context, id string, version int, req *model.PatchRestaurantRequest) error
+	DeleteRestaurant(ctx context.Context, id string) error
+
+	// Trash
+	GetDeletedRestaurantByID(ctx context.Context, id string, retention time.Duration) (*model.Restaurant, error)
+	RestoreRestaurant(ctx context.Context, id string) error
+	// GetTrash lists the restaurants in restaurantIDs deleted within
+	// retention, and what was deleted from the menus of the live ones in
+	// menuRestaurantIDs
+	GetTrash(ctx context.Context, restaurantIDs, menuRestaurantIDs []string, retention time.Duration) (*model.Trash, error)
+	PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+type MenuStore interface {
+	CreateCategory(ctx context.Context, category *model.MenuCategory) error
+	GetCategoriesByRestaurant(ctx context.Context, restaurantID string) ([]model.MenuCategory, error)
+	GetCategoryByID(ctx context.Context, id string) (*model.MenuCategory, error)
+	DeleteCategory(ctx context.Context, id string) error
+	GetDeletedCategoryByID(ctx context.Context, id string, retention time.Duration) (*model.MenuCategory, error)
+	RestoreCategory(ctx context.Context, id string) error
+
+	CreateMenuItem(ctx context.Context, item *model.MenuItem) error
+	GetMenuItemsByCategory(ctx context.Context, categoryID string) ([]model.MenuItem, error)
+	GetMenuItemByID(ctx context.Context, id string) (*model.MenuItem, error)
+	UpdateMenuItem(ctx context.Context, id string, version int, req *model.UpdateMenuItemRequest) error
+	PatchMenuItem(ctx context.Context, id string, version int, req *model.PatchMenuItemRequest) error
+	DeleteMenuItem(ctx context.Context, id string) error
+	GetDeletedMenuItemByID(ctx context.Context, id string, retention time.Duration) (*model.MenuItem, error)
+	RestoreMenuItem(ctx context.Context, id string) error
+
+	// Stock
+	UpdateMenuItemStock(ctx context.Context, id string, req *model.UpdateStockRequest) error
+	ResetDailyStock(ctx context.Context) (int64, error)
+
+	// Availability windows
+	GetWindowsByCategories(ctx context.Context, categoryIDs []string) (map[string][]model.AvailabilityWindow, error)
+	GetWindowsByMenuItems(ctx context.Context, itemIDs []string) (map[string][]model.AvailabilityWindow, error)
+	ReplaceCategoryWindows(ctx context.Context, categoryID string, windows []model.AvailabilityWindow) error
+	ReplaceMenuItemWindows(ctx context.Context, itemID string, windows []model.AvailabilityWindow) error
+}
+
+type OrderStore interface {
+	// CreateOrder inserts the order and items atomically, reserving stock.
+	// Returns *OutOfStockError when a tracked item can't cover the quantity.
+	CreateOrder(ctx context.Context, order *model.Order, items []model.OrderItem) error
+	GetOrderByID(ctx context.Context, id string) (*model.Order, error)
+	GetOrderWithDetails(ctx context.Context, id string) (*model.OrderWithDetails, error)
+	GetOrdersByUser(ctx context.Context, userID string) ([]model.OrderWithDetails, error)
+	GetOrdersByRestaurant(ctx context.Context, restaurantID string) ([]model.OrderWithDetails, error)
+	// UpdateOrderStatus and CancelOrder only apply while the order is still
+	// in status from, returning ErrStale otherwise
+	UpdateOrderStatus(ctx context.Context, orderID string, from, to string) error
+	// ConfirmOrder is UpdateOrderStatus for an order leaving pending, which
+	// also sets its delivery code and records it as accepted, with the prep
+	// estimate if prepMinutes isn't 0
+	ConfirmOrder(ctx context.Context, orderID string, from, to, deliveryCode string, prepMinutes int) error
+	// CancelOrder marks the order cancelled and puts its items back into
+	// stock. A paid order moves to payment status refund_pending.
+	CancelOrder(ctx context.Context, orderID string, from string) error
+	// RejectOrder is CancelOrder for a pending order the restaurant turned
+	// down, or didn't answer in time, recording why
+	RejectOrder(ctx context.Context, orderID string, reason string) error
+	// GetUnansweredOrders lists orders still pending more than olderThan
+	// after they were placed, oldest first
+	GetUnansweredOrders(ctx context.Context, olderThan time.Duration, limit int) ([]model.Order, error)
+
+	// GetRefundsDue lists orders in payment status refund_pending, oldest first
+	GetRefundsDue(ctx context.Context, limit int) ([]model.Order, error)
+	// SetPaymentStatus only applies while the payment status is still from,
+	// returning ErrStale otherwise
+	SetPaymentStatus(ctx context.Context, orderID string, from, to string) error
+
+	// ReissueDeliveryCode replaces the order's delivery code and resets its attempts
+	ReissueDeliveryCode(ctx context.Context, orderID, deliveryCode string) error
+	// UseDeliveryCodeAttempt counts one entry of the delivery code,
+	// returning ErrStale once max have been used
+	UseDeliveryCodeAttempt(ctx context.Context, orderID string, max int) error
+	LogDeliveryCodeEvent(ctx context.Context, event *model.DeliveryCodeEvent) error
+	GetDeliveryCodeEvents(ctx context.Context, orderID string) ([]model.DeliveryCodeEvent, error)
+	// GetRevenue totals delivered orders placed in [from, to); nil bounds are open
+	GetRevenue(ctx context.Context, restaurantID string, from, to *time.Time) (*model.RevenueSummary, error)
+}
+
+// MemberStore holds who works at which restaurant and the invitations to join
+type MemberStore interface {
+	GetMember(ctx context.Context, restaurantID, userID string) (*model.RestaurantMember, error)
+	GetMembers(ctx context.Context, restaurantID string) ([]model.RestaurantMember, error)
+	// GetMembershipsByUser lists userID's role at every restaurant, deleted ones included
+	GetMembershipsByUser(ctx context.Context, userID string) ([]model.RestaurantMember, error)
+	UpdateMemberRole(ctx context.Context, restaurantID, userID, role string) error
+	RemoveMember(ctx context.Context, restaurantID, userID string) error
+
+	// SaveInvitation creates inv, or refreshes the one still pending for the same email
+	SaveInvitation(ctx context.Context, inv *model.Invitation) error
+	GetInvitationByToken(ctx context.Context, tokenHash string) (*model.Invitation, error)
+	GetPendingInvitations(ctx context.Context, restaurantID string) ([]model.Invitation, error)
+	// DeleteInvitation and AcceptInvitation return ErrStale if the
+	// invitation is no longer pending
+	DeleteInvitation(ctx context.Context, restaurantID, id string) error
+	AcceptInvitation(ctx context.Context, id, userID string) error
+}
+
+// BrandStore holds brands, their master menu and the outlets' overrides.
+// Outlets sell linked copies of the master categories and items from their
+// own menu; SyncBrandMenu rewrites those copies after anything changes.
+type BrandStore interface {
+	CreateBrand(ctx context.Context, brand *model.Brand) error
+	GetBrandByID(ctx context.Context, id string) (*model.Brand, error)
+	GetBrandsByOwner(ctx context.Context, ownerID string) ([]model.Brand, error)
+	UpdateBrand(ctx context.Context, id string, version int, req *model.UpdateBrandRequest) error
+
+	GetOutlets(ctx context.Context, brandID string) ([]model.Restaurant, error)
+	// AttachOutlet returns ErrStale if the restaurant already has a brand
+	AttachOutlet(ctx context.Context, brandID, restaurantID string) error
+	// DetachOutlet keeps the outlet's copies as its own menu. It returns
+	// ErrStale if the restaurant isn't an outlet of brandID.
+	DetachOutlet(ctx context.Context, brandID, restaurantID string) error
+
+	CreateBrandCategory(ctx context.Context, category *model.BrandCategory) error
+	GetBrandCategoryByID(ctx context.Context, id string) (*model.BrandCategory, error)
+	// DeleteBrandCategory and DeleteBrandItem archive the outlets' copies
+	DeleteBrandCategory(ctx context.Context, id string) error
+	CreateBrandItem(ctx context.Context, item *model.BrandMenuItem) error
+	GetBrandItemByID(ctx context.Context, id string) (*model.BrandMenuItem, error)
+	UpdateBrandItem(ctx context.Context, id string, version int, req *model.UpdateBrandMenuItemRequest) error
+	DeleteBrandItem(ctx context.Context, id string) error
+	GetBrandMenu(ctx context.Context, brandID string) ([]model.BrandCategory, error)
+
+	GetOutletOverrides(ctx context.Context, restaurantID string) ([]model.OutletOverride, error)
+	SaveOutletOverride(ctx context.Context, override *model.OutletOverride) error
+	DeleteOutletOverride(ctx context.Context, restaurantID, brandItemID string) error
+
+	// SyncBrandMenu updates the copies at restaurantID, or at every outlet when it's ""
+	SyncBrandMenu(ctx context.Context, brandID, restaurantID string) error
+	// GetOutletStats counts each outlet's orders placed in [from, to); nil bounds are open
+	GetOutletStats(ctx context.Context, brandID string, from, to *time.Time) ([]model.OutletStats, error)
+}
+
+// DispatchStore holds delivery partners and the assignments that hand
+// orders to them. An order and a partner each have at most one open
+// (offered or accepted) assignment.
+type DispatchStore interface {
+	GetPartner(ctx context.Context, userID string) (*model.DeliveryPartner, error)
+	SetPartnerOnline(ctx context.Context, userID string, online bool) (*model.DeliveryPartner, error)
+	UpdatePartnerLocation(ctx context.Context, userID string, lat, lng float64) (*model.DeliveryPartner, error)
+	SetRestaurantLocation(ctx context.Context, restaurantID string, lat, lng float64) error
+
+	// GetUndispatchedOrders lists ready orders with no open assignment at
+	// restaurants with a location, oldest first
+	GetUndispatchedOrders(ctx context.Context, limit int) ([]model.Order, error)
+	// FindNearestPartner returns the closest partner to the order's
+	// restaurant, within maxKm, who is online, pinged within fresh, has no
+	// open assignment and hasn't rejected this order. Partners who let an
+	// offer of it expire come after everyone else. It returns pgx.ErrNoRows
+	// if nobody qualifies.
+	FindNearestPartner(ctx context.Context, orderID string, maxKm float64, fresh time.Duration) (partnerID string, distanceKm float64, err error)
+	// CreateOffer returns ErrStale if the order or partner already has an
+	// open assignment
+	CreateOffer(ctx context.Context, a *model.DeliveryAssignment) error
+	// ReleaseStaleAssignments expires offers past their deadline and
+	// releases open assignments whose order was cancelled or delivered
+	// without the partner
+	ReleaseStaleAssignments(ctx context.Context) (int64, error)
+
+	GetAssignment(ctx context.Context, id string) (*model.DeliveryAssignment, error)
+	// GetPartnerTasks lists the partner's open assignments
+	GetPartnerTasks(ctx context.Context, partnerID string) ([]model.DeliveryTask, error)
+	// GetOrderDelivery returns the order's accepted or delivered assignment
+	GetOrderDelivery(ctx context.Context, orderID string) (*model.OrderDelivery, error)
+
+	// AcceptOffer and RejectOffer only apply to an offer still open and
+	// unexpired, returning ErrStale otherwise
+	AcceptOffer(ctx context.Context, id string) error
+	RejectOffer(ctx context.Context, id string) error
+	// PickUp moves the order from ready to out_for_delivery and
+	// CompleteDelivery from out_for_delivery to delivered, closing the
+	// assignment. Both return ErrStale if the order or assignment has moved on.
+	PickUp(ctx context.Context, id string) error
+	CompleteDelivery(ctx context.Context, id string) error
+}
+
+// KitchenStore backs the kitchen display: the orders a restaurant is
+// working on and which of their items are done
+type KitchenStore interface {
+	// GetActiveOrders lists the restaurant's confirmed, preparing and ready
+	// orders with their items, oldest first
+	GetActiveOrders(ctx context.Context, restaurantID string) ([]model.OrderWithDetails, error)
+	// SetItemPrepared returns pgx.ErrNoRows if the item isn't part of the order
+	SetItemPrepared(ctx context.Context, orderID, itemID string, done bool) error
+	// GetPrepTarget returns pgx.ErrNoRows if the restaurant hasn't set one
+	GetPrepTarget(ctx context.Context, restaurantID string) (int, error)
+	SetPrepTarget(ctx context.Context, restaurantID string, minutes int) error
+}
+
+// Stores bundles one implementation of every store
+type Stores struct {
+	Users         UserStore
+	LoginAttempts LoginAttemptStore
+	Restaurants   RestaurantStore
+	Menu          MenuStore
+	Orders        OrderStore
+	Members       MemberStore
+	Brands        BrandStore
+	Dispatch      DispatchStore
+	Kitchen       KitchenStore
+}
+
+// NewStores returns the Postgres-backed stores
+func NewStores(pool *pgxpool.Pool) *Stores {
+	return &Stores{
+		Users:         NewUserRepository(pool),
+		LoginAttempts: NewLoginAttemptRepository(pool),
+		Restaurants:   NewRestaurantRepository(pool),
+		Menu:          NewMenuRepository(pool),
+		Orders:        NewOrderRepository(pool),
+		Members:       NewMemberRepository(pool),
+		Brands:        NewBrandRepository(pool),
+		Dispatch:      NewDispatchRepository(pool),
+		Kitchen:       NewKitchenRepository(pool),
+	}
+}
+
+var (
+	_ UserStore         = (*UserRepository)(nil)
+	_ LoginAttemptStore = (*LoginAttemptRepository)(nil)
+	_ RestaurantStore   = (*RestaurantRepository)(nil)
+	_ MenuStore         = (*MenuRepository)(nil)
+	_ OrderStore        = (*OrderRepository)(nil)
+	_ MemberStore       = (*MemberRepository)(nil)
+	_ BrandStore        = (*BrandRepository)(nil)
+	_ DispatchStore     = (*DispatchRepository)(nil)
+	_ KitchenStore      = (*KitchenRepository)(nil)
+)
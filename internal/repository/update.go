@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"quickbite/internal/model"
+)
+
+// updateBuilder assembles the SET list of an UPDATE from whichever patch
+// fields were sent, numbering placeholders as it goes. The table needs a
+// version column.
+type updateBuilder struct {
+	sets []string
+	args []any
+}
+
+// set adds "column = $n" with cast appended to the placeholder, e.g. "::time"
+func (b *updateBuilder) set(column, cast string, value any) {
+	b.args = append(b.args, value)
+	b.sets = append(b.sets, fmt.Sprintf("%s = $%d%s", column, len(b.args), cast))
+}
+
+// setIfPresent adds column when the patch has the field; a null writes
+// the zero value, so text columns are cleared to an empty string
+func setIfPresent[T any](b *updateBuilder, column string, value model.Optional[T]) {
+	if value.Present {
+		b.set(column, "", value.Get())
+	}
+}
+
+func (b *updateBuilder) empty() bool {
+	return len(b.sets) == 0
+}
+
+// build returns the statement for the row with the given id. version and
+// updated_at are always bumped; a non-zero version must match the stored
+// one. where is extra SQL appended to the id match.
+func (b *updateBuilder) build(table, id string, version int, where string) (string, []any) {
+	args := append(b.args, id, version)
+	query := fmt.Sprintf(
+		"UPDATE %s SET %s, version = version + 1, updated_at = NOW() WHERE id = $%d AND ($%d = 0 OR version = $%d) %s",
+		table, strings.Join(b.sets, ", "), len(args)-1, len(args), len(args), where,
+	)
+	return query, args
+}
@@ -2,19 +2,29 @@ package repository
 
 import (
 	"context"
-	"quickbite/db"
 	"quickbite/internal/model"
+
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func CreateUser(user *model.User) error {
+// UserRepository is the pgx-backed UserStore
+type UserRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewUserRepository(pool *pgxpool.Pool) *UserRepository {
+	return &UserRepository{pool: pool}
+}
+
+func (repo *UserRepository) CreateUser(ctx context.Context, user *model.User) error {
 	query := `
 		INSERT INTO users (name, email, password, phone, role)
 		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, created_at, updated_at
 	`
 
-	return db.DB.QueryRow(
-		context.Background(),
+	return repo.pool.QueryRow(
+		ctx,
 		query,
 		user.Name,
 		user.Email,
@@ -24,16 +34,16 @@ func CreateUser(user *model.User) error {
 	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
 }
 
-func GetUserByEmail(email string) (*model.User, error) {
+func (repo *UserRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
 	query := `
 		SELECT id, name, email, password, phone, role, is_verified, created_at, updated_at
 		FROM users
-		WHERE email = $1
+		WHERE LOWER(email) = LOWER($1)
 	`
 
 	user := &model.User{}
 
-	err := db.DB.QueryRow(context.Background(), query, email).Scan(
+	err := repo.pool.QueryRow(ctx, query, email).Scan(
 		&user.ID,
 		&user.Name,
 		&user.Email,
@@ -51,7 +61,7 @@ func GetUserByEmail(email string) (*model.User, error) {
 	return user, nil
 }
 
-func GetUserByID(id string) (*model.User, error) {
+func (repo *UserRepository) GetUserByID(ctx context.Context, id string) (*model.User, error) {
 	query := `
 		SELECT id, name, email, phone, role, is_verified, created_at, updated_at
 		FROM users
@@ -60,7 +70,7 @@ func GetUserByID(id string) (*model.User, error) {
 
 	user := &model.User{}
 
-	err := db.DB.QueryRow(context.Background(), query, id).Scan(
+	err := repo.pool.QueryRow(ctx, query, id).Scan(
 		&user.ID,
 		&user.Name,
 		&user.Email,
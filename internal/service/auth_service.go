@@ -1,69 +1,185 @@
 package service
 
 import (
-	"errors"
+	"context"
+	"log/slog"
+	"strings"
 	"time"
 
 	"quickbite/config"
+	"quickbite/internal/apperr"
 	"quickbite/internal/model"
 	"quickbite/internal/repository"
+	"quickbite/internal/tracing"
+	"quickbite/internal/validate"
 
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func Register(req *model.RegisterRequest, cfg *config.Config) (*model.AuthResponse, error) {
-	if req.Role == "" {
-		req.Role = "customer"
+// Progressive lockout: after loginLockThreshold failures for one email,
+// each further failure locks it for twice as long as the last, up to loginLockMax
+const (
+	loginFailureWindow = time.Hour // failures further apart than this start the count over
+	loginLockThreshold = 5
+	loginLockBase      = 30 * time.Second
+	loginLockMax       = time.Hour
+)
+
+// dummyPasswordHash is checked against when no account has the email, so an
+// unknown email takes as long to reject as a wrong password. It is a
+// DefaultCost hash of a password nobody has.
+const dummyPasswordHash = "$2a$10$WSi3YH/SYEiKNXTz6tNl7O938JjfVDRn0yfIZ0eRuZFavZKiqY6FO"
+
+type AuthService struct {
+	users    repository.UserStore
+	attempts repository.LoginAttemptStore
+	cfg      *config.Config
+}
+
+func NewAuthService(users repository.UserStore, attempts repository.LoginAttemptStore, cfg *config.Config) *AuthService {
+	return &AuthService{users: users, attempts: attempts, cfg: cfg}
+}
+
+func (s *AuthService) Register(ctx context.Context, req *model.RegisterRequest) (*model.AuthResponse, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Register")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
 	}
 
-	if req.Role != "customer" && req.Role != "restaurant_owner" {
-		return nil, errors.New("invalid role, must be customer or restaurant_owner")
+	if req.Role == "" {
+		req.Role = "customer"
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
-		return nil, errors.New("failed to process password")
+		return nil, apperr.Internal("failed to process password", err)
 	}
 
 	user := &model.User{
 		Name:     req.Name,
-		Email:    req.Email,
+		Email:    normalizeEmail(req.Email),
 		Password: string(hashedPassword),
 		Phone:    req.Phone,
 		Role:     req.Role,
 	}
 
-	if err := repository.CreateUser(user); err != nil {
-		return nil, errors.New("email already in use")
+	if err := s.users.CreateUser(ctx, user); err != nil {
+		if repository.IsUniqueViolation(err) {
+			return nil, apperr.Conflict("email already in use")
+		}
+		return nil, storeErr(err, "failed to create account")
 	}
 
-	token, err := generateJWT(user, cfg)
+	token, err := generateJWT(user, s.cfg)
 	if err != nil {
-		return nil, errors.New("failed to generate token")
+		return nil, apperr.Internal("failed to generate token", err)
 	}
 
 	return &model.AuthResponse{Token: token, User: *user}, nil
 }
 
-func Login(req *model.LoginRequest, cfg *config.Config) (*model.AuthResponse, error) {
-	user, err := repository.GetUserByEmail(req.Email)
+func (s *AuthService) Login(ctx context.Context, req *model.LoginRequest) (*model.AuthResponse, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Login")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	// Lockout is per address as typed, whether or not an account has it,
+	// so it can't be used to find out which emails are registered
+	email := normalizeEmail(req.Email)
+
+	locked, err := s.attempts.GetLoginLock(ctx, email)
 	if err != nil {
-		return nil, errors.New("invalid email or password")
+		return nil, storeErr(err, "failed to check login attempts")
+	}
+	if locked > 0 {
+		return nil, apperr.TooManyRequests("too many failed login attempts, please try again later", locked)
+	}
+
+	user, err := s.users.GetUserByEmail(ctx, email)
+	if err != nil {
+		if ctxErr := repository.ContextError(err); ctxErr != nil {
+			return nil, ctxErr
+		}
+		// Spend the same bcrypt time as a wrong password would
+		bcrypt.CompareHashAndPassword([]byte(dummyPasswordHash), []byte(req.Password))
+		s.loginFailed(ctx, email)
+		return nil, apperr.Unauthorized("invalid email or password")
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		return nil, errors.New("invalid email or password")
+		s.loginFailed(ctx, email)
+		return nil, apperr.Unauthorized("invalid email or password")
 	}
 
-	token, err := generateJWT(user, cfg)
+	if err := s.attempts.ClearLoginFailures(ctx, email); err != nil {
+		slog.WarnContext(ctx, "failed to clear login failures", "error", err)
+	}
+
+	token, err := generateJWT(user, s.cfg)
 	if err != nil {
-		return nil, errors.New("failed to generate token")
+		return nil, apperr.Internal("failed to generate token", err)
 	}
 
 	return &model.AuthResponse{Token: token, User: *user}, nil
 }
 
+// PurgeLoginFailures forgets failures that can no longer count towards a lockout
+func (s *AuthService) PurgeLoginFailures(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "AuthService.PurgeLoginFailures")
+	defer span.End()
+
+	count, err := s.attempts.PurgeLoginFailures(ctx, loginFailureWindow)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		slog.InfoContext(ctx, "purged login failures", "rows", count)
+	}
+	return nil
+}
+
+// loginFailed counts a failure and locks the email once it crosses the
+// threshold. Errors are only logged: the caller is failing the login anyway.
+func (s *AuthService) loginFailed(ctx context.Context, email string) {
+	failures, err := s.attempts.RecordLoginFailure(ctx, email, loginFailureWindow)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to record login failure", "error", err)
+		return
+	}
+
+	lock := loginLockDuration(failures)
+	if lock == 0 {
+		return
+	}
+	if err := s.attempts.LockLogin(ctx, email, lock); err != nil {
+		slog.WarnContext(ctx, "failed to lock login", "error", err)
+		return
+	}
+	slog.WarnContext(ctx, "login locked after repeated failures", "failures", failures, "lock", lock.String())
+}
+
+// normalizeEmail is the form emails are stored and looked up in
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func loginLockDuration(failures int) time.Duration {
+	if failures < loginLockThreshold {
+		return 0
+	}
+	lock := loginLockBase
+	for i := loginLockThreshold; i < failures && lock < loginLockMax; i++ {
+		lock *= 2
+	}
+	return min(lock, loginLockMax)
+}
+
 func generateJWT(user *model.User, cfg *config.Config) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": user.ID,
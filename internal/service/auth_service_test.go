@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"quickbite/config"
+	"quickbite/internal/apperr"
+	"quickbite/internal/model"
+	"quickbite/internal/repository/memory"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLoginNormalizesEmail(t *testing.T) {
+	ctx := context.Background()
+	stores := memory.NewStores()
+	auth := NewAuthService(stores.Users, stores.LoginAttempts, &config.Config{JWTSecret: "test-secret"})
+
+	if _, err := auth.Register(ctx, &model.RegisterRequest{
+		Name:     "Asha",
+		Email:    "Asha@Example.com",
+		Password: "Correct-Horse-42",
+	}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	resp, err := auth.Login(ctx, &model.LoginRequest{Email: "  asha@EXAMPLE.com ", Password: "Correct-Horse-42"})
+	if err != nil {
+		t.Fatalf("login with the email in another case: %v", err)
+	}
+	if resp.User.Email != "asha@example.com" {
+		t.Errorf("stored email = %q, want it lower-cased", resp.User.Email)
+	}
+
+	_, err = auth.Login(ctx, &model.LoginRequest{Email: "asha@example.com", Password: "wrong"})
+	wantKind(t, err, apperr.KindUnauthorized)
+	_, err = auth.Login(ctx, &model.LoginRequest{Email: "nobody@example.com", Password: "Correct-Horse-42"})
+	wantKind(t, err, apperr.KindUnauthorized)
+}
+
+func TestDummyPasswordHash(t *testing.T) {
+	// An unknown email only takes as long as a wrong password if the dummy
+	// hash is valid and as costly as a real one
+	cost, err := bcrypt.Cost([]byte(dummyPasswordHash))
+	if err != nil {
+		t.Fatalf("dummy hash: %v", err)
+	}
+	if cost != bcrypt.DefaultCost {
+		t.Errorf("dummy hash cost = %d, want %d", cost, bcrypt.DefaultCost)
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+
+	"quickbite/internal/apperr"
+	"quickbite/internal/authz"
+	"quickbite/internal/model"
+	"quickbite/internal/repository"
+)
+
+// Authorizer decides what a user may do at a restaurant from their
+// membership there. Services call it instead of comparing owner ids.
+type Authorizer struct {
+	members repository.MemberStore
+}
+
+func NewAuthorizer(members repository.MemberStore) *Authorizer {
+	return &Authorizer{members: members}
+}
+
+// Require returns nil if userID's role at restaurantID grants p, and a
+// forbidden error otherwise
+func (a *Authorizer) Require(ctx context.Context, restaurantID, userID string, p authz.Permission) error {
+	role, err := a.Role(ctx, restaurantID, userID)
+	if err != nil {
+		return err
+	}
+	if role == "" {
+		return apperr.Forbidden("you don't work at this restaurant")
+	}
+	if !role.Can(p) {
+		return apperr.Forbidden("your role can't " + p.Describe())
+	}
+	return nil
+}
+
+// Role returns userID's role at restaurantID, or "" if they have none
+func (a *Authorizer) Role(ctx context.Context, restaurantID, userID string) (authz.Role, error) {
+	member, err := a.members.GetMember(ctx, restaurantID, userID)
+	if repository.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", storeErr(err, "failed to check permissions")
+	}
+	return authz.Role(member.Role), nil
+}
+
+// RestaurantsWhere lists the restaurants, deleted ones included, where
+// userID's role grants p
+func (a *Authorizer) RestaurantsWhere(ctx context.Context, userID string, p authz.Permission) ([]string, error) {
+	members, err := a.members.GetMembershipsByUser(ctx, userID)
+	if err != nil {
+		return nil, storeErr(err, "failed to check permissions")
+	}
+
+	var ids []string
+	for _, m := range members {
+		if authz.Role(m.Role).Can(p) {
+			ids = append(ids, m.RestaurantID)
+		}
+	}
+	return ids, nil
+}
+
+// RequireBrandOwner returns nil if userID owns the brand. Brands have no
+// staff of their own; people work at the outlets.
+func (a *Authorizer) RequireBrandOwner(brand *model.Brand, userID string) error {
+	if brand.OwnerID != userID {
+		return apperr.Forbidden("you don't own this brand")
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"testing"
+
+	"quickbite/internal/apperr"
+	"quickbite/internal/authz"
+	"quickbite/internal/model"
+)
+
+func TestAuthorizerRequire(t *testing.T) {
+	f := newFixture(t)
+	f.addMember("manager-1", "manager")
+	f.addMember("cook-1", "kitchen")
+
+	tests := []struct {
+		userID string
+		perm   authz.Permission
+		allow  bool
+	}{
+		{f.ownerID, authz.ManageMembers, true},
+		{"manager-1", authz.EditMenu, true},
+		{"manager-1", authz.ManageMembers, false},
+		{"cook-1", authz.UpdateOrderStatus, true},
+		{"cook-1", authz.EditMenu, false},
+		{"stranger-1", authz.ViewOrders, false},
+	}
+	for _, tt := range tests {
+		err := f.authz.Require(f.ctx, f.restaurant.ID, tt.userID, tt.perm)
+		if tt.allow {
+			if err != nil {
+				t.Errorf("%s %s: %v, want allowed", tt.userID, tt.perm, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s %s: allowed, want forbidden", tt.userID, tt.perm)
+			continue
+		}
+		wantKind(t, err, apperr.KindForbidden)
+	}
+}
+
+func TestMenuEditsNeedEditMenu(t *testing.T) {
+	f := newFixture(t)
+	f.addMember("manager-1", "manager")
+	f.addMember("cook-1", "kitchen")
+	req := &model.CreateMenuItemRequest{CategoryID: f.category.ID, Name: "Dosa", Price: 80}
+
+	if _, err := f.menu.CreateMenuItem(f.ctx, req, "manager-1"); err != nil {
+		t.Errorf("manager adding an item: %v", err)
+	}
+	for _, userID := range []string{"cook-1", "stranger-1"} {
+		_, err := f.menu.CreateMenuItem(f.ctx, req, userID)
+		wantKind(t, err, apperr.KindForbidden)
+	}
+}
+
+func TestRestaurantStaffSeeOrdersButNotOthers(t *testing.T) {
+	f := newFixture(t)
+	f.addMember("cook-1", "kitchen")
+	item := f.item("Thali", nil)
+	order, err := f.order("customer-1", item, 1)
+	if err != nil {
+		t.Fatalf("order: %v", err)
+	}
+
+	// Staff see the order, but not the code the customer gives at the door
+	seen, err := f.orders.GetOrderByID(f.ctx, order.ID, "cook-1")
+	if err != nil {
+		t.Fatalf("staff reading the order: %v", err)
+	}
+	if seen.DeliveryCode != "" {
+		t.Errorf("staff can see the delivery code")
+	}
+
+	_, err = f.orders.GetOrderByID(f.ctx, order.ID, "customer-2")
+	wantKind(t, err, apperr.KindForbidden)
+
+	_, err = f.orders.GetRestaurantOrders(f.ctx, f.restaurant.ID, "customer-1")
+	wantKind(t, err, apperr.KindForbidden)
+}
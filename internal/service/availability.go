@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"quickbite/internal/apperr"
+	"quickbite/internal/model"
+	"quickbite/internal/repository"
+	"quickbite/internal/validate"
+)
+
+// restaurantNow returns the current time in the restaurant's timezone
+func restaurantNow(restaurant *model.Restaurant) time.Time {
+	loc, err := time.LoadLocation(restaurant.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return time.Now().In(loc)
+}
+
+// isScheduledNow reports whether t falls inside any of the windows.
+// No windows means no restriction.
+func isScheduledNow(windows []model.AvailabilityWindow, t time.Time) bool {
+	if len(windows) == 0 {
+		return true
+	}
+	for _, w := range windows {
+		if windowContains(w, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func windowContains(w model.AvailabilityWindow, t time.Time) bool {
+	start, err := time.Parse("15:04", w.StartTime)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse("15:04", w.EndTime)
+	if err != nil {
+		return false
+	}
+
+	startMin := start.Hour()*60 + start.Minute()
+	endMin := end.Hour()*60 + end.Minute()
+	nowMin := t.Hour()*60 + t.Minute()
+	today := int(t.Weekday())
+
+	if startMin < endMin {
+		return hasDay(w.Days, today) && nowMin >= startMin && nowMin < endMin
+	}
+
+	// Overnight window: the evening part belongs to today,
+	// the early-morning part to yesterday's window
+	if hasDay(w.Days, today) && nowMin >= startMin {
+		return true
+	}
+	yesterday := (today + 6) % 7
+	return hasDay(w.Days, yesterday) && nowMin < endMin
+}
+
+func hasDay(days []int, day int) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// validateSchedule adds the cross-field rules struct tags can't express
+func validateSchedule(req *model.UpdateScheduleRequest) error {
+	if err := validate.Struct(req); err != nil {
+		return err
+	}
+
+	var fields []apperr.FieldError
+	for i, w := range req.Windows {
+		path := fmt.Sprintf("windows[%d]", i)
+
+		seen := make(map[int]bool)
+		for _, d := range w.Days {
+			if seen[d] {
+				fields = append(fields, apperr.FieldError{Field: path + ".days", Message: "must not repeat within a window"})
+				break
+			}
+			seen[d] = true
+		}
+		if w.StartTime == w.EndTime {
+			fields = append(fields, apperr.FieldError{Field: path + ".end_time", Message: "must differ from start_time"})
+		}
+	}
+
+	if len(fields) > 0 {
+		return apperr.Validation("schedule has invalid windows", fields...)
+	}
+	return nil
+}
+
+// isMenuItemScheduledNow checks both the item's and its category's windows
+func isMenuItemScheduledNow(ctx context.Context, menu repository.MenuStore, item *model.MenuItem, category *model.MenuCategory, now time.Time) (bool, error) {
+	categoryWindows, err := menu.GetWindowsByCategories(ctx, []string{category.ID})
+	if err != nil {
+		return false, err
+	}
+	if !isScheduledNow(categoryWindows[category.ID], now) {
+		return false, nil
+	}
+
+	itemWindows, err := menu.GetWindowsByMenuItems(ctx, []string{item.ID})
+	if err != nil {
+		return false, err
+	}
+	return isScheduledNow(itemWindows[item.ID], now), nil
+}
@@ -0,0 +1,525 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"quickbite/internal/apperr"
+	"quickbite/internal/authz"
+	"quickbite/internal/model"
+	"quickbite/internal/repository"
+	"quickbite/internal/tracing"
+	"quickbite/internal/validate"
+)
+
+const (
+	staleBrand     = "brand was changed by another request, reload and try again"
+	staleBrandItem = "brand menu item was changed by another request, reload and try again"
+)
+
+// BrandService runs restaurant chains: a brand owns a master menu that its
+// outlets sell from, each outlet able to change the price and availability
+// of master items for itself
+type BrandService struct {
+	brands      repository.BrandStore
+	restaurants repository.RestaurantStore
+	authz       *Authorizer
+}
+
+func NewBrandService(brands repository.BrandStore, restaurants repository.RestaurantStore, authorizer *Authorizer) *BrandService {
+	return &BrandService{brands: brands, restaurants: restaurants, authz: authorizer}
+}
+
+func (s *BrandService) CreateBrand(ctx context.Context, req *model.CreateBrandRequest, userID string) (*model.Brand, error) {
+	ctx, span := tracing.Start(ctx, "BrandService.CreateBrand")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	brand := &model.Brand{
+		OwnerID:     userID,
+		Name:        req.Name,
+		Description: req.Description,
+		LogoURL:     req.LogoURL,
+	}
+	if err := s.brands.CreateBrand(ctx, brand); err != nil {
+		return nil, storeErr(err, "failed to create brand")
+	}
+	return brand, nil
+}
+
+// GetBrandByID returns the brand with its live outlets
+func (s *BrandService) GetBrandByID(ctx context.Context, id string) (*model.Brand, error) {
+	ctx, span := tracing.Start(ctx, "BrandService.GetBrandByID")
+	defer span.End()
+
+	brand, err := s.brand(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	outlets, err := s.brands.GetOutlets(ctx, id)
+	if err != nil {
+		return nil, storeErr(err, "failed to fetch outlets")
+	}
+	brand.Outlets = outlets
+	return brand, nil
+}
+
+func (s *BrandService) GetMyBrands(ctx context.Context, userID string) ([]model.Brand, error) {
+	ctx, span := tracing.Start(ctx, "BrandService.GetMyBrands")
+	defer span.End()
+
+	brands, err := s.brands.GetBrandsByOwner(ctx, userID)
+	if err != nil {
+		return nil, storeErr(err, "failed to fetch brands")
+	}
+	return brands, nil
+}
+
+// UpdateBrand replaces the brand's details. version is the one the caller
+// last read; 0 skips the check.
+func (s *BrandService) UpdateBrand(ctx context.Context, id string, version int, req *model.UpdateBrandRequest, userID string) (*model.Brand, error) {
+	ctx, span := tracing.Start(ctx, "BrandService.UpdateBrand")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	brand, err := s.ownedBrand(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !versionMatches(brand.Version, version) {
+		return nil, apperr.PreconditionFailed(staleBrand)
+	}
+
+	if err := s.brands.UpdateBrand(ctx, id, version, req); err != nil {
+		return nil, staleErr(err, staleBrand)
+	}
+	return s.brand(ctx, id)
+}
+
+// ====== OUTLETS ======
+
+// AttachOutlet adds a restaurant to the brand and puts the master menu on
+// it. The caller must own both: the brand, and the restaurant's owner role.
+func (s *BrandService) AttachOutlet(ctx context.Context, brandID string, req *model.AttachOutletRequest, userID string) (*model.Restaurant, error) {
+	ctx, span := tracing.Start(ctx, "BrandService.AttachOutlet")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.ownedBrand(ctx, brandID, userID); err != nil {
+		return nil, err
+	}
+
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, req.RestaurantID)
+	if err != nil {
+		return nil, parentErr(err, "restaurant not found")
+	}
+	if err := s.authz.Require(ctx, restaurant.ID, userID, authz.ManageBrand); err != nil {
+		return nil, err
+	}
+	if restaurant.BrandID == brandID {
+		return restaurant, nil
+	}
+
+	if err := s.brands.AttachOutlet(ctx, brandID, restaurant.ID); err != nil {
+		if errors.Is(err, repository.ErrStale) {
+			return nil, apperr.Conflict("restaurant already belongs to a brand")
+		}
+		return nil, storeErr(err, "failed to add outlet")
+	}
+
+	if err := s.sync(ctx, brandID, restaurant.ID); err != nil {
+		return nil, err
+	}
+
+	restaurant, err = s.restaurants.GetRestaurantByID(ctx, restaurant.ID)
+	if err != nil {
+		return nil, lookupErr(err, "restaurant not found")
+	}
+	return restaurant, nil
+}
+
+// DetachOutlet takes a restaurant out of the brand, leaving it the master
+// menu items it had as its own. Either the brand owner or the restaurant's
+// owner may do it.
+func (s *BrandService) DetachOutlet(ctx context.Context, brandID, restaurantID string, userID string) error {
+	ctx, span := tracing.Start(ctx, "BrandService.DetachOutlet")
+	defer span.End()
+
+	brand, err := s.brand(ctx, brandID)
+	if err != nil {
+		return err
+	}
+	if s.authz.RequireBrandOwner(brand, userID) != nil {
+		if err := s.authz.Require(ctx, restaurantID, userID, authz.ManageBrand); err != nil {
+			return err
+		}
+	}
+
+	if err := s.brands.DetachOutlet(ctx, brandID, restaurantID); err != nil {
+		if errors.Is(err, repository.ErrStale) {
+			return apperr.NotFound("outlet not found")
+		}
+		return storeErr(err, "failed to remove outlet")
+	}
+	return nil
+}
+
+// SyncBrand rewrites every outlet's copy of the master menu. Edits sync on
+// their own; this is for retrying one whose sync failed.
+func (s *BrandService) SyncBrand(ctx context.Context, brandID string, userID string) error {
+	ctx, span := tracing.Start(ctx, "BrandService.SyncBrand")
+	defer span.End()
+
+	if _, err := s.ownedBrand(ctx, brandID, userID); err != nil {
+		return err
+	}
+	return s.sync(ctx, brandID, "")
+}
+
+// ====== MASTER MENU ======
+
+func (s *BrandService) GetBrandMenu(ctx context.Context, brandID string) ([]model.BrandCategory, error) {
+	ctx, span := tracing.Start(ctx, "BrandService.GetBrandMenu")
+	defer span.End()
+
+	if _, err := s.brand(ctx, brandID); err != nil {
+		return nil, err
+	}
+
+	menu, err := s.brands.GetBrandMenu(ctx, brandID)
+	if err != nil {
+		return nil, storeErr(err, "failed to fetch brand menu")
+	}
+	return menu, nil
+}
+
+func (s *BrandService) CreateCategory(ctx context.Context, brandID string, req *model.CreateBrandCategoryRequest, userID string) (*model.BrandCategory, error) {
+	ctx, span := tracing.Start(ctx, "BrandService.CreateCategory")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.ownedBrand(ctx, brandID, userID); err != nil {
+		return nil, err
+	}
+
+	category := &model.BrandCategory{
+		BrandID:      brandID,
+		Name:         req.Name,
+		DisplayOrder: req.DisplayOrder,
+	}
+	if err := s.brands.CreateBrandCategory(ctx, category); err != nil {
+		return nil, storeErr(err, "failed to create category")
+	}
+
+	if err := s.sync(ctx, brandID, ""); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// DeleteCategory removes a master category and its items; the outlets'
+// copies go to their trash
+func (s *BrandService) DeleteCategory(ctx context.Context, brandID, id string, userID string) error {
+	ctx, span := tracing.Start(ctx, "BrandService.DeleteCategory")
+	defer span.End()
+
+	if _, err := s.ownedBrand(ctx, brandID, userID); err != nil {
+		return err
+	}
+
+	if _, err := s.category(ctx, brandID, id); err != nil {
+		return err
+	}
+
+	if err := s.brands.DeleteBrandCategory(ctx, id); err != nil {
+		return storeErr(err, "failed to delete category")
+	}
+	return nil
+}
+
+func (s *BrandService) CreateMenuItem(ctx context.Context, brandID string, req *model.CreateBrandMenuItemRequest, userID string) (*model.BrandMenuItem, error) {
+	ctx, span := tracing.Start(ctx, "BrandService.CreateMenuItem")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.ownedBrand(ctx, brandID, userID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.category(ctx, brandID, req.CategoryID); err != nil {
+		return nil, err
+	}
+
+	item := &model.BrandMenuItem{
+		CategoryID:  req.CategoryID,
+		Name:        req.Name,
+		Description: req.Description,
+		Price:       req.Price,
+		ImageURL:    req.ImageURL,
+		IsAvailable: true,
+		IsVeg:       req.IsVeg,
+	}
+	if err := s.brands.CreateBrandItem(ctx, item); err != nil {
+		return nil, storeErr(err, "failed to create menu item")
+	}
+
+	if err := s.sync(ctx, brandID, ""); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// UpdateMenuItem replaces a master item and passes the change on to every
+// outlet, under their overrides
+func (s *BrandService) UpdateMenuItem(ctx context.Context, brandID, id string, version int, req *model.UpdateBrandMenuItemRequest, userID string) (*model.BrandMenuItem, error) {
+	ctx, span := tracing.Start(ctx, "BrandService.UpdateMenuItem")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.ownedBrand(ctx, brandID, userID); err != nil {
+		return nil, err
+	}
+
+	item, err := s.menuItem(ctx, brandID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !versionMatches(item.Version, version) {
+		return nil, apperr.PreconditionFailed(staleBrandItem)
+	}
+
+	if req.CategoryID != item.CategoryID {
+		if _, err := s.category(ctx, brandID, req.CategoryID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.brands.UpdateBrandItem(ctx, id, version, req); err != nil {
+		return nil, staleErr(err, staleBrandItem)
+	}
+
+	if err := s.sync(ctx, brandID, ""); err != nil {
+		return nil, err
+	}
+
+	item, err = s.brands.GetBrandItemByID(ctx, id)
+	if err != nil {
+		return nil, lookupErr(err, "menu item not found")
+	}
+	return item, nil
+}
+
+// DeleteMenuItem removes a master item; the outlets' copies go to their trash
+func (s *BrandService) DeleteMenuItem(ctx context.Context, brandID, id string, userID string) error {
+	ctx, span := tracing.Start(ctx, "BrandService.DeleteMenuItem")
+	defer span.End()
+
+	if _, err := s.ownedBrand(ctx, brandID, userID); err != nil {
+		return err
+	}
+
+	if _, err := s.menuItem(ctx, brandID, id); err != nil {
+		return err
+	}
+
+	if err := s.brands.DeleteBrandItem(ctx, id); err != nil {
+		return storeErr(err, "failed to delete menu item")
+	}
+	return nil
+}
+
+// ====== OVERRIDES ======
+
+// GetOverrides lists what an outlet changed about the master menu
+func (s *BrandService) GetOverrides(ctx context.Context, restaurantID string, userID string) ([]model.OutletOverride, error) {
+	ctx, span := tracing.Start(ctx, "BrandService.GetOverrides")
+	defer span.End()
+
+	if _, err := s.outlet(ctx, restaurantID, userID); err != nil {
+		return nil, err
+	}
+
+	overrides, err := s.brands.GetOutletOverrides(ctx, restaurantID)
+	if err != nil {
+		return nil, storeErr(err, "failed to fetch overrides")
+	}
+	return overrides, nil
+}
+
+// SetOverride sets the outlet's own price and/or availability for a
+// master item. Leaving both out removes the override.
+func (s *BrandService) SetOverride(ctx context.Context, restaurantID, brandItemID string, req *model.SetOverrideRequest, userID string) (*model.OutletOverride, error) {
+	ctx, span := tracing.Start(ctx, "BrandService.SetOverride")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	restaurant, err := s.outlet(ctx, restaurantID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.menuItem(ctx, restaurant.BrandID, brandItemID); err != nil {
+		return nil, err
+	}
+
+	override := &model.OutletOverride{
+		RestaurantID: restaurantID,
+		BrandItemID:  brandItemID,
+		Price:        req.Price,
+		IsAvailable:  req.IsAvailable,
+	}
+	if req.Price == nil && req.IsAvailable == nil {
+		err = s.brands.DeleteOutletOverride(ctx, restaurantID, brandItemID)
+		override.UpdatedAt = time.Now()
+	} else {
+		err = s.brands.SaveOutletOverride(ctx, override)
+	}
+	if err != nil {
+		return nil, storeErr(err, "failed to save override")
+	}
+
+	if err := s.sync(ctx, restaurant.BrandID, restaurantID); err != nil {
+		return nil, err
+	}
+	return override, nil
+}
+
+// DeleteOverride puts the master item back to the brand's price and availability
+func (s *BrandService) DeleteOverride(ctx context.Context, restaurantID, brandItemID string, userID string) error {
+	ctx, span := tracing.Start(ctx, "BrandService.DeleteOverride")
+	defer span.End()
+
+	restaurant, err := s.outlet(ctx, restaurantID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.brands.DeleteOutletOverride(ctx, restaurantID, brandItemID); err != nil {
+		return storeErr(err, "failed to delete override")
+	}
+	return s.sync(ctx, restaurant.BrandID, restaurantID)
+}
+
+// ====== DASHBOARD ======
+
+// GetDashboard totals orders placed across the brand's outlets in
+// [from, to), with a line per outlet
+func (s *BrandService) GetDashboard(ctx context.Context, brandID string, from, to *time.Time, userID string) (*model.BrandDashboard, error) {
+	ctx, span := tracing.Start(ctx, "BrandService.GetDashboard")
+	defer span.End()
+
+	if _, err := s.ownedBrand(ctx, brandID, userID); err != nil {
+		return nil, err
+	}
+
+	outlets, err := s.brands.GetOutletStats(ctx, brandID, from, to)
+	if err != nil {
+		return nil, storeErr(err, "failed to fetch dashboard")
+	}
+
+	dashboard := &model.BrandDashboard{BrandID: brandID, From: from, To: to, Outlets: outlets}
+	for _, o := range outlets {
+		dashboard.Orders += o.Orders
+		dashboard.Revenue += o.Revenue
+		dashboard.ActiveOrders += o.ActiveOrders
+		dashboard.CancelledOrders += o.CancelledOrders
+	}
+	return dashboard, nil
+}
+
+// ====== HELPERS ======
+
+func (s *BrandService) brand(ctx context.Context, id string) (*model.Brand, error) {
+	brand, err := s.brands.GetBrandByID(ctx, id)
+	if err != nil {
+		return nil, lookupErr(err, "brand not found")
+	}
+	return brand, nil
+}
+
+func (s *BrandService) ownedBrand(ctx context.Context, id string, userID string) (*model.Brand, error) {
+	brand, err := s.brand(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authz.RequireBrandOwner(brand, userID); err != nil {
+		return nil, err
+	}
+	return brand, nil
+}
+
+// category loads a master category, treating one of another brand as missing
+func (s *BrandService) category(ctx context.Context, brandID, id string) (*model.BrandCategory, error) {
+	category, err := s.brands.GetBrandCategoryByID(ctx, id)
+	if err != nil {
+		return nil, lookupErr(err, "category not found")
+	}
+	if category.BrandID != brandID {
+		return nil, apperr.NotFound("category not found")
+	}
+	return category, nil
+}
+
+// menuItem loads a master item, treating one of another brand as missing
+func (s *BrandService) menuItem(ctx context.Context, brandID, id string) (*model.BrandMenuItem, error) {
+	item, err := s.brands.GetBrandItemByID(ctx, id)
+	if err != nil {
+		return nil, lookupErr(err, "menu item not found")
+	}
+	if _, err := s.category(ctx, brandID, item.CategoryID); err != nil {
+		return nil, apperr.NotFound("menu item not found")
+	}
+	return item, nil
+}
+
+// outlet loads a restaurant that belongs to a brand and checks userID may
+// edit its menu
+func (s *BrandService) outlet(ctx context.Context, restaurantID string, userID string) (*model.Restaurant, error) {
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, restaurantID)
+	if err != nil {
+		return nil, lookupErr(err, "restaurant not found")
+	}
+	if err := s.authz.Require(ctx, restaurant.ID, userID, authz.EditMenu); err != nil {
+		return nil, err
+	}
+	if restaurant.BrandID == "" {
+		return nil, apperr.Conflict("restaurant isn't part of a brand")
+	}
+	return restaurant, nil
+}
+
+// sync pushes the master menu out to the outlets. The change that called it
+// is already saved, so a failure says how to finish the job.
+func (s *BrandService) sync(ctx context.Context, brandID, restaurantID string) error {
+	if err := s.brands.SyncBrandMenu(ctx, brandID, restaurantID); err != nil {
+		if ctxErr := repository.ContextError(err); ctxErr != nil {
+			return ctxErr
+		}
+		return apperr.Internal("changes saved but the outlets' menus could not be updated; sync the brand to retry", err)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"testing"
+
+	"quickbite/internal/apperr"
+	"quickbite/internal/model"
+)
+
+// brandCopy makes the fixture restaurant an outlet of a new brand with
+// one master item and returns the outlet's copy of it
+func brandCopy(t *testing.T, f *fixture) (*BrandService, *model.Brand, *model.MenuItem) {
+	t.Helper()
+
+	brands := NewBrandService(f.stores.Brands, f.stores.Restaurants, f.authz)
+	brand, err := brands.CreateBrand(f.ctx, &model.CreateBrandRequest{Name: "Chain"}, f.ownerID)
+	if err != nil {
+		t.Fatalf("create brand: %v", err)
+	}
+	category, err := brands.CreateCategory(f.ctx, brand.ID, &model.CreateBrandCategoryRequest{Name: "Biryani"}, f.ownerID)
+	if err != nil {
+		t.Fatalf("create brand category: %v", err)
+	}
+	master, err := brands.CreateMenuItem(f.ctx, brand.ID, &model.CreateBrandMenuItemRequest{
+		CategoryID: category.ID,
+		Name:       "Hyderabadi Biryani",
+		Price:      250,
+	}, f.ownerID)
+	if err != nil {
+		t.Fatalf("create brand item: %v", err)
+	}
+	if _, err := brands.AttachOutlet(f.ctx, brand.ID, &model.AttachOutletRequest{RestaurantID: f.restaurant.ID}, f.ownerID); err != nil {
+		t.Fatalf("attach outlet: %v", err)
+	}
+
+	categories, err := f.stores.Menu.GetCategoriesByRestaurant(f.ctx, f.restaurant.ID)
+	if err != nil {
+		t.Fatalf("list categories: %v", err)
+	}
+	for _, c := range categories {
+		items, err := f.stores.Menu.GetMenuItemsByCategory(f.ctx, c.ID)
+		if err != nil {
+			t.Fatalf("list items: %v", err)
+		}
+		for _, item := range items {
+			if item.BrandItemID == master.ID {
+				return brands, brand, &item
+			}
+		}
+	}
+	t.Fatal("outlet has no copy of the brand item")
+	return nil, nil, nil
+}
+
+func TestBrandItemFieldsBelongToTheBrand(t *testing.T) {
+	f := newFixture(t)
+	_, _, item := brandCopy(t, f)
+
+	err := f.menu.UpdateMenuItem(f.ctx, item.ID, 0, &model.UpdateMenuItemRequest{Name: "Biryani", Price: 200, IsAvailable: true}, f.ownerID)
+	wantKind(t, err, apperr.KindConflict)
+	_, err = f.menu.PatchMenuItem(f.ctx, item.ID, 0, &model.PatchMenuItemRequest{Price: model.Set(200.0)}, f.ownerID)
+	wantKind(t, err, apperr.KindConflict)
+}
+
+func TestOutletOwnsBrandItemStockAndSchedule(t *testing.T) {
+	f := newFixture(t)
+	brands, brand, item := brandCopy(t, f)
+
+	stock := &model.UpdateStockRequest{StockQuantity: intPtr(5), DailyStock: intPtr(20)}
+	if err := f.menu.UpdateMenuItemStock(f.ctx, item.ID, stock, f.ownerID); err != nil {
+		t.Fatalf("set stock: %v", err)
+	}
+	if err := f.menu.UpdateMenuItemSchedule(f.ctx, item.ID, offNow(), f.ownerID); err != nil {
+		t.Fatalf("set schedule: %v", err)
+	}
+
+	// A sync rewrites what the brand owns and leaves the rest alone
+	if err := brands.SyncBrand(f.ctx, brand.ID, f.ownerID); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	got := f.reload(item.ID)
+	if got.StockQuantity == nil || *got.StockQuantity != 5 || got.DailyStock == nil || *got.DailyStock != 20 {
+		t.Errorf("stock after sync = %v/%v, want 5/20", got.StockQuantity, got.DailyStock)
+	}
+	windows, err := f.stores.Menu.GetWindowsByMenuItems(f.ctx, []string{item.ID})
+	if err != nil {
+		t.Fatalf("load schedule: %v", err)
+	}
+	if len(windows[item.ID]) != 1 {
+		t.Errorf("schedule after sync = %v, want the outlet's window", windows[item.ID])
+	}
+}
+
+func TestOutletCanDropBrandItem(t *testing.T) {
+	f := newFixture(t)
+	brands, brand, item := brandCopy(t, f)
+
+	if err := f.menu.DeleteMenuItem(f.ctx, item.ID, f.ownerID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := brands.SyncBrand(f.ctx, brand.ID, f.ownerID); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	if _, err := f.stores.Menu.GetMenuItemByID(f.ctx, item.ID); err == nil {
+		t.Error("sync brought the deleted item back")
+	}
+	categories, _ := f.stores.Menu.GetCategoriesByRestaurant(f.ctx, f.restaurant.ID)
+	for _, c := range categories {
+		items, _ := f.stores.Menu.GetMenuItemsByCategory(f.ctx, c.ID)
+		for _, other := range items {
+			if other.BrandItemID == item.BrandItemID {
+				t.Errorf("sync added a fresh copy %s of the deleted item", other.ID)
+			}
+		}
+	}
+}
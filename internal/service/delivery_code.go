@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"quickbite/internal/apperr"
+	"quickbite/internal/model"
+	"quickbite/internal/repository"
+)
+
+// maxDeliveryCodeAttempts is how many times an order's delivery code can be
+// entered before the customer has to issue a new one
+const maxDeliveryCodeAttempts = 5
+
+// verifyDeliveryCode checks the code entered to mark order delivered. Every
+// entry uses up an attempt and lands in the audit trail, right or wrong.
+func verifyDeliveryCode(ctx context.Context, orders repository.OrderStore, order *model.Order, code, userID string) error {
+	if code == "" {
+		return apperr.InvalidField("delivery_code", "is required to mark an order delivered")
+	}
+	if order.DeliveryCode == "" {
+		return apperr.Conflict("order has no delivery code; the customer needs to issue one")
+	}
+
+	if err := orders.UseDeliveryCodeAttempt(ctx, order.ID, maxDeliveryCodeAttempts); err != nil {
+		if errors.Is(err, repository.ErrStale) {
+			return apperr.Conflict("too many wrong delivery codes; the customer needs to issue a new one")
+		}
+		return storeErr(err, "failed to check delivery code")
+	}
+
+	matches := subtle.ConstantTimeCompare([]byte(code), []byte(order.DeliveryCode)) == 1
+	event := &model.DeliveryCodeEvent{OrderID: order.ID, UserID: userID, Action: "verified"}
+	if !matches {
+		event.Action = "failed"
+	}
+	if err := orders.LogDeliveryCodeEvent(ctx, event); err != nil {
+		return storeErr(err, "failed to check delivery code")
+	}
+
+	if !matches {
+		return apperr.InvalidField("delivery_code", "doesn't match the customer's delivery code")
+	}
+	return nil
+}
+
+// newDeliveryCode returns a random 4-digit code
+func newDeliveryCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(10000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%04d", n.Int64()), nil
+}
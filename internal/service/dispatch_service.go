@@ -0,0 +1,353 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"quickbite/config"
+	"quickbite/internal/apperr"
+	"quickbite/internal/authz"
+	"quickbite/internal/model"
+	"quickbite/internal/repository"
+	"quickbite/internal/tracing"
+	"quickbite/internal/validate"
+)
+
+// dispatchBatch caps how many waiting orders one dispatch round offers out
+const dispatchBatch = 100
+
+// DispatchService gets ready orders to customers: it offers each one to
+// the nearest available delivery partner, re-offers it when they reject or
+// don't answer in time, and takes the partner through pickup and delivery
+type DispatchService struct {
+	dispatch    repository.DispatchStore
+	orders      repository.OrderStore
+	restaurants repository.RestaurantStore
+	authz       *Authorizer
+	events      OrderEvents
+	cfg         *config.Config
+}
+
+// NewDispatchService wires the stores; events may be nil
+func NewDispatchService(dispatch repository.DispatchStore, orders repository.OrderStore, restaurants repository.RestaurantStore, authorizer *Authorizer, events OrderEvents, cfg *config.Config) *DispatchService {
+	if events == nil {
+		events = NoopOrderEvents{}
+	}
+	return &DispatchService{
+		dispatch:    dispatch,
+		orders:      orders,
+		restaurants: restaurants,
+		authz:       authorizer,
+		events:      events,
+		cfg:         cfg,
+	}
+}
+
+// ====== PARTNERS ======
+
+// GetMyStatus returns the partner's availability; a partner who has never
+// gone online is offline
+func (s *DispatchService) GetMyStatus(ctx context.Context, userID string) (*model.DeliveryPartner, error) {
+	ctx, span := tracing.Start(ctx, "DispatchService.GetMyStatus")
+	defer span.End()
+
+	partner, err := s.dispatch.GetPartner(ctx, userID)
+	if repository.IsNotFound(err) {
+		return &model.DeliveryPartner{UserID: userID}, nil
+	}
+	if err != nil {
+		return nil, storeErr(err, "failed to fetch status")
+	}
+	return partner, nil
+}
+
+// SetStatus takes the partner online or offline. Going offline turns down
+// any offer they haven't answered so it goes to someone else straight away;
+// a delivery already accepted still has to be finished.
+func (s *DispatchService) SetStatus(ctx context.Context, req *model.UpdatePartnerStatusRequest, userID string) (*model.DeliveryPartner, error) {
+	ctx, span := tracing.Start(ctx, "DispatchService.SetStatus")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	partner, err := s.dispatch.SetPartnerOnline(ctx, userID, *req.Online)
+	if err != nil {
+		return nil, storeErr(err, "failed to update status")
+	}
+
+	if !partner.IsOnline {
+		tasks, err := s.dispatch.GetPartnerTasks(ctx, userID)
+		if err != nil {
+			return nil, storeErr(err, "failed to fetch offers")
+		}
+		for _, task := range tasks {
+			if task.Status != "offered" {
+				continue
+			}
+			// Losing the race to expiry or a response is fine: it's closed either way
+			if err := s.dispatch.RejectOffer(ctx, task.ID); err != nil && !errors.Is(err, repository.ErrStale) {
+				return nil, storeErr(err, "failed to decline offers")
+			}
+		}
+	}
+
+	return partner, nil
+}
+
+// UpdateLocation records where the partner is. Partners whose last ping is
+// older than PartnerLocationTTL aren't offered orders.
+func (s *DispatchService) UpdateLocation(ctx context.Context, req *model.LocationRequest, userID string) (*model.DeliveryPartner, error) {
+	ctx, span := tracing.Start(ctx, "DispatchService.UpdateLocation")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	partner, err := s.dispatch.UpdatePartnerLocation(ctx, userID, *req.Latitude, *req.Longitude)
+	if err != nil {
+		return nil, storeErr(err, "failed to update location")
+	}
+	return partner, nil
+}
+
+// GetMyTasks lists the partner's open offers and their delivery in progress
+func (s *DispatchService) GetMyTasks(ctx context.Context, userID string) ([]model.DeliveryTask, error) {
+	ctx, span := tracing.Start(ctx, "DispatchService.GetMyTasks")
+	defer span.End()
+
+	tasks, err := s.dispatch.GetPartnerTasks(ctx, userID)
+	if err != nil {
+		return nil, storeErr(err, "failed to fetch deliveries")
+	}
+	return tasks, nil
+}
+
+// ====== OFFERS ======
+
+// AcceptOffer makes the order the partner's delivery
+func (s *DispatchService) AcceptOffer(ctx context.Context, assignmentID string, userID string) (*model.DeliveryAssignment, error) {
+	ctx, span := tracing.Start(ctx, "DispatchService.AcceptOffer")
+	defer span.End()
+
+	if _, err := s.assignment(ctx, assignmentID, userID); err != nil {
+		return nil, err
+	}
+
+	if err := s.dispatch.AcceptOffer(ctx, assignmentID); err != nil {
+		return nil, offerErr(err, "failed to accept offer")
+	}
+
+	return s.assignment(ctx, assignmentID, userID)
+}
+
+// RejectOffer turns the order down for good; the next dispatch round
+// offers it to the next nearest partner
+func (s *DispatchService) RejectOffer(ctx context.Context, assignmentID string, userID string) error {
+	ctx, span := tracing.Start(ctx, "DispatchService.RejectOffer")
+	defer span.End()
+
+	if _, err := s.assignment(ctx, assignmentID, userID); err != nil {
+		return err
+	}
+
+	if err := s.dispatch.RejectOffer(ctx, assignmentID); err != nil {
+		return offerErr(err, "failed to reject offer")
+	}
+	return nil
+}
+
+// ====== DELIVERY ======
+
+// PickUp is the partner collecting the order from the restaurant, which
+// sends it out for delivery
+func (s *DispatchService) PickUp(ctx context.Context, assignmentID string, userID string) error {
+	ctx, span := tracing.Start(ctx, "DispatchService.PickUp")
+	defer span.End()
+
+	assignment, order, err := s.delivery(ctx, assignmentID, userID)
+	if err != nil {
+		return err
+	}
+	if assignment.PickedUpAt != nil {
+		return apperr.Conflict("order has already been picked up")
+	}
+	if order.Status != "ready" {
+		return apperr.Conflict("order isn't ready for pickup")
+	}
+
+	if err := s.dispatch.PickUp(ctx, assignmentID); err != nil {
+		return statusErr(err, "failed to record pickup")
+	}
+	s.events.OrderStatusChanged(ctx, order, "out_for_delivery")
+	return nil
+}
+
+// CompleteDelivery marks the order delivered. The partner must enter the
+// order's delivery code, which the customer gives them at the door.
+func (s *DispatchService) CompleteDelivery(ctx context.Context, assignmentID string, req *model.CompleteDeliveryRequest, userID string) error {
+	ctx, span := tracing.Start(ctx, "DispatchService.CompleteDelivery")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return err
+	}
+
+	assignment, order, err := s.delivery(ctx, assignmentID, userID)
+	if err != nil {
+		return err
+	}
+	if assignment.PickedUpAt == nil {
+		return apperr.Conflict("order hasn't been picked up yet")
+	}
+	if err := verifyDeliveryCode(ctx, s.orders, order, req.DeliveryCode, userID); err != nil {
+		return err
+	}
+
+	if err := s.dispatch.CompleteDelivery(ctx, assignmentID); err != nil {
+		return statusErr(err, "failed to record delivery")
+	}
+	s.events.OrderStatusChanged(ctx, order, "delivered")
+	return nil
+}
+
+// GetOrderDelivery shows who is delivering an order to the customer who
+// placed it or staff of the restaurant
+func (s *DispatchService) GetOrderDelivery(ctx context.Context, orderID string, userID string) (*model.OrderDelivery, error) {
+	ctx, span := tracing.Start(ctx, "DispatchService.GetOrderDelivery")
+	defer span.End()
+
+	order, err := s.orders.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, lookupErr(err, "order not found")
+	}
+	if order.UserID != userID {
+		if err := s.authz.Require(ctx, order.RestaurantID, userID, authz.ViewOrders); err != nil {
+			return nil, apperr.Forbidden("you don't own this order")
+		}
+	}
+
+	delivery, err := s.dispatch.GetOrderDelivery(ctx, orderID)
+	if err != nil {
+		return nil, lookupErr(err, "no delivery partner has taken this order yet")
+	}
+	return delivery, nil
+}
+
+// SetRestaurantLocation places the restaurant on the map. Its orders are
+// only offered to partners once it has a location.
+func (s *DispatchService) SetRestaurantLocation(ctx context.Context, restaurantID string, req *model.LocationRequest, userID string) error {
+	ctx, span := tracing.Start(ctx, "DispatchService.SetRestaurantLocation")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return err
+	}
+
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, restaurantID)
+	if err != nil {
+		return lookupErr(err, "restaurant not found")
+	}
+	if err := s.authz.Require(ctx, restaurant.ID, userID, authz.EditRestaurant); err != nil {
+		return err
+	}
+
+	if err := s.dispatch.SetRestaurantLocation(ctx, restaurantID, *req.Latitude, *req.Longitude); err != nil {
+		return storeErr(err, "failed to update location")
+	}
+	return nil
+}
+
+// ====== ENGINE ======
+
+// Dispatch runs one round: it closes offers that timed out and
+// assignments whose order ended without the partner, then offers every
+// ready order still waiting to the nearest free partner who hasn't turned
+// it down, trying those who let it time out last. Orders nobody in range
+// can take wait for the next round.
+func (s *DispatchService) Dispatch(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "DispatchService.Dispatch")
+	defer span.End()
+
+	if _, err := s.dispatch.ReleaseStaleAssignments(ctx); err != nil {
+		return err
+	}
+
+	orders, err := s.dispatch.GetUndispatchedOrders(ctx, dispatchBatch)
+	if err != nil {
+		return err
+	}
+
+	offered := 0
+	maxKm := float64(s.cfg.DispatchRadiusKm)
+	for _, order := range orders {
+		partnerID, distance, err := s.dispatch.FindNearestPartner(ctx, order.ID, maxKm, s.cfg.PartnerLocationTTL)
+		if repository.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		offer := &model.DeliveryAssignment{
+			OrderID:    order.ID,
+			PartnerID:  partnerID,
+			DistanceKm: distance,
+			ExpiresAt:  time.Now().Add(s.cfg.OfferTimeout),
+		}
+		// Another instance got to the order or the partner first
+		if err := s.dispatch.CreateOffer(ctx, offer); errors.Is(err, repository.ErrStale) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		offered++
+	}
+
+	if offered > 0 {
+		slog.InfoContext(ctx, "offered orders to delivery partners", "orders", offered)
+	}
+	return nil
+}
+
+// assignment loads an assignment, which only its partner may act on
+func (s *DispatchService) assignment(ctx context.Context, id string, userID string) (*model.DeliveryAssignment, error) {
+	assignment, err := s.dispatch.GetAssignment(ctx, id)
+	if err != nil {
+		return nil, lookupErr(err, "delivery not found")
+	}
+	if assignment.PartnerID != userID {
+		return nil, apperr.Forbidden("this delivery isn't yours")
+	}
+	return assignment, nil
+}
+
+// delivery loads an accepted assignment and its order
+func (s *DispatchService) delivery(ctx context.Context, id string, userID string) (*model.DeliveryAssignment, *model.Order, error) {
+	assignment, err := s.assignment(ctx, id, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if assignment.Status != "accepted" {
+		return nil, nil, apperr.Conflict("delivery is " + assignment.Status)
+	}
+
+	order, err := s.orders.GetOrderByID(ctx, assignment.OrderID)
+	if err != nil {
+		return nil, nil, lookupErr(err, "order not found")
+	}
+	return assignment, order, nil
+}
+
+// offerErr is storeErr for answering an offer that expired or was already
+// answered
+func offerErr(err error, message string) error {
+	if errors.Is(err, repository.ErrStale) {
+		return apperr.Conflict("offer is no longer open")
+	}
+	return storeErr(err, message)
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"errors"
+
+	"quickbite/internal/apperr"
+	"quickbite/internal/repository"
+)
+
+// storeErr turns a failed store write into an internal error with a
+// user-facing message, except when the request was cancelled or timed out:
+// those pass through so the handler can answer 499/504 instead
+func storeErr(err error, message string) error {
+	if ctxErr := repository.ContextError(err); ctxErr != nil {
+		return ctxErr
+	}
+	return apperr.Internal(message, err)
+}
+
+// lookupErr is storeErr for reads by id: a missing row becomes not found
+// with message, anything else is internal
+func lookupErr(err error, message string) error {
+	if repository.IsNotFound(err) {
+		return apperr.NotFound(message)
+	}
+	return storeErr(err, "failed to load data")
+}
+
+// parentErr is lookupErr for the row something hangs off, where a missing
+// parent means the request conflicts with current state rather than naming
+// something that doesn't exist
+func parentErr(err error, message string) error {
+	if repository.IsNotFound(err) {
+		return apperr.Conflict(message)
+	}
+	return storeErr(err, "failed to load data")
+}
+
+// staleErr is storeErr for conditional writes: a version that moved on
+// underneath the caller becomes precondition failed with message
+func staleErr(err error, message string) error {
+	if errors.Is(err, repository.ErrStale) {
+		return apperr.PreconditionFailed(message)
+	}
+	return storeErr(err, "failed to save changes")
+}
+
+// versionMatches reports whether current is the version the caller expects;
+// expected 0 means they didn't say
+func versionMatches(current, expected int) bool {
+	return expected == 0 || current == expected
+}
@@ -0,0 +1,25 @@
+package service
+
+import (
+	"context"
+
+	"quickbite/internal/model"
+)
+
+// OrderEvents is told about order lifecycle changes after they are stored.
+// Implementations must be quick and must not fail the request.
+type OrderEvents interface {
+	OrderCreated(ctx context.Context, order *model.Order)
+	// OrderStatusChanged sees the order as it was before the change
+	OrderStatusChanged(ctx context.Context, order *model.Order, status string)
+	// OrderCancelled fires alongside OrderStatusChanged; by is "customer",
+	// "restaurant", or "timeout" when nobody accepted it in time
+	OrderCancelled(ctx context.Context, order *model.Order, by string)
+}
+
+// NoopOrderEvents ignores every event
+type NoopOrderEvents struct{}
+
+func (NoopOrderEvents) OrderCreated(context.Context, *model.Order)               {}
+func (NoopOrderEvents) OrderStatusChanged(context.Context, *model.Order, string) {}
+func (NoopOrderEvents) OrderCancelled(context.Context, *model.Order, string)     {}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"quickbite/internal/apperr"
+	"quickbite/internal/authz"
+	"quickbite/internal/model"
+	"quickbite/internal/repository"
+	"quickbite/internal/tracing"
+	"quickbite/internal/validate"
+)
+
+// defaultPrepTargetMinutes applies until a restaurant sets its own target
+const defaultPrepTargetMinutes = 20
+
+// Bumping moves a ticket one step along the kitchen's statuses; recalling
+// moves it one step back, for a ticket bumped by mistake
+var (
+	bumpTo   = map[string]string{"confirmed": "preparing", "preparing": "ready"}
+	recallTo = map[string]string{"ready": "preparing", "preparing": "confirmed"}
+)
+
+// KitchenService runs the kitchen display: the live board of orders the
+// kitchen is working on and the actions the tablets send back
+type KitchenService struct {
+	restaurants repository.RestaurantStore
+	orders      repository.OrderStore
+	kitchen     repository.KitchenStore
+	authz       *Authorizer
+	events      OrderEvents
+}
+
+// NewKitchenService wires the stores; events may be nil
+func NewKitchenService(restaurants repository.RestaurantStore, orders repository.OrderStore, kitchen repository.KitchenStore, authorizer *Authorizer, events OrderEvents) *KitchenService {
+	if events == nil {
+		events = NoopOrderEvents{}
+	}
+	return &KitchenService{restaurants: restaurants, orders: orders, kitchen: kitchen, authz: authorizer, events: events}
+}
+
+// GetBoard builds the restaurant's board for anyone who can see its orders
+func (s *KitchenService) GetBoard(ctx context.Context, restaurantID string, userID string) (*model.KitchenBoard, error) {
+	ctx, span := tracing.Start(ctx, "KitchenService.GetBoard")
+	defer span.End()
+
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, restaurantID)
+	if err != nil {
+		return nil, lookupErr(err, "restaurant not found")
+	}
+	if err := s.authz.Require(ctx, restaurant.ID, userID, authz.ViewOrders); err != nil {
+		return nil, err
+	}
+
+	target, err := s.prepTarget(ctx, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := s.kitchen.GetActiveOrders(ctx, restaurantID)
+	if err != nil {
+		return nil, storeErr(err, "failed to fetch orders")
+	}
+
+	board := &model.KitchenBoard{
+		RestaurantID:      restaurantID,
+		PrepTargetMinutes: target,
+		Confirmed:         []model.KitchenTicket{},
+		Preparing:         []model.KitchenTicket{},
+		Ready:             []model.KitchenTicket{},
+	}
+	now := time.Now()
+	for _, order := range orders {
+		ticket := kitchenTicket(order, time.Duration(target)*time.Minute, now)
+		if ticket.Late {
+			board.Late++
+		}
+		switch order.Status {
+		case "confirmed":
+			board.Confirmed = append(board.Confirmed, ticket)
+		case "preparing":
+			board.Preparing = append(board.Preparing, ticket)
+		case "ready":
+			board.Ready = append(board.Ready, ticket)
+		}
+	}
+	return board, nil
+}
+
+// Bump moves the order to its next kitchen status and returns it
+func (s *KitchenService) Bump(ctx context.Context, orderID string, userID string) (string, error) {
+	ctx, span := tracing.Start(ctx, "KitchenService.Bump")
+	defer span.End()
+
+	return s.move(ctx, orderID, userID, bumpTo, "order can't be bumped from ")
+}
+
+// Recall moves the order back to its previous kitchen status and returns it
+func (s *KitchenService) Recall(ctx context.Context, orderID string, userID string) (string, error) {
+	ctx, span := tracing.Start(ctx, "KitchenService.Recall")
+	defer span.End()
+
+	return s.move(ctx, orderID, userID, recallTo, "order can't be recalled from ")
+}
+
+func (s *KitchenService) move(ctx context.Context, orderID string, userID string, steps map[string]string, refusal string) (string, error) {
+	order, err := s.order(ctx, orderID, userID)
+	if err != nil {
+		return "", err
+	}
+
+	next, ok := steps[order.Status]
+	if !ok {
+		return "", apperr.Conflict(refusal + order.Status)
+	}
+	if err := s.orders.UpdateOrderStatus(ctx, orderID, order.Status, next); err != nil {
+		return "", statusErr(err, "failed to update order status")
+	}
+	s.events.OrderStatusChanged(ctx, order, next)
+	return next, nil
+}
+
+// SetItemDone marks one item of an order done, or not done again
+func (s *KitchenService) SetItemDone(ctx context.Context, orderID, itemID string, req *model.UpdateKitchenItemRequest, userID string) error {
+	ctx, span := tracing.Start(ctx, "KitchenService.SetItemDone")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return err
+	}
+
+	order, err := s.order(ctx, orderID, userID)
+	if err != nil {
+		return err
+	}
+	if _, onBoard := bumpTo[order.Status]; !onBoard && order.Status != "ready" {
+		return apperr.Conflict("order isn't in the kitchen")
+	}
+
+	if err := s.kitchen.SetItemPrepared(ctx, orderID, itemID, *req.Done); err != nil {
+		return lookupErr(err, "item not found in this order")
+	}
+	return nil
+}
+
+// UpdateSettings sets the restaurant's prep-time target
+func (s *KitchenService) UpdateSettings(ctx context.Context, restaurantID string, req *model.KitchenSettingsRequest, userID string) error {
+	ctx, span := tracing.Start(ctx, "KitchenService.UpdateSettings")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return err
+	}
+
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, restaurantID)
+	if err != nil {
+		return lookupErr(err, "restaurant not found")
+	}
+	if err := s.authz.Require(ctx, restaurant.ID, userID, authz.EditRestaurant); err != nil {
+		return err
+	}
+
+	if err := s.kitchen.SetPrepTarget(ctx, restaurantID, req.PrepTargetMinutes); err != nil {
+		return storeErr(err, "failed to update kitchen settings")
+	}
+	return nil
+}
+
+// order loads an order the caller may update the status of
+func (s *KitchenService) order(ctx context.Context, orderID string, userID string) (*model.Order, error) {
+	order, err := s.orders.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, lookupErr(err, "order not found")
+	}
+	if err := s.authz.Require(ctx, order.RestaurantID, userID, authz.UpdateOrderStatus); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (s *KitchenService) prepTarget(ctx context.Context, restaurantID string) (int, error) {
+	minutes, err := s.kitchen.GetPrepTarget(ctx, restaurantID)
+	if repository.IsNotFound(err) {
+		return defaultPrepTargetMinutes, nil
+	}
+	if err != nil {
+		return 0, storeErr(err, "failed to fetch kitchen settings")
+	}
+	return minutes, nil
+}
+
+// kitchenTicket trims an order down to what the kitchen needs to see. An
+// order accepted with a prep estimate is due when the estimate runs out,
+// the same time the customer's ETA counts from; any other order gets the
+// restaurant's target.
+func kitchenTicket(order model.OrderWithDetails, target time.Duration, now time.Time) model.KitchenTicket {
+	ticket := model.KitchenTicket{
+		OrderID:  order.ID,
+		Number:   order.ID[len(order.ID)-4:],
+		PlacedAt: order.CreatedAt,
+		DueAt:    order.CreatedAt.Add(target),
+		Items:    make([]model.KitchenItem, len(order.Items)),
+	}
+	if order.AcceptedAt != nil && order.PrepMinutes != nil {
+		ticket.DueAt = order.AcceptedAt.Add(time.Duration(*order.PrepMinutes) * time.Minute)
+	}
+	ticket.Late = order.Status != "ready" && now.After(ticket.DueAt)
+
+	for i, item := range order.Items {
+		ticket.Items[i] = model.KitchenItem{
+			ID:       item.ID,
+			Name:     item.ItemName,
+			Quantity: item.Quantity,
+			Options:  item.Options,
+			Done:     item.PreparedAt != nil,
+		}
+	}
+	return ticket
+}
@@ -0,0 +1,318 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"quickbite/config"
+	"quickbite/internal/apperr"
+	"quickbite/internal/authz"
+	"quickbite/internal/mail"
+	"quickbite/internal/model"
+	"quickbite/internal/repository"
+	"quickbite/internal/tracing"
+	"quickbite/internal/validate"
+)
+
+// MemberService manages restaurant staff: who works where, in what role,
+// and the email invitations that bring new people in
+type MemberService struct {
+	restaurants repository.RestaurantStore
+	members     repository.MemberStore
+	users       repository.UserStore
+	authz       *Authorizer
+	mailer      mail.Mailer
+	cfg         *config.Config
+}
+
+func NewMemberService(restaurants repository.RestaurantStore, members repository.MemberStore, users repository.UserStore, authorizer *Authorizer, mailer mail.Mailer, cfg *config.Config) *MemberService {
+	return &MemberService{
+		restaurants: restaurants,
+		members:     members,
+		users:       users,
+		authz:       authorizer,
+		mailer:      mailer,
+		cfg:         cfg,
+	}
+}
+
+// GetMembers lists a restaurant's staff to anyone who works there
+func (s *MemberService) GetMembers(ctx context.Context, restaurantID string, userID string) ([]model.RestaurantMember, error) {
+	ctx, span := tracing.Start(ctx, "MemberService.GetMembers")
+	defer span.End()
+
+	if _, err := s.restaurant(ctx, restaurantID); err != nil {
+		return nil, err
+	}
+
+	role, err := s.authz.Role(ctx, restaurantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, apperr.Forbidden("you don't work at this restaurant")
+	}
+
+	members, err := s.members.GetMembers(ctx, restaurantID)
+	if err != nil {
+		return nil, storeErr(err, "failed to fetch members")
+	}
+	return members, nil
+}
+
+// UpdateMemberRole changes what a member may do. The owner's role is fixed.
+func (s *MemberService) UpdateMemberRole(ctx context.Context, restaurantID, memberID string, req *model.UpdateMemberRequest, userID string) (*model.RestaurantMember, error) {
+	ctx, span := tracing.Start(ctx, "MemberService.UpdateMemberRole")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.restaurant(ctx, restaurantID); err != nil {
+		return nil, err
+	}
+
+	if err := s.authz.Require(ctx, restaurantID, userID, authz.ManageMembers); err != nil {
+		return nil, err
+	}
+
+	member, err := s.members.GetMember(ctx, restaurantID, memberID)
+	if err != nil {
+		return nil, lookupErr(err, "member not found")
+	}
+	if authz.Role(member.Role) == authz.RoleOwner {
+		return nil, apperr.Conflict("the owner's role can't be changed")
+	}
+
+	if err := s.members.UpdateMemberRole(ctx, restaurantID, memberID, req.Role); err != nil {
+		return nil, storeErr(err, "failed to update member")
+	}
+	member.Role = req.Role
+	return member, nil
+}
+
+// RemoveMember takes someone off the staff. Members may always remove
+// themselves; the owner can't be removed.
+func (s *MemberService) RemoveMember(ctx context.Context, restaurantID, memberID string, userID string) error {
+	ctx, span := tracing.Start(ctx, "MemberService.RemoveMember")
+	defer span.End()
+
+	if _, err := s.restaurant(ctx, restaurantID); err != nil {
+		return err
+	}
+
+	if memberID != userID {
+		if err := s.authz.Require(ctx, restaurantID, userID, authz.ManageMembers); err != nil {
+			return err
+		}
+	}
+
+	member, err := s.members.GetMember(ctx, restaurantID, memberID)
+	if err != nil {
+		return lookupErr(err, "member not found")
+	}
+	if authz.Role(member.Role) == authz.RoleOwner {
+		return apperr.Conflict("the owner can't be removed")
+	}
+
+	if err := s.members.RemoveMember(ctx, restaurantID, memberID); err != nil {
+		return storeErr(err, "failed to remove member")
+	}
+	return nil
+}
+
+// ====== INVITATIONS ======
+
+// InviteMember emails an invitation to join the restaurant. Inviting the
+// same address again replaces the pending invitation, which is also how
+// an invitation is resent.
+func (s *MemberService) InviteMember(ctx context.Context, restaurantID string, req *model.InviteMemberRequest, userID string) (*model.Invitation, error) {
+	ctx, span := tracing.Start(ctx, "MemberService.InviteMember")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	restaurant, err := s.restaurant(ctx, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authz.Require(ctx, restaurantID, userID, authz.ManageMembers); err != nil {
+		return nil, err
+	}
+
+	email := normalizeEmail(req.Email)
+
+	invitee, err := s.users.GetUserByEmail(ctx, email)
+	if err != nil && !repository.IsNotFound(err) {
+		return nil, storeErr(err, "failed to load data")
+	}
+	if invitee != nil {
+		role, err := s.authz.Role(ctx, restaurantID, invitee.ID)
+		if err != nil {
+			return nil, err
+		}
+		if role != "" {
+			return nil, apperr.Conflict("this person already works at this restaurant")
+		}
+	}
+
+	token, tokenHash, err := newInvitationToken()
+	if err != nil {
+		return nil, apperr.Internal("failed to create invitation", err)
+	}
+
+	invitation := &model.Invitation{
+		RestaurantID: restaurantID,
+		Email:        email,
+		Role:         req.Role,
+		TokenHash:    tokenHash,
+		InvitedBy:    userID,
+		ExpiresAt:    time.Now().Add(s.cfg.InvitationTTL),
+	}
+	if err := s.members.SaveInvitation(ctx, invitation); err != nil {
+		return nil, storeErr(err, "failed to create invitation")
+	}
+
+	if err := s.mailer.Send(ctx, s.invitationEmail(restaurant, invitation, token)); err != nil {
+		return nil, apperr.Internal("invitation saved but the email could not be sent; invite again to resend", err)
+	}
+
+	return invitation, nil
+}
+
+// GetInvitations lists the restaurant's pending invitations
+func (s *MemberService) GetInvitations(ctx context.Context, restaurantID string, userID string) ([]model.Invitation, error) {
+	ctx, span := tracing.Start(ctx, "MemberService.GetInvitations")
+	defer span.End()
+
+	if _, err := s.restaurant(ctx, restaurantID); err != nil {
+		return nil, err
+	}
+
+	if err := s.authz.Require(ctx, restaurantID, userID, authz.ManageMembers); err != nil {
+		return nil, err
+	}
+
+	invitations, err := s.members.GetPendingInvitations(ctx, restaurantID)
+	if err != nil {
+		return nil, storeErr(err, "failed to fetch invitations")
+	}
+	return invitations, nil
+}
+
+func (s *MemberService) RevokeInvitation(ctx context.Context, restaurantID, invitationID string, userID string) error {
+	ctx, span := tracing.Start(ctx, "MemberService.RevokeInvitation")
+	defer span.End()
+
+	if _, err := s.restaurant(ctx, restaurantID); err != nil {
+		return err
+	}
+
+	if err := s.authz.Require(ctx, restaurantID, userID, authz.ManageMembers); err != nil {
+		return err
+	}
+
+	if err := s.members.DeleteInvitation(ctx, restaurantID, invitationID); err != nil {
+		if errors.Is(err, repository.ErrStale) {
+			return apperr.NotFound("invitation not found")
+		}
+		return storeErr(err, "failed to revoke invitation")
+	}
+	return nil
+}
+
+// AcceptInvitation adds userID to the restaurant the token invites them
+// to. The invitation must have been sent to their account's email.
+func (s *MemberService) AcceptInvitation(ctx context.Context, req *model.AcceptInvitationRequest, userID string) (*model.RestaurantMember, error) {
+	ctx, span := tracing.Start(ctx, "MemberService.AcceptInvitation")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	invitation, err := s.members.GetInvitationByToken(ctx, hashInvitationToken(req.Token))
+	if err != nil {
+		return nil, lookupErr(err, "invitation not found")
+	}
+	if invitation.AcceptedAt != nil {
+		return nil, apperr.Conflict("invitation was already accepted")
+	}
+	if !time.Now().Before(invitation.ExpiresAt) {
+		return nil, apperr.Conflict("invitation has expired, ask for a new one")
+	}
+
+	user, err := s.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, lookupErr(err, "user not found")
+	}
+	if !strings.EqualFold(user.Email, invitation.Email) {
+		return nil, apperr.Forbidden("this invitation was sent to a different email address")
+	}
+
+	if _, err := s.restaurant(ctx, invitation.RestaurantID); err != nil {
+		return nil, err
+	}
+
+	if err := s.members.AcceptInvitation(ctx, invitation.ID, userID); err != nil {
+		if errors.Is(err, repository.ErrStale) {
+			return nil, apperr.Conflict("invitation is no longer valid")
+		}
+		return nil, storeErr(err, "failed to accept invitation")
+	}
+
+	member, err := s.members.GetMember(ctx, invitation.RestaurantID, userID)
+	if err != nil {
+		return nil, lookupErr(err, "member not found")
+	}
+	return member, nil
+}
+
+func (s *MemberService) restaurant(ctx context.Context, id string) (*model.Restaurant, error) {
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, id)
+	if err != nil {
+		return nil, lookupErr(err, "restaurant not found")
+	}
+	return restaurant, nil
+}
+
+func (s *MemberService) invitationEmail(restaurant *model.Restaurant, invitation *model.Invitation, token string) mail.Message {
+	link := strings.TrimRight(s.cfg.FrontendURL, "/") + "/invitations/accept?token=" + url.QueryEscape(token)
+	return mail.Message{
+		To:      invitation.Email,
+		Subject: fmt.Sprintf("You're invited to join %s on QuickBite", restaurant.Name),
+		Body: fmt.Sprintf(
+			"You've been invited to join %s as %s.\n\n"+
+				"Sign in or create an account with this email address, then open:\n%s\n\n"+
+				"The invitation expires on %s.\n",
+			restaurant.Name, invitation.Role, link, invitation.ExpiresAt.UTC().Format("2 Jan 2006 15:04 MST"),
+		),
+	}
+}
+
+// newInvitationToken returns a random token for the email and the hash we keep
+func newInvitationToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, hashInvitationToken(token), nil
+}
+
+func hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,25 +1,49 @@
 package service
 
 import (
-	"errors"
+	"context"
+	"log/slog"
+	"quickbite/internal/apperr"
+	"quickbite/internal/authz"
 	"quickbite/internal/model"
 	"quickbite/internal/repository"
+	"quickbite/internal/tracing"
+	"quickbite/internal/validate"
+	"time"
 )
 
+const (
+	staleMenuItem = "menu item was changed by another request, reload and try again"
+	brandMenuItem = "this item comes from the brand menu; change it there, or set an override for this restaurant"
+)
+
+type MenuService struct {
+	restaurants repository.RestaurantStore
+	menu        repository.MenuStore
+	authz       *Authorizer
+}
+
+func NewMenuService(restaurants repository.RestaurantStore, menu repository.MenuStore, authorizer *Authorizer) *MenuService {
+	return &MenuService{restaurants: restaurants, menu: menu, authz: authorizer}
+}
+
 // ====== MENU CATEGORIES ======
 
-func CreateCategory(req *model.CreateCategoryRequest, userID string) (*model.MenuCategory, error) {
-	if req.Name == "" {
-		return nil, errors.New("category name is required")
+func (s *MenuService) CreateCategory(ctx context.Context, req *model.CreateCategoryRequest, userID string) (*model.MenuCategory, error) {
+	ctx, span := tracing.Start(ctx, "MenuService.CreateCategory")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
 	}
 
-	restaurant, err := repository.GetRestaurantByID(req.RestaurantID)
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, req.RestaurantID)
 	if err != nil {
-		return nil, errors.New("restaurant not found")
+		return nil, lookupErr(err, "restaurant not found")
 	}
 
-	if restaurant.OwnerID != userID {
-		return nil, errors.New("unauthorized: you don't own this restaurant")
+	if err := s.authz.Require(ctx, restaurant.ID, userID, authz.EditMenu); err != nil {
+		return nil, err
 	}
 
 	category := &model.MenuCategory{
@@ -28,60 +52,202 @@ func CreateCategory(req *model.CreateCategoryRequest, userID string) (*model.Men
 		DisplayOrder: req.DisplayOrder,
 	}
 
-	if err := repository.CreateCategory(category); err != nil {
-		return nil, errors.New("failed to create category")
+	if err := s.menu.CreateCategory(ctx, category); err != nil {
+		return nil, storeErr(err, "failed to create category")
 	}
 
 	return category, nil
 }
 
-func GetCategoriesByRestaurant(restaurantID string) ([]model.MenuCategory, error) {
-	return repository.GetCategoriesByRestaurant(restaurantID)
+// GetCategoriesByRestaurant returns the categories that are on the menu right
+// now; editors see the rest through GetFullMenu
+func (s *MenuService) GetCategoriesByRestaurant(ctx context.Context, restaurantID string) ([]model.MenuCategory, error) {
+	ctx, span := tracing.Start(ctx, "MenuService.GetCategoriesByRestaurant")
+	defer span.End()
+
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, restaurantID)
+	if err != nil {
+		return nil, lookupErr(err, "restaurant not found")
+	}
+
+	categories, err := s.menu.GetCategoriesByRestaurant(ctx, restaurantID)
+	if err != nil {
+		return nil, storeErr(err, "failed to fetch categories")
+	}
+
+	ids := make([]string, len(categories))
+	for i, c := range categories {
+		ids[i] = c.ID
+	}
+	windows, err := s.menu.GetWindowsByCategories(ctx, ids)
+	if err != nil {
+		return nil, storeErr(err, "failed to fetch categories")
+	}
+
+	now := restaurantNow(restaurant)
+	visible := []model.MenuCategory{}
+	for _, c := range categories {
+		if !isScheduledNow(windows[c.ID], now) {
+			continue
+		}
+		c.Schedule = windows[c.ID]
+		visible = append(visible, c)
+	}
+
+	return visible, nil
+}
+
+// GetFullMenu returns every category with its items and schedules,
+// including those off the menu right now, for staff who edit the menu
+func (s *MenuService) GetFullMenu(ctx context.Context, restaurantID string, userID string) ([]model.MenuCategory, error) {
+	ctx, span := tracing.Start(ctx, "MenuService.GetFullMenu")
+	defer span.End()
+
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, restaurantID)
+	if err != nil {
+		return nil, lookupErr(err, "restaurant not found")
+	}
+
+	if err := s.authz.Require(ctx, restaurant.ID, userID, authz.EditMenu); err != nil {
+		return nil, err
+	}
+
+	categories, err := s.menu.GetCategoriesByRestaurant(ctx, restaurantID)
+	if err != nil {
+		return nil, storeErr(err, "failed to fetch menu")
+	}
+
+	categoryIDs := make([]string, len(categories))
+	for i, c := range categories {
+		categoryIDs[i] = c.ID
+	}
+	categoryWindows, err := s.menu.GetWindowsByCategories(ctx, categoryIDs)
+	if err != nil {
+		return nil, storeErr(err, "failed to fetch menu")
+	}
+
+	for i := range categories {
+		items, err := s.menu.GetMenuItemsByCategory(ctx, categories[i].ID)
+		if err != nil {
+			return nil, storeErr(err, "failed to fetch menu")
+		}
+
+		itemIDs := make([]string, len(items))
+		for j, item := range items {
+			itemIDs[j] = item.ID
+		}
+		windows, err := s.menu.GetWindowsByMenuItems(ctx, itemIDs)
+		if err != nil {
+			return nil, storeErr(err, "failed to fetch menu")
+		}
+		for j := range items {
+			items[j].Schedule = windows[items[j].ID]
+		}
+
+		categories[i].Schedule = categoryWindows[categories[i].ID]
+		categories[i].Items = items
+	}
+
+	return categories, nil
 }
 
-func DeleteCategory(id string, userID string) error {
+func (s *MenuService) DeleteCategory(ctx context.Context, id string, userID string) error {
+	ctx, span := tracing.Start(ctx, "MenuService.DeleteCategory")
+	defer span.End()
+
 	// Get the category to find which restaurant it belongs to
-	category, err := repository.GetCategoryByID(id)
+	category, err := s.menu.GetCategoryByID(ctx, id)
 	if err != nil {
-		return errors.New("category not found")
+		return lookupErr(err, "category not found")
 	}
 
-	// Get the restaurant to verify ownership
-	restaurant, err := repository.GetRestaurantByID(category.RestaurantID)
+	// Get the restaurant to check permissions
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, category.RestaurantID)
 	if err != nil {
-		return errors.New("restaurant not found")
+		return lookupErr(err, "restaurant not found")
 	}
 
-	// Verify user owns the restaurant
-	if restaurant.OwnerID != userID {
-		return errors.New("unauthorized: you don't own this restaurant")
+	// Verify user may edit its menu
+	if err := s.authz.Require(ctx, restaurant.ID, userID, authz.EditMenu); err != nil {
+		return err
 	}
 
-	return repository.DeleteCategory(id)
+	return s.menu.DeleteCategory(ctx, id)
+}
+
+func (s *MenuService) RestoreCategory(ctx context.Context, id string, userID string, retention time.Duration) error {
+	ctx, span := tracing.Start(ctx, "MenuService.RestoreCategory")
+	defer span.End()
+
+	category, err := s.menu.GetDeletedCategoryByID(ctx, id, retention)
+	if err != nil {
+		return lookupErr(err, "category not found in trash")
+	}
+
+	// A category can only come back into a live restaurant
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, category.RestaurantID)
+	if err != nil {
+		return parentErr(err, "restore the restaurant first")
+	}
+
+	if err := s.authz.Require(ctx, restaurant.ID, userID, authz.EditMenu); err != nil {
+		return err
+	}
+
+	return s.menu.RestoreCategory(ctx, id)
+}
+
+// UpdateCategorySchedule replaces the category's availability windows
+func (s *MenuService) UpdateCategorySchedule(ctx context.Context, id string, req *model.UpdateScheduleRequest, userID string) error {
+	ctx, span := tracing.Start(ctx, "MenuService.UpdateCategorySchedule")
+	defer span.End()
+
+	if err := validateSchedule(req); err != nil {
+		return err
+	}
+
+	category, err := s.menu.GetCategoryByID(ctx, id)
+	if err != nil {
+		return lookupErr(err, "category not found")
+	}
+
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, category.RestaurantID)
+	if err != nil {
+		return lookupErr(err, "restaurant not found")
+	}
+
+	if err := s.authz.Require(ctx, restaurant.ID, userID, authz.EditMenu); err != nil {
+		return err
+	}
+
+	return s.menu.ReplaceCategoryWindows(ctx, id, req.Windows)
 }
 
 // ====== MENU ITEMS ======
 
-func CreateMenuItem(req *model.CreateMenuItemRequest, userID string) (*model.MenuItem, error) {
-	if req.Name == "" || req.Price <= 0 {
-		return nil, errors.New("name and valid price are required")
+func (s *MenuService) CreateMenuItem(ctx context.Context, req *model.CreateMenuItemRequest, userID string) (*model.MenuItem, error) {
+	ctx, span := tracing.Start(ctx, "MenuService.CreateMenuItem")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
 	}
 
 	// Get the category to find which restaurant it belongs to
-	category, err := repository.GetCategoryByID(req.CategoryID)
+	category, err := s.menu.GetCategoryByID(ctx, req.CategoryID)
 	if err != nil {
-		return nil, errors.New("category not found")
+		return nil, lookupErr(err, "category not found")
 	}
 
-	// Get the restaurant to verify ownership
-	restaurant, err := repository.GetRestaurantByID(category.RestaurantID)
+	// Get the restaurant to check permissions
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, category.RestaurantID)
 	if err != nil {
-		return nil, errors.New("restaurant not found")
+		return nil, lookupErr(err, "restaurant not found")
 	}
 
-	// Verify user owns the restaurant
-	if restaurant.OwnerID != userID {
-		return nil, errors.New("unauthorized: you don't own this restaurant")
+	// Verify user may edit its menu
+	if err := s.authz.Require(ctx, restaurant.ID, userID, authz.EditMenu); err != nil {
+		return nil, err
 	}
 
 	item := &model.MenuItem{
@@ -94,71 +260,355 @@ func CreateMenuItem(req *model.CreateMenuItemRequest, userID string) (*model.Men
 		IsVeg:       req.IsVeg,
 	}
 
-	if err := repository.CreateMenuItem(item); err != nil {
-		return nil, errors.New("failed to create menu item")
+	// Tracked items start the day with their full daily stock
+	if req.DailyStock != nil {
+		stock := *req.DailyStock
+		item.StockQuantity = &stock
+		item.DailyStock = req.DailyStock
+		item.IsAvailable = stock > 0
+	}
+
+	if err := s.menu.CreateMenuItem(ctx, item); err != nil {
+		return nil, storeErr(err, "failed to create menu item")
 	}
 
 	return item, nil
 }
 
-func GetMenuItemsByCategory(categoryID string) ([]model.MenuItem, error) {
-	return repository.GetMenuItemsByCategory(categoryID)
+// GetMenuItemsByCategory returns the category's items that are on the menu
+// right now; editors see the rest through GetFullMenu
+func (s *MenuService) GetMenuItemsByCategory(ctx context.Context, categoryID string) ([]model.MenuItem, error) {
+	ctx, span := tracing.Start(ctx, "MenuService.GetMenuItemsByCategory")
+	defer span.End()
+
+	category, err := s.menu.GetCategoryByID(ctx, categoryID)
+	if err != nil {
+		return nil, lookupErr(err, "category not found")
+	}
+
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, category.RestaurantID)
+	if err != nil {
+		return nil, lookupErr(err, "restaurant not found")
+	}
+
+	now := restaurantNow(restaurant)
+	visible := []model.MenuItem{}
+
+	categoryWindows, err := s.menu.GetWindowsByCategories(ctx, []string{categoryID})
+	if err != nil {
+		return nil, storeErr(err, "failed to fetch menu items")
+	}
+	if !isScheduledNow(categoryWindows[categoryID], now) {
+		return visible, nil
+	}
+
+	items, err := s.menu.GetMenuItemsByCategory(ctx, categoryID)
+	if err != nil {
+		return nil, storeErr(err, "failed to fetch menu items")
+	}
+
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	windows, err := s.menu.GetWindowsByMenuItems(ctx, ids)
+	if err != nil {
+		return nil, storeErr(err, "failed to fetch menu items")
+	}
+
+	for _, item := range items {
+		if !isScheduledNow(windows[item.ID], now) {
+			continue
+		}
+		item.Schedule = windows[item.ID]
+		visible = append(visible, item)
+	}
+
+	return visible, nil
+}
+
+// GetMenuItemByID returns one item with its schedule, whether or not it is
+// on the menu right now; owners read it before editing
+func (s *MenuService) GetMenuItemByID(ctx context.Context, id string) (*model.MenuItem, error) {
+	ctx, span := tracing.Start(ctx, "MenuService.GetMenuItemByID")
+	defer span.End()
+
+	item, err := s.menu.GetMenuItemByID(ctx, id)
+	if err != nil {
+		return nil, lookupErr(err, "menu item not found")
+	}
+
+	windows, err := s.menu.GetWindowsByMenuItems(ctx, []string{id})
+	if err != nil {
+		return nil, storeErr(err, "failed to fetch menu item")
+	}
+	item.Schedule = windows[id]
+
+	return item, nil
 }
 
-func UpdateMenuItem(id string, req *model.UpdateMenuItemRequest, userID string) error {
-	if req.Name == "" || req.Price <= 0 {
-		return errors.New("name and valid price are required")
+// UpdateMenuItem replaces the owner-editable fields. version is the one the
+// caller last read; 0 skips the check.
+func (s *MenuService) UpdateMenuItem(ctx context.Context, id string, version int, req *model.UpdateMenuItemRequest, userID string) error {
+	ctx, span := tracing.Start(ctx, "MenuService.UpdateMenuItem")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return err
 	}
 
 	// Get the item
-	item, err := repository.GetMenuItemByID(id)
+	item, err := s.menu.GetMenuItemByID(ctx, id)
 	if err != nil {
-		return errors.New("menu item not found")
+		return lookupErr(err, "menu item not found")
 	}
 
 	// Get the category
-	category, err := repository.GetCategoryByID(item.CategoryID)
+	category, err := s.menu.GetCategoryByID(ctx, item.CategoryID)
 	if err != nil {
-		return errors.New("category not found")
+		return lookupErr(err, "category not found")
 	}
 
 	// Get the restaurant
-	restaurant, err := repository.GetRestaurantByID(category.RestaurantID)
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, category.RestaurantID)
 	if err != nil {
-		return errors.New("restaurant not found")
+		return lookupErr(err, "restaurant not found")
+	}
+
+	// Verify user may edit the menu
+	if err := s.authz.Require(ctx, restaurant.ID, userID, authz.EditMenu); err != nil {
+		return err
 	}
 
-	// Verify ownership
-	if restaurant.OwnerID != userID {
-		return errors.New("unauthorized: you don't own this restaurant")
+	if item.BrandItemID != "" {
+		return apperr.Conflict(brandMenuItem)
 	}
 
-	return repository.UpdateMenuItem(id, req)
+	if !versionMatches(item.Version, version) {
+		return apperr.PreconditionFailed(staleMenuItem)
+	}
+
+	if err := s.menu.UpdateMenuItem(ctx, id, version, req); err != nil {
+		return staleErr(err, staleMenuItem)
+	}
+	return nil
+}
+
+// PatchMenuItem applies a merge patch and returns the item as it now stands
+func (s *MenuService) PatchMenuItem(ctx context.Context, id string, version int, req *model.PatchMenuItemRequest, userID string) (*model.MenuItem, error) {
+	ctx, span := tracing.Start(ctx, "MenuService.PatchMenuItem")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	item, err := s.authorizeMenuItem(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if item.BrandItemID != "" {
+		return nil, apperr.Conflict(brandMenuItem)
+	}
+
+	if !versionMatches(item.Version, version) {
+		return nil, apperr.PreconditionFailed(staleMenuItem)
+	}
+
+	if err := s.menu.PatchMenuItem(ctx, id, version, req); err != nil {
+		return nil, staleErr(err, staleMenuItem)
+	}
+
+	item, err = s.menu.GetMenuItemByID(ctx, id)
+	if err != nil {
+		return nil, lookupErr(err, "menu item not found")
+	}
+	return item, nil
+}
+
+// SetMenuItemAvailability is the quick in/out of stock toggle owners flip
+// during service
+func (s *MenuService) SetMenuItemAvailability(ctx context.Context, id string, version int, req *model.UpdateAvailabilityRequest, userID string) (*model.MenuItem, error) {
+	ctx, span := tracing.Start(ctx, "MenuService.SetMenuItemAvailability")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	return s.PatchMenuItem(ctx, id, version, &model.PatchMenuItemRequest{IsAvailable: model.Set(*req.IsAvailable)}, userID)
+}
+
+// authorizeMenuItem loads the item and checks userID may edit the menu it's on
+func (s *MenuService) authorizeMenuItem(ctx context.Context, id string, userID string) (*model.MenuItem, error) {
+	item, err := s.menu.GetMenuItemByID(ctx, id)
+	if err != nil {
+		return nil, lookupErr(err, "menu item not found")
+	}
+
+	category, err := s.menu.GetCategoryByID(ctx, item.CategoryID)
+	if err != nil {
+		return nil, lookupErr(err, "category not found")
+	}
+
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, category.RestaurantID)
+	if err != nil {
+		return nil, lookupErr(err, "restaurant not found")
+	}
+
+	if err := s.authz.Require(ctx, restaurant.ID, userID, authz.EditMenu); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (s *MenuService) RestoreMenuItem(ctx context.Context, id string, userID string, retention time.Duration) error {
+	ctx, span := tracing.Start(ctx, "MenuService.RestoreMenuItem")
+	defer span.End()
+
+	item, err := s.menu.GetDeletedMenuItemByID(ctx, id, retention)
+	if err != nil {
+		return lookupErr(err, "menu item not found in trash")
+	}
+
+	// An item can only come back into a live category
+	category, err := s.menu.GetCategoryByID(ctx, item.CategoryID)
+	if err != nil {
+		return parentErr(err, "restore the category first")
+	}
+
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, category.RestaurantID)
+	if err != nil {
+		return lookupErr(err, "restaurant not found")
+	}
+
+	if err := s.authz.Require(ctx, restaurant.ID, userID, authz.EditMenu); err != nil {
+		return err
+	}
+
+	return s.menu.RestoreMenuItem(ctx, id)
 }
 
-func DeleteMenuItem(id string, userID string) error {
+// UpdateMenuItemStock lets the owner set today's count and the daily refill.
+// Stock is each outlet's own, so brand items take it too.
+func (s *MenuService) UpdateMenuItemStock(ctx context.Context, id string, req *model.UpdateStockRequest, userID string) error {
+	ctx, span := tracing.Start(ctx, "MenuService.UpdateMenuItemStock")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return err
+	}
+	if req.StockQuantity == nil && req.DailyStock != nil {
+		return apperr.InvalidField("stock_quantity", "is required when daily_stock is set")
+	}
+
+	// Get the item
+	item, err := s.menu.GetMenuItemByID(ctx, id)
+	if err != nil {
+		return lookupErr(err, "menu item not found")
+	}
+
+	// Get the category
+	category, err := s.menu.GetCategoryByID(ctx, item.CategoryID)
+	if err != nil {
+		return lookupErr(err, "category not found")
+	}
+
+	// Get the restaurant
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, category.RestaurantID)
+	if err != nil {
+		return lookupErr(err, "restaurant not found")
+	}
+
+	// Verify user may edit the menu
+	if err := s.authz.Require(ctx, restaurant.ID, userID, authz.EditMenu); err != nil {
+		return err
+	}
+
+	return s.menu.UpdateMenuItemStock(ctx, id, req)
+}
+
+// UpdateMenuItemSchedule replaces the item's availability windows. Like
+// stock, an outlet sets these on brand items as well.
+func (s *MenuService) UpdateMenuItemSchedule(ctx context.Context, id string, req *model.UpdateScheduleRequest, userID string) error {
+	ctx, span := tracing.Start(ctx, "MenuService.UpdateMenuItemSchedule")
+	defer span.End()
+
+	if err := validateSchedule(req); err != nil {
+		return err
+	}
+
+	// Get the item
+	item, err := s.menu.GetMenuItemByID(ctx, id)
+	if err != nil {
+		return lookupErr(err, "menu item not found")
+	}
+
+	// Get the category
+	category, err := s.menu.GetCategoryByID(ctx, item.CategoryID)
+	if err != nil {
+		return lookupErr(err, "category not found")
+	}
+
+	// Get the restaurant
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, category.RestaurantID)
+	if err != nil {
+		return lookupErr(err, "restaurant not found")
+	}
+
+	// Verify user may edit the menu
+	if err := s.authz.Require(ctx, restaurant.ID, userID, authz.EditMenu); err != nil {
+		return err
+	}
+
+	return s.menu.ReplaceMenuItemWindows(ctx, id, req.Windows)
+}
+
+// ResetDailyStock refills tracked items for restaurants that have opened today.
+// Called periodically by the stock-reset worker.
+func (s *MenuService) ResetDailyStock(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "MenuService.ResetDailyStock")
+	defer span.End()
+
+	count, err := s.menu.ResetDailyStock(ctx)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		slog.InfoContext(ctx, "daily stock refilled", "menu_items", count)
+	}
+	return nil
+}
+
+// DeleteMenuItem moves the item to the trash. An outlet may drop a brand
+// item; syncs leave it deleted.
+func (s *MenuService) DeleteMenuItem(ctx context.Context, id string, userID string) error {
+	ctx, span := tracing.Start(ctx, "MenuService.DeleteMenuItem")
+	defer span.End()
+
 	// Get the item
-	item, err := repository.GetMenuItemByID(id)
+	item, err := s.menu.GetMenuItemByID(ctx, id)
 	if err != nil {
-		return errors.New("menu item not found")
+		return lookupErr(err, "menu item not found")
 	}
 
 	// Get the category
-	category, err := repository.GetCategoryByID(item.CategoryID)
+	category, err := s.menu.GetCategoryByID(ctx, item.CategoryID)
 	if err != nil {
-		return errors.New("category not found")
+		return lookupErr(err, "category not found")
 	}
 
 	// Get the restaurant
-	restaurant, err := repository.GetRestaurantByID(category.RestaurantID)
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, category.RestaurantID)
 	if err != nil {
-		return errors.New("restaurant not found")
+		return lookupErr(err, "restaurant not found")
 	}
 
-	// Verify ownership
-	if restaurant.OwnerID != userID {
-		return errors.New("unauthorized: you don't own this restaurant")
+	// Verify user may edit the menu
+	if err := s.authz.Require(ctx, restaurant.ID, userID, authz.EditMenu); err != nil {
+		return err
 	}
 
-	return repository.DeleteMenuItem(id)
+	return s.menu.DeleteMenuItem(ctx, id)
 }
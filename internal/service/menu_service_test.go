@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"quickbite/internal/apperr"
+	"quickbite/internal/model"
+	"quickbite/internal/repository"
+)
+
+func TestWindowContains(t *testing.T) {
+	weekdays := []int{1, 2, 3, 4, 5}
+	// 2026-10-19 is a Monday
+	at := func(day int, clock string) time.Time {
+		hm, _ := time.Parse("15:04", clock)
+		return time.Date(2026, 10, 19+day-1, hm.Hour(), hm.Minute(), 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name   string
+		window model.AvailabilityWindow
+		t      time.Time
+		want   bool
+	}{
+		{"inside", model.AvailabilityWindow{Days: weekdays, StartTime: "07:00", EndTime: "11:00"}, at(1, "09:30"), true},
+		{"at start", model.AvailabilityWindow{Days: weekdays, StartTime: "07:00", EndTime: "11:00"}, at(1, "07:00"), true},
+		{"at end", model.AvailabilityWindow{Days: weekdays, StartTime: "07:00", EndTime: "11:00"}, at(1, "11:00"), false},
+		{"wrong day", model.AvailabilityWindow{Days: weekdays, StartTime: "07:00", EndTime: "11:00"}, at(6, "09:30"), false},
+		{"overnight evening", model.AvailabilityWindow{Days: []int{5}, StartTime: "22:00", EndTime: "02:00"}, at(5, "23:00"), true},
+		{"overnight morning after", model.AvailabilityWindow{Days: []int{5}, StartTime: "22:00", EndTime: "02:00"}, at(6, "01:00"), true},
+		{"overnight morning of the day", model.AvailabilityWindow{Days: []int{5}, StartTime: "22:00", EndTime: "02:00"}, at(5, "01:00"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := windowContains(tt.window, tt.t); got != tt.want {
+				t.Errorf("windowContains(%v, %s) = %v, want %v", tt.window, tt.t.Format("Mon 15:04"), got, tt.want)
+			}
+		})
+	}
+}
+
+// offNow is a schedule that never includes the current time: a single day
+// two days from today
+func offNow() *model.UpdateScheduleRequest {
+	day := (int(time.Now().UTC().Weekday()) + 2) % 7
+	return &model.UpdateScheduleRequest{Windows: []model.AvailabilityWindow{
+		{Days: []int{day}, StartTime: "00:00", EndTime: "23:59"},
+	}}
+}
+
+func TestMenuScheduleHidesItemFromCustomersOnly(t *testing.T) {
+	f := newFixture(t)
+	always := f.item("Thali", nil)
+	breakfast := f.item("Poha", nil)
+	if err := f.menu.UpdateMenuItemSchedule(f.ctx, breakfast.ID, offNow(), f.ownerID); err != nil {
+		t.Fatalf("set schedule: %v", err)
+	}
+
+	items, err := f.menu.GetMenuItemsByCategory(f.ctx, f.category.ID)
+	if err != nil {
+		t.Fatalf("list items: %v", err)
+	}
+	if len(items) != 1 || items[0].ID != always.ID {
+		t.Fatalf("public listing has %d items, want only %q", len(items), always.Name)
+	}
+
+	_, err = f.order("customer-1", breakfast, 1)
+	wantKind(t, err, apperr.KindConflict)
+
+	menu, err := f.menu.GetFullMenu(f.ctx, f.restaurant.ID, f.ownerID)
+	if err != nil {
+		t.Fatalf("full menu: %v", err)
+	}
+	if len(menu) != 1 || len(menu[0].Items) != 2 {
+		t.Fatalf("full menu has %d categories, want 1 with both items", len(menu))
+	}
+	for _, item := range menu[0].Items {
+		if item.ID == breakfast.ID && len(item.Schedule) != 1 {
+			t.Errorf("full menu shows %q with %d windows, want 1", item.Name, len(item.Schedule))
+		}
+	}
+
+	_, err = f.menu.GetFullMenu(f.ctx, f.restaurant.ID, "customer-1")
+	wantKind(t, err, apperr.KindForbidden)
+}
+
+func TestMenuScheduleHidesCategory(t *testing.T) {
+	f := newFixture(t)
+	item := f.item("Thali", nil)
+	if err := f.menu.UpdateCategorySchedule(f.ctx, f.category.ID, offNow(), f.ownerID); err != nil {
+		t.Fatalf("set schedule: %v", err)
+	}
+
+	categories, err := f.menu.GetCategoriesByRestaurant(f.ctx, f.restaurant.ID)
+	if err != nil {
+		t.Fatalf("list categories: %v", err)
+	}
+	if len(categories) != 0 {
+		t.Errorf("public listing has %d categories, want none", len(categories))
+	}
+
+	items, err := f.menu.GetMenuItemsByCategory(f.ctx, f.category.ID)
+	if err != nil {
+		t.Fatalf("list items: %v", err)
+	}
+	if len(items) != 0 {
+		t.Errorf("public listing has %d items in a hidden category, want none", len(items))
+	}
+
+	_, err = f.order("customer-1", item, 1)
+	wantKind(t, err, apperr.KindConflict)
+}
+
+func TestUpdateMenuItemStaleVersion(t *testing.T) {
+	f := newFixture(t)
+	item := f.item("Thali", nil)
+	req := &model.UpdateMenuItemRequest{Name: "Veg Thali", Price: 120, IsAvailable: true}
+
+	if err := f.menu.UpdateMenuItem(f.ctx, item.ID, item.Version, req, f.ownerID); err != nil {
+		t.Fatalf("first update: %v", err)
+	}
+	if got := f.reload(item.ID).Version; got != item.Version+1 {
+		t.Fatalf("version after update = %d, want %d", got, item.Version+1)
+	}
+
+	// The caller's copy is now out of date
+	err := f.menu.UpdateMenuItem(f.ctx, item.ID, item.Version, req, f.ownerID)
+	wantKind(t, err, apperr.KindPreconditionFailed)
+}
+
+// patchRequest decodes body the way the handler does
+func patchRequest(t *testing.T, body string) *model.PatchMenuItemRequest {
+	t.Helper()
+
+	var req model.PatchMenuItemRequest
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("decode %s: %v", body, err)
+	}
+	return &req
+}
+
+func TestPatchMenuItemNullClears(t *testing.T) {
+	f := newFixture(t)
+	item := f.item("Thali", nil)
+	full := `{"description": "Rice, dal and two sabzis", "image_url": "https://example.com/thali.jpg"}`
+	if _, err := f.menu.PatchMenuItem(f.ctx, item.ID, 0, patchRequest(t, full), f.ownerID); err != nil {
+		t.Fatalf("set description: %v", err)
+	}
+
+	// Leaving a field out keeps it
+	got, err := f.menu.PatchMenuItem(f.ctx, item.ID, 0, patchRequest(t, `{"price": 120}`), f.ownerID)
+	if err != nil {
+		t.Fatalf("patch price: %v", err)
+	}
+	if got.Description == "" || got.ImageURL == "" || got.Price != 120 {
+		t.Fatalf("after patching price: %+v, want description and image kept", got)
+	}
+
+	got, err = f.menu.PatchMenuItem(f.ctx, item.ID, 0, patchRequest(t, `{"description": null, "image_url": null}`), f.ownerID)
+	if err != nil {
+		t.Fatalf("clear description: %v", err)
+	}
+	if got.Description != "" || got.ImageURL != "" {
+		t.Errorf("description = %q, image_url = %q, want both cleared", got.Description, got.ImageURL)
+	}
+	if got.Name != "Thali" || got.Price != 120 {
+		t.Errorf("name = %q, price = %v, want them untouched", got.Name, got.Price)
+	}
+}
+
+func TestPatchMenuItemRejectsNullForRequiredFields(t *testing.T) {
+	f := newFixture(t)
+	item := f.item("Thali", nil)
+
+	for _, body := range []string{`{"name": null}`, `{"price": null}`, `{"is_available": null}`, `{"is_veg": null}`} {
+		_, err := f.menu.PatchMenuItem(f.ctx, item.ID, 0, patchRequest(t, body), f.ownerID)
+		wantKind(t, err, apperr.KindValidation)
+	}
+	if got := f.reload(item.ID); got.Name != "Thali" || got.Version != item.Version {
+		t.Errorf("item = %+v, want it unchanged", got)
+	}
+}
+
+// racingMenu lets another writer in between the service's read and its
+// conditional write
+type racingMenu struct {
+	repository.MenuStore
+	race func()
+}
+
+func (m racingMenu) UpdateMenuItem(ctx context.Context, id string, version int, req *model.UpdateMenuItemRequest) error {
+	m.race()
+	return m.MenuStore.UpdateMenuItem(ctx, id, version, req)
+}
+
+func TestUpdateMenuItemLosesRace(t *testing.T) {
+	f := newFixture(t)
+	item := f.item("Thali", nil)
+	req := &model.UpdateMenuItemRequest{Name: "Veg Thali", Price: 120, IsAvailable: true}
+
+	raced := false
+	menu := NewMenuService(f.stores.Restaurants, racingMenu{MenuStore: f.stores.Menu, race: func() {
+		if raced {
+			return
+		}
+		raced = true
+		other := &model.UpdateMenuItemRequest{Name: "Thali Special", Price: 150, IsAvailable: true}
+		if err := f.stores.Menu.UpdateMenuItem(f.ctx, item.ID, item.Version, other); err != nil {
+			t.Fatalf("racing update: %v", err)
+		}
+	}}, f.authz)
+
+	err := menu.UpdateMenuItem(f.ctx, item.ID, item.Version, req, f.ownerID)
+	wantKind(t, err, apperr.KindPreconditionFailed)
+	if got := f.reload(item.ID).Name; got != "Thali Special" {
+		t.Errorf("name = %q, want the racing writer's %q", got, "Thali Special")
+	}
+}
+
+func TestResetDailyStock(t *testing.T) {
+	f := newFixture(t)
+	refilled := f.item("Biryani", intPtr(2))
+	retired := f.item("Special", intPtr(1))
+
+	if _, err := f.order("customer-1", refilled, 2); err != nil {
+		t.Fatalf("order biryani: %v", err)
+	}
+	if _, err := f.order("customer-1", retired, 1); err != nil {
+		t.Fatalf("order special: %v", err)
+	}
+	// The special is sold out and won't be made again tomorrow
+	if err := f.menu.UpdateMenuItemStock(f.ctx, retired.ID, &model.UpdateStockRequest{
+		StockQuantity: intPtr(0),
+		DailyStock:    intPtr(0),
+	}, f.ownerID); err != nil {
+		t.Fatalf("set stock: %v", err)
+	}
+
+	if err := f.menu.ResetDailyStock(f.ctx); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+
+	got := f.reload(refilled.ID)
+	if !got.IsAvailable || got.StockQuantity == nil || *got.StockQuantity != 2 {
+		t.Errorf("biryani after reset: available=%v stock=%v, want available with 2", got.IsAvailable, got.StockQuantity)
+	}
+	got = f.reload(retired.ID)
+	if got.IsAvailable {
+		t.Errorf("special is back on with a daily stock of 0")
+	}
+}
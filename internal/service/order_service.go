@@ -1,70 +1,134 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"log/slog"
+	"quickbite/config"
+	"quickbite/internal/apperr"
+	"quickbite/internal/authz"
 	"quickbite/internal/model"
+	"quickbite/internal/payment"
 	"quickbite/internal/repository"
+	"quickbite/internal/tracing"
+	"quickbite/internal/validate"
+	"slices"
+	"time"
 )
 
-// CreateOrder validates items, calculates total, and creates order with items
-func CreateOrder(req *model.CreateOrderRequest, userID string) (*model.OrderWithDetails, error) {
-	// Validate request
-	if req.RestaurantID == "" {
-		return nil, errors.New("restaurant_id is required")
-	}
-	if len(req.Items) == 0 {
-		return nil, errors.New("order must contain at least one item")
-	}
-	if req.DeliveryAddress == "" {
-		return nil, errors.New("delivery_address is required")
+// autoRejectBatch caps how many unanswered orders one round rejects
+const autoRejectBatch = 100
+
+// orderTransitions lists the statuses staff may move an order to from
+// each open one. Orders only move forward, so confirming (and issuing the
+// delivery code) happens once, and they can't be cancelled once picked up.
+var orderTransitions = map[string][]string{
+	"pending":          {"confirmed", "cancelled"},
+	"confirmed":        {"preparing", "cancelled"},
+	"preparing":        {"ready", "cancelled"},
+	"ready":            {"out_for_delivery", "cancelled"},
+	"out_for_delivery": {"delivered"},
+}
+
+type OrderService struct {
+	restaurants repository.RestaurantStore
+	menu        repository.MenuStore
+	orders      repository.OrderStore
+	authz       *Authorizer
+	events      OrderEvents
+	cfg         *config.Config
+}
+
+// NewOrderService wires the stores; events may be nil
+func NewOrderService(restaurants repository.RestaurantStore, menu repository.MenuStore, orders repository.OrderStore, authorizer *Authorizer, events OrderEvents, cfg *config.Config) *OrderService {
+	if events == nil {
+		events = NoopOrderEvents{}
 	}
-	if req.PaymentMethod == "" {
-		return nil, errors.New("payment_method is required")
+	return &OrderService{restaurants: restaurants, menu: menu, orders: orders, authz: authorizer, events: events, cfg: cfg}
+}
+
+// CreateOrder validates items, calculates total, and creates order with items
+func (s *OrderService) CreateOrder(ctx context.Context, req *model.CreateOrderRequest, userID string) (*model.OrderWithDetails, error) {
+	ctx, span := tracing.Start(ctx, "OrderService.CreateOrder")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
 	}
 
 	// Validate restaurant exists and is active
-	restaurant, err := repository.GetRestaurantByID(req.RestaurantID)
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, req.RestaurantID)
 	if err != nil {
-		return nil, errors.New("restaurant not found")
+		return nil, lookupErr(err, "restaurant not found")
 	}
 	if !restaurant.IsActive {
-		return nil, errors.New("restaurant is currently closed")
+		return nil, apperr.Conflict("restaurant is currently closed")
 	}
 
+	// Menus can be time-restricted, so check items against the restaurant's clock
+	now := restaurantNow(restaurant)
+
 	// Validate items and calculate total
 	var totalAmount float64
 	var validatedItems []model.OrderItem
 
 	for _, itemInput := range req.Items {
-		if itemInput.Quantity <= 0 {
-			return nil, errors.New("item quantity must be greater than 0")
-		}
-
 		// Get menu item to verify it exists and is available
-		menuItem, err := repository.GetMenuItemByID(itemInput.MenuItemID)
+		menuItem, err := s.menu.GetMenuItemByID(ctx, itemInput.MenuItemID)
 		if err != nil {
-			return nil, errors.New("menu item not found: " + itemInput.MenuItemID)
+			return nil, lookupErr(err, "menu item not found: "+itemInput.MenuItemID)
 		}
 
 		if !menuItem.IsAvailable {
-			return nil, errors.New("item is not available: " + menuItem.Name)
+			return nil, apperr.Conflict("item is not available: " + menuItem.Name)
+		}
+
+		category, err := s.menu.GetCategoryByID(ctx, menuItem.CategoryID)
+		if err != nil {
+			return nil, lookupErr(err, "category not found for item: "+menuItem.Name)
+		}
+		if category.RestaurantID != req.RestaurantID {
+			return nil, apperr.Validation("item does not belong to this restaurant: " + menuItem.Name)
+		}
+
+		onSchedule, err := isMenuItemScheduledNow(ctx, s.menu, menuItem, category, now)
+		if err != nil {
+			return nil, storeErr(err, "failed to check item availability")
+		}
+		if !onSchedule {
+			return nil, apperr.Conflict("item is not available at this time: " + menuItem.Name)
+		}
+
+		// Early check for a friendly error; the authoritative check happens under row lock
+		if menuItem.StockQuantity != nil && *menuItem.StockQuantity < itemInput.Quantity {
+			stockErr := &repository.OutOfStockError{ItemName: menuItem.Name, Available: *menuItem.StockQuantity}
+			return nil, apperr.Conflict(stockErr.Error())
 		}
 
 		// Calculate item total
 		itemTotal := menuItem.Price * float64(itemInput.Quantity)
 		totalAmount += itemTotal
 
-		// Store validated item
+		// Store validated item with a snapshot of the dish as it is now
 		validatedItems = append(validatedItems, model.OrderItem{
 			MenuItemID: itemInput.MenuItemID,
 			Quantity:   itemInput.Quantity,
-			Price:      menuItem.Price, // Store current price
+			Price:      menuItem.Price,
+			ItemName:   menuItem.Name,
+			ItemImage:  menuItem.ImageURL,
+			IsVeg:      menuItem.IsVeg,
+			Options:    itemInput.Options,
 		})
 	}
 
 	// Set delivery fee (fixed for now, can be dynamic later)
 	deliveryFee := 50.0
 
+	paymentStatus := "pending"
+	if payment.Prepaid(req.PaymentMethod) {
+		paymentStatus = "paid"
+	}
+
 	// Create order
 	order := &model.Order{
 		UserID:          userID,
@@ -74,69 +138,118 @@ func CreateOrder(req *model.CreateOrderRequest, userID string) (*model.OrderWith
 		DeliveryFee:     deliveryFee,
 		DeliveryAddress: req.DeliveryAddress,
 		PaymentMethod:   req.PaymentMethod,
-		PaymentStatus:   "pending", // Will change to "paid" after payment gateway integration
+		PaymentStatus:   paymentStatus,
 	}
 
-	// Insert order into database
-	if err := repository.CreateOrder(order); err != nil {
-		return nil, errors.New("failed to create order")
-	}
-
-	// Insert order items
-	for i := range validatedItems {
-		validatedItems[i].OrderID = order.ID
-		if err := repository.CreateOrderItem(&validatedItems[i]); err != nil {
-			// TODO: In production, wrap this in a transaction and rollback if items fail
-			return nil, errors.New("failed to create order items")
+	// Insert order and items in one transaction, reserving stock as we go
+	if err := s.orders.CreateOrder(ctx, order, validatedItems); err != nil {
+		var stockErr *repository.OutOfStockError
+		if errors.As(err, &stockErr) {
+			return nil, apperr.Conflict(stockErr.Error())
 		}
+		return nil, storeErr(err, "failed to create order")
 	}
+	s.events.OrderCreated(ctx, order)
 
 	// Fetch and return complete order details
-	orderDetails, err := repository.GetOrderWithDetails(order.ID)
+	orderDetails, err := s.orders.GetOrderWithDetails(ctx, order.ID)
 	if err != nil {
-		return nil, errors.New("order created but failed to fetch details")
+		return nil, storeErr(err, "order created but failed to fetch details")
 	}
 
 	return orderDetails, nil
 }
 
-// GetOrderByID fetches order details for a user
-func GetOrderByID(orderID string, userID string) (*model.OrderWithDetails, error) {
-	orderDetails, err := repository.GetOrderWithDetails(orderID)
+// GetOrderByID fetches order details for the customer who placed it or
+// staff of the restaurant
+func (s *OrderService) GetOrderByID(ctx context.Context, orderID string, userID string) (*model.OrderWithDetails, error) {
+	ctx, span := tracing.Start(ctx, "OrderService.GetOrderByID")
+	defer span.End()
+
+	orderDetails, err := s.orders.GetOrderWithDetails(ctx, orderID)
 	if err != nil {
-		return nil, errors.New("order not found")
+		return nil, lookupErr(err, "order not found")
 	}
 
-	// Verify user owns this order
 	if orderDetails.UserID != userID {
-		return nil, errors.New("unauthorized: you don't own this order")
+		if err := s.authz.Require(ctx, orderDetails.RestaurantID, userID, authz.ViewOrders); err != nil {
+			return nil, apperr.Forbidden("you don't own this order")
+		}
+		// Staff must get the code from the customer at the door
+		orderDetails.DeliveryCode = ""
 	}
+	orderDetails.ETA = s.eta(&orderDetails.Order)
 
 	return orderDetails, nil
 }
 
 // GetMyOrders fetches all orders for a user
-func GetMyOrders(userID string) ([]model.OrderWithDetails, error) {
-	return repository.GetOrdersByUser(userID)
+func (s *OrderService) GetMyOrders(ctx context.Context, userID string) ([]model.OrderWithDetails, error) {
+	ctx, span := tracing.Start(ctx, "OrderService.GetMyOrders")
+	defer span.End()
+
+	orders, err := s.orders.GetOrdersByUser(ctx, userID)
+	if err != nil {
+		return nil, storeErr(err, "failed to fetch orders")
+	}
+	for i := range orders {
+		orders[i].ETA = s.eta(&orders[i].Order)
+	}
+	return orders, nil
 }
 
-// GetRestaurantOrders fetches all orders for a restaurant (owner only)
-func GetRestaurantOrders(restaurantID string, userID string) ([]model.OrderWithDetails, error) {
-	// Verify user owns the restaurant
-	restaurant, err := repository.GetRestaurantByID(restaurantID)
+// GetRestaurantOrders fetches all orders for a restaurant (staff only)
+func (s *OrderService) GetRestaurantOrders(ctx context.Context, restaurantID string, userID string) ([]model.OrderWithDetails, error) {
+	ctx, span := tracing.Start(ctx, "OrderService.GetRestaurantOrders")
+	defer span.End()
+
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, restaurantID)
+	if err != nil {
+		return nil, lookupErr(err, "restaurant not found")
+	}
+
+	if err := s.authz.Require(ctx, restaurant.ID, userID, authz.ViewOrders); err != nil {
+		return nil, err
+	}
+
+	orders, err := s.orders.GetOrdersByRestaurant(ctx, restaurantID)
+	if err != nil {
+		return nil, storeErr(err, "failed to fetch orders")
+	}
+	for i := range orders {
+		orders[i].ETA = s.eta(&orders[i].Order)
+	}
+	return orders, nil
+}
+
+// GetRevenue totals the restaurant's delivered orders placed in [from, to)
+func (s *OrderService) GetRevenue(ctx context.Context, restaurantID string, from, to *time.Time, userID string) (*model.RevenueSummary, error) {
+	ctx, span := tracing.Start(ctx, "OrderService.GetRevenue")
+	defer span.End()
+
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, restaurantID)
 	if err != nil {
-		return nil, errors.New("restaurant not found")
+		return nil, lookupErr(err, "restaurant not found")
 	}
 
-	if restaurant.OwnerID != userID {
-		return nil, errors.New("unauthorized: you don't own this restaurant")
+	if err := s.authz.Require(ctx, restaurant.ID, userID, authz.ViewRevenue); err != nil {
+		return nil, err
 	}
 
-	return repository.GetOrdersByRestaurant(restaurantID)
+	summary, err := s.orders.GetRevenue(ctx, restaurantID, from, to)
+	if err != nil {
+		return nil, storeErr(err, "failed to fetch revenue")
+	}
+	return summary, nil
 }
 
-// UpdateOrderStatus allows restaurant owners to update order status
-func UpdateOrderStatus(orderID string, newStatus string, userID string) error {
+// UpdateOrderStatus allows restaurant staff to update order status.
+// Confirming an order gives it a delivery code, which deliveryCode must
+// match to mark it delivered.
+func (s *OrderService) UpdateOrderStatus(ctx context.Context, orderID string, newStatus string, deliveryCode string, userID string) error {
+	ctx, span := tracing.Start(ctx, "OrderService.UpdateOrderStatus")
+	defer span.End()
+
 	// Validate status
 	validStatuses := map[string]bool{
 		"pending":          true,
@@ -149,52 +262,294 @@ func UpdateOrderStatus(orderID string, newStatus string, userID string) error {
 	}
 
 	if !validStatuses[newStatus] {
-		return errors.New("invalid order status")
+		return apperr.InvalidField("status", "is not a valid order status")
+	}
+
+	// Get order to check permissions
+	order, err := s.orders.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return lookupErr(err, "order not found")
+	}
+
+	// Verify user may update orders at the restaurant it belongs to
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, order.RestaurantID)
+	if err != nil {
+		return lookupErr(err, "restaurant not found")
+	}
+
+	if err := s.authz.Require(ctx, restaurant.ID, userID, authz.UpdateOrderStatus); err != nil {
+		return err
+	}
+
+	next, open := orderTransitions[order.Status]
+	if !open {
+		return apperr.Conflict("cannot update status of completed order")
+	}
+	if order.Status == newStatus {
+		return apperr.Conflict("order is already " + newStatus)
+	}
+	if !slices.Contains(next, newStatus) {
+		return apperr.Conflict("cannot move a " + order.Status + " order to " + newStatus)
+	}
+
+	// Both writes only land if the order is still in the status checked
+	// above, so two racing updates can't both win.
+	// Cancelling puts the reserved stock back
+	if newStatus == "cancelled" {
+		if err := s.orders.CancelOrder(ctx, orderID, order.Status); err != nil {
+			return statusErr(err, "failed to cancel order")
+		}
+		s.events.OrderStatusChanged(ctx, order, newStatus)
+		s.events.OrderCancelled(ctx, order, "restaurant")
+		return nil
+	}
+
+	// Confirming is when the delivery code is issued
+	if newStatus == "confirmed" {
+		if err := s.confirm(ctx, order, 0, userID); err != nil {
+			return err
+		}
+		s.events.OrderStatusChanged(ctx, order, newStatus)
+		return nil
+	}
+
+	if newStatus == "delivered" {
+		if err := verifyDeliveryCode(ctx, s.orders, order, deliveryCode, userID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.orders.UpdateOrderStatus(ctx, orderID, order.Status, newStatus); err != nil {
+		return statusErr(err, "failed to update order status")
+	}
+	s.events.OrderStatusChanged(ctx, order, newStatus)
+	return nil
+}
+
+// confirm moves a pending order to confirmed and issues its delivery code;
+// prepMinutes is 0 when the restaurant gave no estimate
+func (s *OrderService) confirm(ctx context.Context, order *model.Order, prepMinutes int, userID string) error {
+	code, err := newDeliveryCode()
+	if err != nil {
+		return apperr.Internal("failed to update order status", err)
+	}
+	if err := s.orders.ConfirmOrder(ctx, order.ID, order.Status, "confirmed", code, prepMinutes); err != nil {
+		return statusErr(err, "failed to update order status")
+	}
+
+	event := &model.DeliveryCodeEvent{OrderID: order.ID, UserID: userID, Action: "issued"}
+	if err := s.orders.LogDeliveryCodeEvent(ctx, event); err != nil {
+		return storeErr(err, "order updated but failed to record its delivery code")
+	}
+	return nil
+}
+
+// AcceptOrder confirms a pending order with the restaurant's estimate of
+// how long it will take to prepare, which gives the customer an ETA
+func (s *OrderService) AcceptOrder(ctx context.Context, orderID string, req *model.AcceptOrderRequest, userID string) (*model.Order, error) {
+	ctx, span := tracing.Start(ctx, "OrderService.AcceptOrder")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
 	}
 
-	// Get order to verify ownership
-	order, err := repository.GetOrderByID(orderID)
+	order, err := s.pendingOrder(ctx, orderID, userID)
 	if err != nil {
-		return errors.New("order not found")
+		return nil, err
 	}
 
-	// Verify user owns the restaurant this order belongs to
-	restaurant, err := repository.GetRestaurantByID(order.RestaurantID)
+	if err := s.confirm(ctx, order, req.PrepMinutes, userID); err != nil {
+		return nil, err
+	}
+	s.events.OrderStatusChanged(ctx, order, "confirmed")
+
+	accepted, err := s.orders.GetOrderByID(ctx, orderID)
 	if err != nil {
-		return errors.New("restaurant not found")
+		return nil, storeErr(err, "order accepted but failed to fetch it")
+	}
+	accepted.DeliveryCode = ""
+	accepted.ETA = s.eta(accepted)
+	return accepted, nil
+}
+
+// RejectOrder turns down a pending order with a reason the customer is
+// shown. The stock goes back, and a paid order is refunded.
+func (s *OrderService) RejectOrder(ctx context.Context, orderID string, req *model.RejectOrderRequest, userID string) error {
+	ctx, span := tracing.Start(ctx, "OrderService.RejectOrder")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return err
+	}
+
+	order, err := s.pendingOrder(ctx, orderID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.orders.RejectOrder(ctx, orderID, req.Reason); err != nil {
+		return statusErr(err, "failed to reject order")
+	}
+	s.events.OrderStatusChanged(ctx, order, "cancelled")
+	s.events.OrderCancelled(ctx, order, "restaurant")
+	return nil
+}
+
+// RejectUnanswered rejects orders the restaurant hasn't accepted within
+// OrderAcceptTimeout, with reason timeout, so customers aren't left
+// waiting on a restaurant that isn't watching its orders
+func (s *OrderService) RejectUnanswered(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "OrderService.RejectUnanswered")
+	defer span.End()
+
+	orders, err := s.orders.GetUnansweredOrders(ctx, s.cfg.OrderAcceptTimeout, autoRejectBatch)
+	if err != nil {
+		return err
+	}
+
+	rejected := 0
+	for i := range orders {
+		order := &orders[i]
+		// The restaurant answered in the meantime
+		if err := s.orders.RejectOrder(ctx, order.ID, "timeout"); errors.Is(err, repository.ErrStale) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		s.events.OrderStatusChanged(ctx, order, "cancelled")
+		s.events.OrderCancelled(ctx, order, "timeout")
+		rejected++
+	}
+
+	if rejected > 0 {
+		slog.InfoContext(ctx, "rejected orders nobody accepted", "orders", rejected)
 	}
+	return nil
+}
 
-	if restaurant.OwnerID != userID {
-		return errors.New("unauthorized: you don't own this restaurant")
+// pendingOrder loads an order waiting for the restaurant's answer, which
+// staff who can update its status may give
+func (s *OrderService) pendingOrder(ctx context.Context, orderID string, userID string) (*model.Order, error) {
+	order, err := s.orders.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, lookupErr(err, "order not found")
+	}
+	if err := s.authz.Require(ctx, order.RestaurantID, userID, authz.UpdateOrderStatus); err != nil {
+		return nil, err
 	}
+	if order.Status != "pending" {
+		return nil, apperr.Conflict("order is no longer waiting for an answer")
+	}
+	return order, nil
+}
 
-	// Validate status transitions (state machine logic)
-	// For now, allow any transition, but in production you'd enforce rules like:
-	// - Can't go from "delivered" to "pending"
-	// - Can't change status after "delivered" or "cancelled"
+// eta is when the customer can expect an accepted order: the prep estimate
+// plus DeliveryEstimate. Orders accepted without an estimate, and finished
+// orders, have none.
+func (s *OrderService) eta(order *model.Order) *time.Time {
+	if order.AcceptedAt == nil || order.PrepMinutes == nil {
+		return nil
+	}
 	if order.Status == "delivered" || order.Status == "cancelled" {
-		return errors.New("cannot update status of completed order")
+		return nil
+	}
+	eta := order.AcceptedAt.Add(time.Duration(*order.PrepMinutes)*time.Minute + s.cfg.DeliveryEstimate)
+	return &eta
+}
+
+// ReissueDeliveryCode gives the customer a new delivery code for an order
+// still on its way, such as after too many wrong entries locked the old one
+func (s *OrderService) ReissueDeliveryCode(ctx context.Context, orderID string, userID string) (*model.Order, error) {
+	ctx, span := tracing.Start(ctx, "OrderService.ReissueDeliveryCode")
+	defer span.End()
+
+	order, err := s.orders.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, lookupErr(err, "order not found")
+	}
+	if order.UserID != userID {
+		return nil, apperr.Forbidden("you don't own this order")
+	}
+	switch order.Status {
+	case "confirmed", "preparing", "ready", "out_for_delivery":
+	default:
+		return nil, apperr.Conflict("order is not on its way")
 	}
 
-	return repository.UpdateOrderStatus(orderID, newStatus)
+	code, err := newDeliveryCode()
+	if err != nil {
+		return nil, apperr.Internal("failed to issue delivery code", err)
+	}
+	if err := s.orders.ReissueDeliveryCode(ctx, orderID, code); err != nil {
+		return nil, storeErr(err, "failed to issue delivery code")
+	}
+	event := &model.DeliveryCodeEvent{OrderID: orderID, UserID: userID, Action: "reissued"}
+	if err := s.orders.LogDeliveryCodeEvent(ctx, event); err != nil {
+		return nil, storeErr(err, "delivery code issued but failed to record it")
+	}
+
+	order.DeliveryCode = code
+	order.DeliveryCodeAttempts = 0
+	return order, nil
+}
+
+// GetDeliveryCodeEvents shows an order's delivery code audit trail to the
+// customer who placed it or staff of the restaurant
+func (s *OrderService) GetDeliveryCodeEvents(ctx context.Context, orderID string, userID string) ([]model.DeliveryCodeEvent, error) {
+	ctx, span := tracing.Start(ctx, "OrderService.GetDeliveryCodeEvents")
+	defer span.End()
+
+	order, err := s.orders.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, lookupErr(err, "order not found")
+	}
+	if order.UserID != userID {
+		if err := s.authz.Require(ctx, order.RestaurantID, userID, authz.ViewOrders); err != nil {
+			return nil, apperr.Forbidden("you don't own this order")
+		}
+	}
+
+	events, err := s.orders.GetDeliveryCodeEvents(ctx, orderID)
+	if err != nil {
+		return nil, storeErr(err, "failed to fetch delivery code history")
+	}
+	return events, nil
 }
 
 // CancelOrder allows customers to cancel their order (only if status is pending or confirmed)
-func CancelOrder(orderID string, userID string) error {
-	order, err := repository.GetOrderByID(orderID)
+func (s *OrderService) CancelOrder(ctx context.Context, orderID string, userID string) error {
+	ctx, span := tracing.Start(ctx, "OrderService.CancelOrder")
+	defer span.End()
+
+	order, err := s.orders.GetOrderByID(ctx, orderID)
 	if err != nil {
-		return errors.New("order not found")
+		return lookupErr(err, "order not found")
 	}
 
 	// Verify user owns this order
 	if order.UserID != userID {
-		return errors.New("unauthorized: you don't own this order")
+		return apperr.Forbidden("you don't own this order")
 	}
 
 	// Only allow cancellation if order is pending or confirmed
 	if order.Status != "pending" && order.Status != "confirmed" {
-		return errors.New("cannot cancel order in current status")
+		return apperr.Conflict("cannot cancel order in current status")
 	}
 
-	return repository.UpdateOrderStatus(orderID, "cancelled")
+	if err := s.orders.CancelOrder(ctx, orderID, order.Status); err != nil {
+		return statusErr(err, "failed to cancel order")
+	}
+	s.events.OrderStatusChanged(ctx, order, "cancelled")
+	s.events.OrderCancelled(ctx, order, "customer")
+	return nil
+}
+
+// statusErr is storeErr for status compare-and-swaps: losing the race to
+// another update is a conflict the caller can retry after reloading
+func statusErr(err error, message string) error {
+	if errors.Is(err, repository.ErrStale) {
+		return apperr.Conflict("order status changed, reload and try again")
+	}
+	return storeErr(err, message)
 }
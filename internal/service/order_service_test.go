@@ -0,0 +1,324 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"quickbite/internal/apperr"
+	"quickbite/internal/model"
+)
+
+func TestCreateOrderReservesStock(t *testing.T) {
+	f := newFixture(t)
+	item := f.item("Biryani", intPtr(3))
+
+	if _, err := f.order("customer-1", item, 2); err != nil {
+		t.Fatalf("order: %v", err)
+	}
+	got := f.reload(item.ID)
+	if got.StockQuantity == nil || *got.StockQuantity != 1 || !got.IsAvailable {
+		t.Fatalf("after ordering 2 of 3: stock=%v available=%v, want 1 left and available", got.StockQuantity, got.IsAvailable)
+	}
+
+	// More than is left is refused and reserves nothing
+	_, err := f.order("customer-2", item, 2)
+	wantKind(t, err, apperr.KindConflict)
+	if got := f.reload(item.ID); *got.StockQuantity != 1 {
+		t.Fatalf("a refused order changed stock to %d", *got.StockQuantity)
+	}
+
+	if _, err := f.order("customer-2", item, 1); err != nil {
+		t.Fatalf("order the last one: %v", err)
+	}
+	got = f.reload(item.ID)
+	if *got.StockQuantity != 0 || got.IsAvailable {
+		t.Fatalf("after selling out: stock=%d available=%v, want 0 and unavailable", *got.StockQuantity, got.IsAvailable)
+	}
+
+	_, err = f.order("customer-3", item, 1)
+	wantKind(t, err, apperr.KindConflict)
+}
+
+func TestCancelOrderRestocks(t *testing.T) {
+	f := newFixture(t)
+	item := f.item("Biryani", intPtr(2))
+
+	order, err := f.order("customer-1", item, 2)
+	if err != nil {
+		t.Fatalf("order: %v", err)
+	}
+	if f.reload(item.ID).IsAvailable {
+		t.Fatalf("sold out item still available")
+	}
+
+	// Only the customer can cancel their order
+	err = f.orders.CancelOrder(f.ctx, order.ID, "customer-2")
+	wantKind(t, err, apperr.KindForbidden)
+
+	if err := f.orders.CancelOrder(f.ctx, order.ID, "customer-1"); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	got := f.reload(item.ID)
+	if got.StockQuantity == nil || *got.StockQuantity != 2 || !got.IsAvailable {
+		t.Fatalf("after cancel: stock=%v available=%v, want 2 back and available", got.StockQuantity, got.IsAvailable)
+	}
+
+	// A second cancel finds the order already cancelled, and restocks nothing
+	err = f.orders.CancelOrder(f.ctx, order.ID, "customer-1")
+	wantKind(t, err, apperr.KindConflict)
+	if got := f.reload(item.ID); *got.StockQuantity != 2 {
+		t.Fatalf("second cancel changed stock to %d", *got.StockQuantity)
+	}
+}
+
+func TestCancelOrderKeepsItemsSwitchedOffByHand(t *testing.T) {
+	f := newFixture(t)
+	item := f.item("Biryani", intPtr(1))
+
+	order, err := f.order("customer-1", item, 1)
+	if err != nil {
+		t.Fatalf("order: %v", err)
+	}
+	// The owner takes the sold out item off the menu for good
+	off := false
+	if _, err := f.menu.SetMenuItemAvailability(f.ctx, item.ID, 0, &model.UpdateAvailabilityRequest{IsAvailable: &off}, f.ownerID); err != nil {
+		t.Fatalf("switch off: %v", err)
+	}
+
+	if err := f.orders.CancelOrder(f.ctx, order.ID, "customer-1"); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	got := f.reload(item.ID)
+	if *got.StockQuantity != 1 || got.IsAvailable {
+		t.Errorf("after cancel: stock=%d available=%v, want 1 back and still off", *got.StockQuantity, got.IsAvailable)
+	}
+}
+
+func TestUpdateOrderStatusTransitions(t *testing.T) {
+	f := newFixture(t)
+	item := f.item("Thali", nil)
+	order, err := f.order("customer-1", item, 1)
+	if err != nil {
+		t.Fatalf("order: %v", err)
+	}
+
+	// A pending order stays pending, without a delivery code, until confirmed
+	for _, status := range []string{"pending", "preparing"} {
+		err := f.orders.UpdateOrderStatus(f.ctx, order.ID, status, "", f.ownerID)
+		wantKind(t, err, apperr.KindConflict)
+	}
+	stored, err := f.stores.Orders.GetOrderByID(f.ctx, order.ID)
+	if err != nil {
+		t.Fatalf("reload order: %v", err)
+	}
+	if stored.Status != "pending" || stored.DeliveryCode != "" || stored.AcceptedAt != nil {
+		t.Fatalf("refused updates changed the order: status=%s code=%q accepted=%v", stored.Status, stored.DeliveryCode, stored.AcceptedAt)
+	}
+
+	if err := f.orders.UpdateOrderStatus(f.ctx, order.ID, "confirmed", "", f.ownerID); err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	stored, _ = f.stores.Orders.GetOrderByID(f.ctx, order.ID)
+	if stored.DeliveryCode == "" || stored.AcceptedAt == nil {
+		t.Fatalf("confirmed order has code %q and accepted_at %v, want both set", stored.DeliveryCode, stored.AcceptedAt)
+	}
+
+	err = f.orders.UpdateOrderStatus(f.ctx, order.ID, "confirmed", "", f.ownerID)
+	wantKind(t, err, apperr.KindConflict)
+
+	if err := f.orders.UpdateOrderStatus(f.ctx, order.ID, "preparing", "", f.ownerID); err != nil {
+		t.Fatalf("preparing: %v", err)
+	}
+}
+
+func TestAcceptOrderSetsETA(t *testing.T) {
+	f := newFixture(t)
+	f.addMember("cook-1", "kitchen")
+	f.addMember("viewer-1", "viewer")
+	order, err := f.order("customer-1", f.item("Thali", nil), 1)
+	if err != nil {
+		t.Fatalf("order: %v", err)
+	}
+
+	_, err = f.orders.AcceptOrder(f.ctx, order.ID, &model.AcceptOrderRequest{PrepMinutes: 20}, "viewer-1")
+	wantKind(t, err, apperr.KindForbidden)
+	_, err = f.orders.AcceptOrder(f.ctx, order.ID, &model.AcceptOrderRequest{}, "cook-1")
+	wantKind(t, err, apperr.KindValidation)
+
+	accepted, err := f.orders.AcceptOrder(f.ctx, order.ID, &model.AcceptOrderRequest{PrepMinutes: 20}, "cook-1")
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	if accepted.Status != "confirmed" || accepted.AcceptedAt == nil {
+		t.Fatalf("accepted order has status %s and accepted_at %v", accepted.Status, accepted.AcceptedAt)
+	}
+	// Prep time plus the fixture's 30 minute delivery estimate
+	want := accepted.AcceptedAt.Add(50 * time.Minute)
+	if accepted.ETA == nil || !accepted.ETA.Equal(want) {
+		t.Errorf("ETA = %v, want %v", accepted.ETA, want)
+	}
+	if accepted.DeliveryCode != "" {
+		t.Errorf("staff were shown the delivery code")
+	}
+
+	seen, err := f.orders.GetOrderByID(f.ctx, order.ID, "customer-1")
+	if err != nil {
+		t.Fatalf("customer reading the order: %v", err)
+	}
+	if seen.ETA == nil || !seen.ETA.Equal(want) || seen.DeliveryCode == "" {
+		t.Errorf("customer sees ETA %v and code %q, want %v and a code", seen.ETA, seen.DeliveryCode, want)
+	}
+
+	_, err = f.orders.AcceptOrder(f.ctx, order.ID, &model.AcceptOrderRequest{PrepMinutes: 20}, "cook-1")
+	wantKind(t, err, apperr.KindConflict)
+}
+
+func TestRejectOrderRestocks(t *testing.T) {
+	f := newFixture(t)
+	item := f.item("Biryani", intPtr(1))
+	order, err := f.order("customer-1", item, 1)
+	if err != nil {
+		t.Fatalf("order: %v", err)
+	}
+
+	err = f.orders.RejectOrder(f.ctx, order.ID, &model.RejectOrderRequest{Reason: "bored"}, f.ownerID)
+	wantKind(t, err, apperr.KindValidation)
+
+	if err := f.orders.RejectOrder(f.ctx, order.ID, &model.RejectOrderRequest{Reason: "too_busy"}, f.ownerID); err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+	stored, err := f.stores.Orders.GetOrderByID(f.ctx, order.ID)
+	if err != nil {
+		t.Fatalf("reload order: %v", err)
+	}
+	if stored.Status != "cancelled" || stored.RejectionReason != "too_busy" {
+		t.Errorf("rejected order has status %s and reason %q", stored.Status, stored.RejectionReason)
+	}
+	if got := f.reload(item.ID); *got.StockQuantity != 1 || !got.IsAvailable {
+		t.Errorf("after reject: stock=%d available=%v, want 1 back and available", *got.StockQuantity, got.IsAvailable)
+	}
+
+	err = f.orders.RejectOrder(f.ctx, order.ID, &model.RejectOrderRequest{Reason: "too_busy"}, f.ownerID)
+	wantKind(t, err, apperr.KindConflict)
+}
+
+func TestRejectUnanswered(t *testing.T) {
+	f := newFixture(t)
+	item := f.item("Thali", nil)
+	waiting, err := f.order("customer-1", item, 1)
+	if err != nil {
+		t.Fatalf("order: %v", err)
+	}
+	answered, err := f.order("customer-2", item, 1)
+	if err != nil {
+		t.Fatalf("order: %v", err)
+	}
+	if _, err := f.orders.AcceptOrder(f.ctx, answered.ID, &model.AcceptOrderRequest{PrepMinutes: 15}, f.ownerID); err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+
+	status := func(id string) (string, string) {
+		t.Helper()
+		o, err := f.stores.Orders.GetOrderByID(f.ctx, id)
+		if err != nil {
+			t.Fatalf("reload order: %v", err)
+		}
+		return o.Status, o.RejectionReason
+	}
+
+	// Still inside the fixture's 10 minute timeout
+	if err := f.orders.RejectUnanswered(f.ctx); err != nil {
+		t.Fatalf("reject unanswered: %v", err)
+	}
+	if got, _ := status(waiting.ID); got != "pending" {
+		t.Fatalf("order rejected before the timeout: status %s", got)
+	}
+
+	f.orders.cfg.OrderAcceptTimeout = 0
+	if err := f.orders.RejectUnanswered(f.ctx); err != nil {
+		t.Fatalf("reject unanswered: %v", err)
+	}
+	if got, reason := status(waiting.ID); got != "cancelled" || reason != "timeout" {
+		t.Errorf("unanswered order has status %s and reason %q, want cancelled for timeout", got, reason)
+	}
+	if got, _ := status(answered.ID); got != "confirmed" {
+		t.Errorf("accepted order has status %s, want confirmed", got)
+	}
+}
+
+func TestUpdateOrderStatusOnlyMovesForward(t *testing.T) {
+	f := newFixture(t)
+	item := f.item("Thali", nil)
+	order, err := f.order("customer-1", item, 1)
+	if err != nil {
+		t.Fatalf("order: %v", err)
+	}
+
+	for _, status := range []string{"confirmed", "preparing", "ready"} {
+		if err := f.orders.UpdateOrderStatus(f.ctx, order.ID, status, "", f.ownerID); err != nil {
+			t.Fatalf("%s: %v", status, err)
+		}
+	}
+	issued, _ := f.stores.Orders.GetOrderByID(f.ctx, order.ID)
+
+	// Going back, or skipping ahead, is refused and leaves the code alone
+	for _, status := range []string{"pending", "confirmed", "preparing", "delivered"} {
+		err := f.orders.UpdateOrderStatus(f.ctx, order.ID, status, issued.DeliveryCode, f.ownerID)
+		wantKind(t, err, apperr.KindConflict)
+	}
+	stored, _ := f.stores.Orders.GetOrderByID(f.ctx, order.ID)
+	if stored.Status != "ready" || stored.DeliveryCode != issued.DeliveryCode {
+		t.Fatalf("refused updates changed the order: status=%s code=%q, want ready and %q", stored.Status, stored.DeliveryCode, issued.DeliveryCode)
+	}
+
+	// Once picked up the order can only be delivered
+	if err := f.orders.UpdateOrderStatus(f.ctx, order.ID, "out_for_delivery", "", f.ownerID); err != nil {
+		t.Fatalf("out for delivery: %v", err)
+	}
+	err = f.orders.UpdateOrderStatus(f.ctx, order.ID, "cancelled", "", f.ownerID)
+	wantKind(t, err, apperr.KindConflict)
+	if err := f.orders.UpdateOrderStatus(f.ctx, order.ID, "delivered", issued.DeliveryCode, f.ownerID); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	err = f.orders.UpdateOrderStatus(f.ctx, order.ID, "confirmed", "", f.ownerID)
+	wantKind(t, err, apperr.KindConflict)
+
+	events, err := f.stores.Orders.GetDeliveryCodeEvents(f.ctx, order.ID)
+	if err != nil {
+		t.Fatalf("code events: %v", err)
+	}
+	issues := 0
+	for _, e := range events {
+		if e.Action == "issued" {
+			issues++
+		}
+	}
+	if issues != 1 {
+		t.Errorf("delivery code issued %d times, want once", issues)
+	}
+}
+
+func TestRestaurantCancelsOnlyBeforePickup(t *testing.T) {
+	f := newFixture(t)
+	item := f.item("Thali", nil)
+
+	for _, path := range [][]string{
+		{},
+		{"confirmed"},
+		{"confirmed", "preparing"},
+		{"confirmed", "preparing", "ready"},
+	} {
+		order, err := f.order("customer-1", item, 1)
+		if err != nil {
+			t.Fatalf("order: %v", err)
+		}
+		for _, status := range path {
+			if err := f.orders.UpdateOrderStatus(f.ctx, order.ID, status, "", f.ownerID); err != nil {
+				t.Fatalf("%s: %v", status, err)
+			}
+		}
+		if err := f.orders.UpdateOrderStatus(f.ctx, order.ID, "cancelled", "", f.ownerID); err != nil {
+			t.Errorf("cancel after %v: %v", path, err)
+		}
+	}
+}
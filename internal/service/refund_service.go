@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"quickbite/internal/payment"
+	"quickbite/internal/repository"
+	"quickbite/internal/tracing"
+)
+
+// refundBatch caps how many refunds one round sends
+const refundBatch = 100
+
+// RefundService pays back cancelled orders. Cancelling a paid order only
+// marks it refund_pending; Refund sends the money back afterwards, so a
+// provider outage delays refunds instead of failing the cancellation.
+type RefundService struct {
+	orders   repository.OrderStore
+	refunder payment.Refunder
+}
+
+func NewRefundService(orders repository.OrderStore, refunder payment.Refunder) *RefundService {
+	return &RefundService{orders: orders, refunder: refunder}
+}
+
+// Refund runs one round, refunding every order waiting for it. A refund
+// the provider fails stays pending and is tried again next round.
+func (s *RefundService) Refund(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "RefundService.Refund")
+	defer span.End()
+
+	orders, err := s.orders.GetRefundsDue(ctx, refundBatch)
+	if err != nil {
+		return err
+	}
+
+	refunded, failed := 0, 0
+	for _, order := range orders {
+		refund := payment.Refund{
+			OrderID: order.ID,
+			Amount:  order.TotalAmount + order.DeliveryFee,
+			Method:  order.PaymentMethod,
+		}
+		if err := s.refunder.Refund(ctx, refund); err != nil {
+			slog.ErrorContext(ctx, "refund failed", "order_id", order.ID, "error", err)
+			failed++
+			continue
+		}
+
+		// Another instance refunded it too; the order ID as idempotency key
+		// kept the provider from paying twice
+		if err := s.orders.SetPaymentStatus(ctx, order.ID, "refund_pending", "refunded"); err != nil && !errors.Is(err, repository.ErrStale) {
+			return err
+		}
+		refunded++
+	}
+
+	if refunded > 0 || failed > 0 {
+		slog.InfoContext(ctx, "refunded cancelled orders", "orders", refunded, "failed", failed)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"quickbite/internal/model"
+	"quickbite/internal/payment"
+)
+
+// recordingRefunder keeps the refunds it is asked for and fails them
+// while err is set
+type recordingRefunder struct {
+	refunds []payment.Refund
+	err     error
+}
+
+func (r *recordingRefunder) Refund(ctx context.Context, refund payment.Refund) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.refunds = append(r.refunds, refund)
+	return nil
+}
+
+// prepaidOrder places an order paid by card, which counts as charged
+func (f *fixture) prepaidOrder(customerID string, item *model.MenuItem) *model.OrderWithDetails {
+	f.t.Helper()
+
+	order, err := f.orders.CreateOrder(f.ctx, &model.CreateOrderRequest{
+		RestaurantID:    f.restaurant.ID,
+		Items:           []model.OrderItemInput{{MenuItemID: item.ID, Quantity: 1}},
+		DeliveryAddress: "1 Test Street",
+		PaymentMethod:   "card",
+	}, customerID)
+	if err != nil {
+		f.t.Fatalf("order: %v", err)
+	}
+	return order
+}
+
+func (f *fixture) paymentStatus(orderID string) string {
+	f.t.Helper()
+
+	o, err := f.stores.Orders.GetOrderByID(f.ctx, orderID)
+	if err != nil {
+		f.t.Fatalf("reload order: %v", err)
+	}
+	return o.PaymentStatus
+}
+
+func TestRejectedOrdersAreRefunded(t *testing.T) {
+	f := newFixture(t)
+	item := f.item("Thali", nil)
+	refunder := &recordingRefunder{}
+	refunds := NewRefundService(f.stores.Orders, refunder)
+
+	rejected := f.prepaidOrder("customer-1", item)
+	unanswered := f.prepaidOrder("customer-2", item)
+	cash, err := f.order("customer-3", item, 1)
+	if err != nil {
+		t.Fatalf("order: %v", err)
+	}
+	if got := f.paymentStatus(rejected.ID); got != "paid" {
+		t.Fatalf("card order has payment status %s, want paid", got)
+	}
+	if got := f.paymentStatus(cash.ID); got != "pending" {
+		t.Fatalf("cash order has payment status %s, want pending", got)
+	}
+
+	if err := f.orders.RejectOrder(f.ctx, rejected.ID, &model.RejectOrderRequest{Reason: "too_busy"}, f.ownerID); err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+	f.orders.cfg.OrderAcceptTimeout = 0
+	if err := f.orders.RejectUnanswered(f.ctx); err != nil {
+		t.Fatalf("reject unanswered: %v", err)
+	}
+	for _, id := range []string{rejected.ID, unanswered.ID} {
+		if got := f.paymentStatus(id); got != "refund_pending" {
+			t.Errorf("cancelled card order has payment status %s, want refund_pending", got)
+		}
+	}
+
+	// A failed refund waits for the next round
+	refunder.err = errors.New("provider down")
+	if err := refunds.Refund(f.ctx); err != nil {
+		t.Fatalf("refund: %v", err)
+	}
+	if got := f.paymentStatus(rejected.ID); got != "refund_pending" {
+		t.Errorf("failed refund left payment status %s, want refund_pending", got)
+	}
+
+	refunder.err = nil
+	if err := refunds.Refund(f.ctx); err != nil {
+		t.Fatalf("refund: %v", err)
+	}
+	if len(refunder.refunds) != 2 {
+		t.Fatalf("sent %d refunds, want the two card orders", len(refunder.refunds))
+	}
+	for _, refund := range refunder.refunds {
+		if refund.Amount != 150 || refund.Method != "card" {
+			t.Errorf("refund %+v, want 150 by card", refund)
+		}
+	}
+	for _, id := range []string{rejected.ID, unanswered.ID} {
+		if got := f.paymentStatus(id); got != "refunded" {
+			t.Errorf("payment status %s after refund, want refunded", got)
+		}
+	}
+	if got := f.paymentStatus(cash.ID); got != "pending" {
+		t.Errorf("cash order has payment status %s, want it never charged", got)
+	}
+
+	// Nothing is refunded twice
+	if err := refunds.Refund(f.ctx); err != nil {
+		t.Fatalf("refund: %v", err)
+	}
+	if len(refunder.refunds) != 2 {
+		t.Errorf("sent %d refunds after another round, want 2", len(refunder.refunds))
+	}
+}
@@ -1,14 +1,46 @@
 package service
 
 import (
-	"errors"
+	"context"
+	"log/slog"
+	"quickbite/internal/apperr"
+	"quickbite/internal/authz"
 	"quickbite/internal/model"
 	"quickbite/internal/repository"
+	"quickbite/internal/tracing"
+	"quickbite/internal/validate"
+	"time"
 )
 
-func CreateRestaurant(req *model.CreateRestaurantRequest, ownerID string) (*model.Restaurant, error) {
-	if req.Name == "" || req.Address == "" || req.City == "" {
-		return nil, errors.New("name, address and city are required")
+const (
+	defaultOpensAt  = "09:00"
+	defaultTimezone = "Asia/Kolkata"
+
+	staleRestaurant = "restaurant was changed by another request, reload and try again"
+)
+
+type RestaurantService struct {
+	restaurants repository.RestaurantStore
+	authz       *Authorizer
+}
+
+func NewRestaurantService(restaurants repository.RestaurantStore, authorizer *Authorizer) *RestaurantService {
+	return &RestaurantService{restaurants: restaurants, authz: authorizer}
+}
+
+func (s *RestaurantService) CreateRestaurant(ctx context.Context, req *model.CreateRestaurantRequest, ownerID string) (*model.Restaurant, error) {
+	ctx, span := tracing.Start(ctx, "RestaurantService.CreateRestaurant")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	if req.OpensAt == "" {
+		req.OpensAt = defaultOpensAt
+	}
+	if req.Timezone == "" {
+		req.Timezone = defaultTimezone
 	}
 
 	restaurant := &model.Restaurant{
@@ -20,57 +52,182 @@ func CreateRestaurant(req *model.CreateRestaurantRequest, ownerID string) (*mode
 		ImageURL:    req.ImageURL,
 		IsActive:    true,
 		Rating:      0.0,
+		OpensAt:     req.OpensAt,
+		Timezone:    req.Timezone,
 	}
 
-	if err := repository.CreateRestaurant(restaurant); err != nil {
-		return nil, errors.New("failed to create restaurant")
+	if err := s.restaurants.CreateRestaurant(ctx, restaurant); err != nil {
+		return nil, storeErr(err, "failed to create restaurant")
 	}
 
 	return restaurant, nil
 }
 
-func GetRestaurantByID(id string) (*model.Restaurant, error) {
-	restaurant, err := repository.GetRestaurantByID(id)
+func (s *RestaurantService) GetRestaurantByID(ctx context.Context, id string) (*model.Restaurant, error) {
+	ctx, span := tracing.Start(ctx, "RestaurantService.GetRestaurantByID")
+	defer span.End()
+
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, id)
 	if err != nil {
-		return nil, errors.New("restaurant not found")
+		return nil, lookupErr(err, "restaurant not found")
 	}
 	return restaurant, nil
 }
 
-func GetRestaurantsByOwner(ownerID string) ([]model.Restaurant, error) {
-	return repository.GetRestaurantsByOwner(ownerID)
+// GetRestaurantsByMember lists the restaurants userID works at, owned or not
+func (s *RestaurantService) GetRestaurantsByMember(ctx context.Context, userID string) ([]model.Restaurant, error) {
+	ctx, span := tracing.Start(ctx, "RestaurantService.GetRestaurantsByMember")
+	defer span.End()
+
+	restaurants, err := s.restaurants.GetRestaurantsByMember(ctx, userID)
+	if err != nil {
+		return nil, storeErr(err, "failed to fetch restaurants")
+	}
+	return restaurants, nil
+}
+
+func (s *RestaurantService) GetAllRestaurants(ctx context.Context, city string) ([]model.Restaurant, error) {
+	ctx, span := tracing.Start(ctx, "RestaurantService.GetAllRestaurants")
+	defer span.End()
+
+	restaurants, err := s.restaurants.GetAllRestaurants(ctx, city)
+	if err != nil {
+		return nil, storeErr(err, "failed to fetch restaurants")
+	}
+	return restaurants, nil
+}
+
+// UpdateRestaurant replaces the owner-editable fields. version is the one the
+// caller last read; 0 skips the check.
+func (s *RestaurantService) UpdateRestaurant(ctx context.Context, id string, version int, req *model.UpdateRestaurantRequest, userID string) error {
+	ctx, span := tracing.Start(ctx, "RestaurantService.UpdateRestaurant")
+	defer span.End()
+
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, id)
+	if err != nil {
+		return lookupErr(err, "restaurant not found")
+	}
+
+	if err := s.authz.Require(ctx, restaurant.ID, userID, authz.EditRestaurant); err != nil {
+		return err
+	}
+
+	if !versionMatches(restaurant.Version, version) {
+		return apperr.PreconditionFailed(staleRestaurant)
+	}
+
+	// Empty opens_at / timezone keep the current values
+	if err := validate.Struct(req); err != nil {
+		return err
+	}
+
+	if err := s.restaurants.UpdateRestaurant(ctx, id, version, req); err != nil {
+		return staleErr(err, staleRestaurant)
+	}
+	return nil
 }
 
-func GetAllRestaurants(city string) ([]model.Restaurant, error) {
-	return repository.GetAllRestaurants(city)
+// PatchRestaurant applies a merge patch and returns the restaurant as it
+// now stands
+func (s *RestaurantService) PatchRestaurant(ctx context.Context, id string, version int, req *model.PatchRestaurantRequest, userID string) (*model.Restaurant, error) {
+	ctx, span := tracing.Start(ctx, "RestaurantService.PatchRestaurant")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, id)
+	if err != nil {
+		return nil, lookupErr(err, "restaurant not found")
+	}
+
+	if err := s.authz.Require(ctx, restaurant.ID, userID, authz.EditRestaurant); err != nil {
+		return nil, err
+	}
+
+	if !versionMatches(restaurant.Version, version) {
+		return nil, apperr.PreconditionFailed(staleRestaurant)
+	}
+
+	if err := s.restaurants.PatchRestaurant(ctx, id, version, req); err != nil {
+		return nil, staleErr(err, staleRestaurant)
+	}
+
+	restaurant, err = s.restaurants.GetRestaurantByID(ctx, id)
+	if err != nil {
+		return nil, lookupErr(err, "restaurant not found")
+	}
+	return restaurant, nil
 }
 
-func UpdateRestaurant(id string, req *model.UpdateRestaurantRequest, userID string) error {
-	restaurant, err := repository.GetRestaurantByID(id)
+// GetTrash lists what userID can restore: deleted restaurants where they
+// may delete the restaurant, and menu entries where they may edit the menu
+func (s *RestaurantService) GetTrash(ctx context.Context, userID string, retention time.Duration) (*model.Trash, error) {
+	ctx, span := tracing.Start(ctx, "RestaurantService.GetTrash")
+	defer span.End()
+
+	restaurantIDs, err := s.authz.RestaurantsWhere(ctx, userID, authz.DeleteRestaurant)
+	if err != nil {
+		return nil, err
+	}
+	menuRestaurantIDs, err := s.authz.RestaurantsWhere(ctx, userID, authz.EditMenu)
+	if err != nil {
+		return nil, err
+	}
+
+	trash, err := s.restaurants.GetTrash(ctx, restaurantIDs, menuRestaurantIDs, retention)
 	if err != nil {
-		return errors.New("restaurant not found")
+		return nil, storeErr(err, "failed to fetch trash")
 	}
+	trash.RetentionDays = int(retention.Hours() / 24)
+	return trash, nil
+}
+
+func (s *RestaurantService) RestoreRestaurant(ctx context.Context, id string, userID string, retention time.Duration) error {
+	ctx, span := tracing.Start(ctx, "RestaurantService.RestoreRestaurant")
+	defer span.End()
 
-	if restaurant.OwnerID != userID {
-		return errors.New("unauthorized: you don't own this restaurant")
+	restaurant, err := s.restaurants.GetDeletedRestaurantByID(ctx, id, retention)
+	if err != nil {
+		return lookupErr(err, "restaurant not found in trash")
 	}
 
-	if req.Name == "" || req.Address == "" || req.City == "" {
-		return errors.New("name, address and city are required")
+	if err := s.authz.Require(ctx, restaurant.ID, userID, authz.DeleteRestaurant); err != nil {
+		return err
 	}
 
-	return repository.UpdateRestaurant(id, req)
+	return s.restaurants.RestoreRestaurant(ctx, id)
+}
+
+// PurgeDeleted permanently removes trash older than the retention window.
+// Called periodically by the trash-purge worker.
+func (s *RestaurantService) PurgeDeleted(ctx context.Context, retention time.Duration) error {
+	ctx, span := tracing.Start(ctx, "RestaurantService.PurgeDeleted")
+	defer span.End()
+
+	count, err := s.restaurants.PurgeDeleted(ctx, retention)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		slog.InfoContext(ctx, "purged soft-deleted rows", "rows", count, "retention", retention.String())
+	}
+	return nil
 }
 
-func DeleteRestaurant(id string, userID string) error {
-	restaurant, err := repository.GetRestaurantByID(id)
+func (s *RestaurantService) DeleteRestaurant(ctx context.Context, id string, userID string) error {
+	ctx, span := tracing.Start(ctx, "RestaurantService.DeleteRestaurant")
+	defer span.End()
+
+	restaurant, err := s.restaurants.GetRestaurantByID(ctx, id)
 	if err != nil {
-		return errors.New("restaurant not found")
+		return lookupErr(err, "restaurant not found")
 	}
 
-	if restaurant.OwnerID != userID {
-		return errors.New("unauthorized: you don't own this restaurant")
+	if err := s.authz.Require(ctx, restaurant.ID, userID, authz.DeleteRestaurant); err != nil {
+		return err
 	}
 
-	return repository.DeleteRestaurant(id)
+	return s.restaurants.DeleteRestaurant(ctx, id)
 }
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"quickbite/internal/apperr"
+	"quickbite/internal/model"
+)
+
+func TestUpdateRestaurantRequiresIsActive(t *testing.T) {
+	f := newFixture(t)
+	restaurants := NewRestaurantService(f.stores.Restaurants, f.authz)
+	req := &model.UpdateRestaurantRequest{Name: "Test Kitchen", Address: "1 Test Street", City: "Pune"}
+
+	// Leaving is_active out used to switch the restaurant off
+	err := restaurants.UpdateRestaurant(f.ctx, f.restaurant.ID, 0, req, f.ownerID)
+	wantKind(t, err, apperr.KindValidation)
+
+	active := true
+	req.IsActive = &active
+	if err := restaurants.UpdateRestaurant(f.ctx, f.restaurant.ID, 0, req, f.ownerID); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	got, err := f.stores.Restaurants.GetRestaurantByID(f.ctx, f.restaurant.ID)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if !got.IsActive || got.City != "Pune" {
+		t.Errorf("restaurant = %+v, want it active in Pune", got)
+	}
+}
+
+func TestPatchRestaurantNullClears(t *testing.T) {
+	f := newFixture(t)
+	restaurants := NewRestaurantService(f.stores.Restaurants, f.authz)
+	patch := func(body string) (*model.Restaurant, error) {
+		var req model.PatchRestaurantRequest
+		if err := json.Unmarshal([]byte(body), &req); err != nil {
+			t.Fatalf("decode %s: %v", body, err)
+		}
+		return restaurants.PatchRestaurant(f.ctx, f.restaurant.ID, 0, &req, f.ownerID)
+	}
+
+	if _, err := patch(`{"description": "Home style", "image_url": "https://example.com/k.jpg"}`); err != nil {
+		t.Fatalf("set description: %v", err)
+	}
+	got, err := patch(`{"description": null, "image_url": null}`)
+	if err != nil {
+		t.Fatalf("clear description: %v", err)
+	}
+	if got.Description != "" || got.ImageURL != "" || got.Name != "Test Kitchen" {
+		t.Errorf("restaurant = %+v, want description and image cleared, name kept", got)
+	}
+
+	for _, body := range []string{`{"name": null}`, `{"is_active": null}`, `{"timezone": null}`} {
+		_, err := patch(body)
+		wantKind(t, err, apperr.KindValidation)
+	}
+}
+
+func TestTrashFollowsMembership(t *testing.T) {
+	f := newFixture(t)
+	restaurants := NewRestaurantService(f.stores.Restaurants, f.authz)
+	const retention = 30 * 24 * time.Hour
+
+	item := f.item("Thali", nil)
+	if err := f.menu.DeleteMenuItem(f.ctx, item.ID, f.ownerID); err != nil {
+		t.Fatalf("delete item: %v", err)
+	}
+	other, err := restaurants.CreateRestaurant(f.ctx, &model.CreateRestaurantRequest{Name: "Second", Address: "2 Test Street", City: "Pune"}, f.ownerID)
+	if err != nil {
+		t.Fatalf("create restaurant: %v", err)
+	}
+	if err := restaurants.DeleteRestaurant(f.ctx, other.ID, f.ownerID); err != nil {
+		t.Fatalf("delete restaurant: %v", err)
+	}
+
+	f.addMember("manager-1", "manager")
+	f.addMember("viewer-1", "viewer")
+
+	tests := []struct {
+		userID      string
+		restaurants int
+		items       int
+	}{
+		{f.ownerID, 1, 1},
+		{"manager-1", 0, 1}, // can edit the menu, not delete the restaurant
+		{"viewer-1", 0, 0},
+		{"stranger", 0, 0},
+	}
+	for _, tt := range tests {
+		trash, err := restaurants.GetTrash(f.ctx, tt.userID, retention)
+		if err != nil {
+			t.Fatalf("%s: GetTrash: %v", tt.userID, err)
+		}
+		if len(trash.Restaurants) != tt.restaurants || len(trash.MenuItems) != tt.items {
+			t.Errorf("%s sees %d restaurants and %d items, want %d and %d",
+				tt.userID, len(trash.Restaurants), len(trash.MenuItems), tt.restaurants, tt.items)
+		}
+	}
+
+	err = f.menu.RestoreMenuItem(f.ctx, item.ID, "viewer-1", retention)
+	wantKind(t, err, apperr.KindForbidden)
+	if err := f.menu.RestoreMenuItem(f.ctx, item.ID, "manager-1", retention); err != nil {
+		t.Fatalf("manager restore: %v", err)
+	}
+
+	err = restaurants.RestoreRestaurant(f.ctx, other.ID, "manager-1", retention)
+	wantKind(t, err, apperr.KindForbidden)
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"quickbite/config"
+	"quickbite/internal/apperr"
+	"quickbite/internal/model"
+	"quickbite/internal/repository"
+	"quickbite/internal/repository/memory"
+)
+
+// fixture wires the services onto the in-memory stores, with one
+// restaurant owned by ownerID and one category on its menu
+type fixture struct {
+	t      *testing.T
+	ctx    context.Context
+	stores *repository.Stores
+
+	menu   *MenuService
+	orders *OrderService
+	authz  *Authorizer
+
+	ownerID    string
+	restaurant *model.Restaurant
+	category   *model.MenuCategory
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	stores := memory.NewStores()
+	authorizer := NewAuthorizer(stores.Members)
+	cfg := &config.Config{DeliveryEstimate: 30 * time.Minute, OrderAcceptTimeout: 10 * time.Minute}
+
+	f := &fixture{
+		t:       t,
+		ctx:     context.Background(),
+		stores:  stores,
+		menu:    NewMenuService(stores.Restaurants, stores.Menu, authorizer),
+		orders:  NewOrderService(stores.Restaurants, stores.Menu, stores.Orders, authorizer, nil, cfg),
+		authz:   authorizer,
+		ownerID: "owner-1",
+	}
+
+	// UTC and midnight opening keep schedules and stock resets off the wall clock
+	f.restaurant = &model.Restaurant{OwnerID: f.ownerID, Name: "Test Kitchen", IsActive: true, OpensAt: "00:00", Timezone: "UTC"}
+	if err := stores.Restaurants.CreateRestaurant(f.ctx, f.restaurant); err != nil {
+		t.Fatalf("create restaurant: %v", err)
+	}
+
+	category, err := f.menu.CreateCategory(f.ctx, &model.CreateCategoryRequest{
+		RestaurantID: f.restaurant.ID,
+		Name:         "Mains",
+	}, f.ownerID)
+	if err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+	f.category = category
+	return f
+}
+
+// item adds an item to the fixture's category; a nil dailyStock leaves its
+// stock untracked
+func (f *fixture) item(name string, dailyStock *int) *model.MenuItem {
+	f.t.Helper()
+
+	item, err := f.menu.CreateMenuItem(f.ctx, &model.CreateMenuItemRequest{
+		CategoryID: f.category.ID,
+		Name:       name,
+		Price:      100,
+		DailyStock: dailyStock,
+	}, f.ownerID)
+	if err != nil {
+		f.t.Fatalf("create item %q: %v", name, err)
+	}
+	return item
+}
+
+// reload reads an item back from the store
+func (f *fixture) reload(id string) *model.MenuItem {
+	f.t.Helper()
+
+	item, err := f.stores.Menu.GetMenuItemByID(f.ctx, id)
+	if err != nil {
+		f.t.Fatalf("reload item: %v", err)
+	}
+	return item
+}
+
+// order places an order for qty of item as customerID
+func (f *fixture) order(customerID string, item *model.MenuItem, qty int) (*model.OrderWithDetails, error) {
+	return f.orders.CreateOrder(f.ctx, &model.CreateOrderRequest{
+		RestaurantID:    f.restaurant.ID,
+		Items:           []model.OrderItemInput{{MenuItemID: item.ID, Quantity: qty}},
+		DeliveryAddress: "1 Test Street",
+		PaymentMethod:   "cash",
+	}, customerID)
+}
+
+// addMember gives userID role at the fixture's restaurant, the way an
+// accepted invitation does
+func (f *fixture) addMember(userID, role string) {
+	f.t.Helper()
+
+	inv := &model.Invitation{
+		RestaurantID: f.restaurant.ID,
+		Email:        userID + "@example.com",
+		Role:         role,
+		TokenHash:    "token-" + userID,
+		InvitedBy:    f.ownerID,
+		ExpiresAt:    time.Now().Add(time.Hour),
+	}
+	if err := f.stores.Members.SaveInvitation(f.ctx, inv); err != nil {
+		f.t.Fatalf("invite %s: %v", userID, err)
+	}
+	if err := f.stores.Members.AcceptInvitation(f.ctx, inv.ID, userID); err != nil {
+		f.t.Fatalf("accept invitation for %s: %v", userID, err)
+	}
+}
+
+// wantKind fails unless err is an apperr.Error of kind
+func wantKind(t *testing.T, err error, kind apperr.Kind) {
+	t.Helper()
+
+	var appErr *apperr.Error
+	if !errors.As(err, &appErr) {
+		t.Fatalf("got error %v, want a %s error", err, kind)
+	}
+	if appErr.Kind != kind {
+		t.Fatalf("got %s error %q, want %s", appErr.Kind, appErr.Message, kind)
+	}
+}
+
+func intPtr(n int) *int {
+	return &n
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"quickbite/config"
+	"quickbite/internal/mail"
+	"quickbite/internal/payment"
+	"quickbite/internal/repository"
+)
+
+// Services bundles one of every service, built on the same stores and
+// sharing one Authorizer. main builds them once for both the router and
+// the background jobs.
+type Services struct {
+	Authorizer  *Authorizer
+	Auth        *AuthService
+	Restaurants *RestaurantService
+	Menu        *MenuService
+	Orders      *OrderService
+	Members     *MemberService
+	Brands      *BrandService
+	Dispatch    *DispatchService
+	Kitchen     *KitchenService
+	Refunds     *RefundService
+}
+
+// NewServices builds every service. events is optional; a nil mailer logs
+// emails instead of sending them, and a nil refunder logs refunds.
+func NewServices(stores *repository.Stores, events OrderEvents, mailer mail.Mailer, refunder payment.Refunder, cfg *config.Config) *Services {
+	if mailer == nil {
+		mailer = mail.LogMailer{}
+	}
+	if refunder == nil {
+		refunder = payment.LogRefunder{}
+	}
+
+	authorizer := NewAuthorizer(stores.Members)
+	return &Services{
+		Authorizer:  authorizer,
+		Auth:        NewAuthService(stores.Users, stores.LoginAttempts, cfg),
+		Restaurants: NewRestaurantService(stores.Restaurants, authorizer),
+		Menu:        NewMenuService(stores.Restaurants, stores.Menu, authorizer),
+		Orders:      NewOrderService(stores.Restaurants, stores.Menu, stores.Orders, authorizer, events, cfg),
+		Members:     NewMemberService(stores.Restaurants, stores.Members, stores.Users, authorizer, mailer, cfg),
+		Brands:      NewBrandService(stores.Brands, stores.Restaurants, authorizer),
+		Dispatch:    NewDispatchService(stores.Dispatch, stores.Orders, stores.Restaurants, authorizer, events, cfg),
+		Kitchen:     NewKitchenService(stores.Restaurants, stores.Orders, stores.Kitchen, authorizer, events),
+		Refunds:     NewRefundService(stores.Orders, refunder),
+	}
+}
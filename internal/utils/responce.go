@@ -11,6 +11,36 @@ func WriteJSON(w http.ResponseWriter, status int, data any) {
 	json.NewEncoder(w).Encode(data)
 }
 
+// Problem is an RFC 7807 problem details body. Type stays about:blank, so
+// Title is the status text and Detail says what went wrong this time.
+type Problem struct {
+	Type      string         `json:"type"`
+	Title     string         `json:"title"`
+	Status    int            `json:"status"`
+	Detail    string         `json:"detail,omitempty"`
+	Instance  string         `json:"instance,omitempty"`
+	RequestID string         `json:"request_id,omitempty"`
+	Errors    []InvalidParam `json:"errors,omitempty"`
+}
+
+// InvalidParam is one entry of a validation problem's errors list
+type InvalidParam struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func WriteProblem(w http.ResponseWriter, p Problem) {
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
 func WriteError(w http.ResponseWriter, status int, message string) {
-	WriteJSON(w, status, map[string]string{"error": message})
+	WriteProblem(w, Problem{Status: status, Detail: message})
 }
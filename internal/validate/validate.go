@@ -0,0 +1,481 @@
+// Package validate checks request structs against their `validate` tags
+// and reports every failing field at once.
+//
+// Rules are comma separated and checked in order; each field reports only
+// its first failure. Nil pointers and empty strings skip every rule except
+// required, so optional fields only need to be valid when present.
+//
+//	required     non-zero; strings must have non-space characters, and a non-nil
+//	             pointer to a number or bool counts as set even if zero. Goes first.
+//	notblank     like required, but a nil pointer or absent patch field passes. Goes first.
+//	nonnull      a patch field may be left out but not sent as null
+//	min=N max=N  length for strings (in characters) and slices, value for numbers
+//	gt=N         number strictly greater than N
+//	oneof=a b    one of the space separated values
+//	email        a bare address such as name@example.com
+//	phone        7 to 15 digits, optional leading +; spaces, dashes and parentheses are ignored
+//	uuid         canonical hyphenated UUID
+//	hhmm         24-hour "HH:MM"
+//	timezone     IANA name such as Asia/Kolkata
+//	password     8 to 72 bytes with at least one letter and one digit
+//	dive         apply the remaining rules to each element of a slice
+//
+// Field types with Unwrap and Null methods, such as model.Optional, are
+// checked through the value they hold, and a null counts as nil; notblank
+// rejects it too.
+//
+// Nested structs, and struct elements reached through dive, are checked
+// too. Field paths use the json names, e.g. items[2].quantity.
+//
+// A type's tags are checked once, by Register or on its first Struct call:
+// an unknown rule, a missing number or a rule the field's kind can't take
+// is an error, never a panic.
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"quickbite/internal/apperr"
+)
+
+var (
+	uuidPattern  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	phonePattern = regexp.MustCompile(`^\+?[0-9]{7,15}$`)
+	phoneNoise   = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "")
+)
+
+// bcrypt ignores everything past 72 bytes
+const maxPasswordBytes = 72
+
+type rule struct {
+	name  string
+	param string
+	limit float64 // min, max and gt
+}
+
+// wrapper is a field type holding the value to check, such as a merge
+// patch field that can be absent or null
+type wrapper interface {
+	Unwrap() any
+	Null() bool
+}
+
+var wrapperType = reflect.TypeOf((*wrapper)(nil)).Elem()
+
+var (
+	ruleCache sync.Map // raw tag -> []rule, for tags that parsed
+	typeCache sync.Map // reflect.Type -> error from checking its tags
+)
+
+// Register checks the tags of each struct type in types, given as values
+// or nil pointers, and of every struct they contain. Call it at startup
+// so a bad tag stops the server instead of failing requests.
+func Register(types ...any) error {
+	var errs []error
+	for _, t := range types {
+		errs = append(errs, register(reflect.TypeOf(t)))
+	}
+	return errors.Join(errs...)
+}
+
+func register(t reflect.Type) error {
+	if t == nil {
+		return nil
+	}
+	if err, ok := typeCache.Load(t); ok {
+		return toError(err)
+	}
+	err := checkType(t, map[reflect.Type]bool{})
+	typeCache.Store(t, err)
+	return err
+}
+
+// Struct validates v, a struct or pointer to one. It returns nil or an
+// apperr validation error listing every invalid field; a type whose tags
+// don't check out is an internal error.
+func Struct(v any) error {
+	if err := register(reflect.TypeOf(v)); err != nil {
+		return apperr.Internal("invalid validate tag", err)
+	}
+
+	var errs []apperr.FieldError
+	walkStruct(reflect.ValueOf(v), "", &errs)
+
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return apperr.Validation(errs[0].Field+" "+errs[0].Message, errs...)
+	default:
+		return apperr.Validation("request has invalid fields", errs...)
+	}
+}
+
+// UUID reports whether s is a canonical hyphenated UUID, for ids that
+// don't arrive in a request struct, such as path parameters
+func UUID(s string) bool {
+	return uuidPattern.MatchString(s)
+}
+
+func walkStruct(v reflect.Value, prefix string, errs *[]apperr.FieldError) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := jsonName(field)
+		if name == "-" {
+			continue
+		}
+		// Embedded structs share their parent's level, as in encoding/json
+		path := prefix
+		if !field.Anonymous {
+			path = joinPath(prefix, name)
+		}
+
+		checkValue(v.Field(i), path, cachedRules(field.Tag.Get("validate")), errs)
+	}
+}
+
+func checkValue(v reflect.Value, path string, rules []rule, errs *[]apperr.FieldError) {
+	fail := func(message string) {
+		*errs = append(*errs, apperr.FieldError{Field: path, Message: message})
+	}
+
+	required := len(rules) > 0 && rules[0].name == "required"
+	notBlank := len(rules) > 0 && rules[0].name == "notblank"
+	if required || notBlank {
+		rules = rules[1:]
+	}
+
+	if w, ok := v.Interface().(wrapper); ok {
+		if w.Null() && (notBlank || hasRule(rules, "nonnull")) {
+			fail("must not be null")
+			return
+		}
+		v = reflect.ValueOf(w.Unwrap())
+	}
+
+	viaPointer := false
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			if required {
+				fail("is required")
+			}
+			return
+		}
+		v = v.Elem()
+		viaPointer = true
+	}
+
+	// A pointer is how a request says "false" or "0" on purpose
+	if viaPointer && v.Kind() != reflect.String && v.Kind() != reflect.Slice && v.Kind() != reflect.Map {
+		required = false
+	}
+
+	if required && isBlank(v) {
+		fail("is required")
+		return
+	}
+	if notBlank && isBlank(v) {
+		fail("must not be blank")
+		return
+	}
+	if v.Kind() == reflect.String && v.Len() == 0 {
+		return
+	}
+
+	for i, r := range rules {
+		if r.name == "dive" {
+			for j := 0; j < v.Len(); j++ {
+				checkValue(v.Index(j), fmt.Sprintf("%s[%d]", path, j), rules[i+1:], errs)
+			}
+			return
+		}
+		if message := check(v, r); message != "" {
+			fail(message)
+			return
+		}
+	}
+
+	if v.Kind() == reflect.Struct && v.Type() != reflect.TypeOf(time.Time{}) {
+		walkStruct(v, path, errs)
+	}
+}
+
+// check applies one rule and returns what's wrong, or "" if it passes
+func check(v reflect.Value, r rule) string {
+	switch r.name {
+	case "min", "max":
+		size, unit := measure(v)
+		if r.name == "min" && size < r.limit {
+			return fmt.Sprintf("must be at least %s%s", r.param, unit)
+		}
+		if r.name == "max" && size > r.limit {
+			return fmt.Sprintf("must be at most %s%s", r.param, unit)
+		}
+	case "gt":
+		if size, _ := measure(v); size <= r.limit {
+			return "must be greater than " + r.param
+		}
+	case "oneof":
+		options := strings.Fields(r.param)
+		for _, option := range options {
+			if fmt.Sprint(v.Interface()) == option {
+				return ""
+			}
+		}
+		return "must be one of: " + strings.Join(options, ", ")
+	case "email":
+		addr, err := mail.ParseAddress(v.String())
+		if err != nil || addr.Address != v.String() {
+			return "must be a valid email address"
+		}
+	case "phone":
+		if !phonePattern.MatchString(phoneNoise.Replace(v.String())) {
+			return "must be a valid phone number"
+		}
+	case "uuid":
+		if !uuidPattern.MatchString(v.String()) {
+			return "must be a valid UUID"
+		}
+	case "hhmm":
+		if _, err := time.Parse("15:04", v.String()); err != nil {
+			return "must be in HH:MM format"
+		}
+	case "timezone":
+		if _, err := time.LoadLocation(v.String()); err != nil || v.String() == "Local" {
+			return "must be a valid IANA time zone"
+		}
+	case "password":
+		if !strongPassword(v.String()) {
+			return "must be 8 to 72 characters and contain a letter and a digit"
+		}
+	}
+	return ""
+}
+
+// measure returns the number min/max/gt compare against and the unit to
+// name in the message. Register only lets those rules onto measurable
+// kinds; anything else measures as zero.
+func measure(v reflect.Value) (float64, string) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), ""
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), ""
+	}
+	return 0, ""
+}
+
+func measurable(k reflect.Kind) bool {
+	switch k {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func isBlank(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+func strongPassword(s string) bool {
+	if len(s) < 8 || len(s) > maxPasswordBytes {
+		return false
+	}
+	var letter, digit bool
+	for _, c := range s {
+		letter = letter || unicode.IsLetter(c)
+		digit = digit || unicode.IsDigit(c)
+	}
+	return letter && digit
+}
+
+// checkType checks the tags of struct type t and of the structs its
+// fields hold, directly or through pointers and slices
+func checkType(t reflect.Type, seen map[reflect.Type]bool) error {
+	t = deref(t)
+	if t.Kind() != reflect.Struct || t == reflect.TypeOf(time.Time{}) || seen[t] {
+		return nil
+	}
+	seen[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || jsonName(field) == "-" {
+			continue
+		}
+		rules, err := parseRules(field.Tag.Get("validate"))
+		if err == nil {
+			err = checkKinds(field.Type, rules)
+		}
+		if err != nil {
+			return fmt.Errorf("validate: %s.%s: %w", t.Name(), field.Name, err)
+		}
+
+		inner := deref(unwrapType(field.Type))
+		for inner.Kind() == reflect.Slice || inner.Kind() == reflect.Array {
+			inner = deref(unwrapType(inner.Elem()))
+		}
+		if err := checkType(inner, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkKinds reports a rule that can't apply to a field of type t
+func checkKinds(t reflect.Type, rules []rule) error {
+	if hasRule(rules, "nonnull") && !t.Implements(wrapperType) {
+		return fmt.Errorf("nonnull needs a patch field, got %s", t)
+	}
+	for _, r := range rules {
+		t = deref(unwrapType(t))
+		if t.Kind() == reflect.Interface {
+			return nil // only known per value
+		}
+		switch r.name {
+		case "dive":
+			if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+				return fmt.Errorf("dive needs a slice, got %s", t)
+			}
+			t = t.Elem()
+		case "min", "max", "gt":
+			if !measurable(t.Kind()) {
+				return fmt.Errorf("%s can't measure %s", r.name, t)
+			}
+		case "email", "phone", "uuid", "hhmm", "timezone", "password":
+			if t.Kind() != reflect.String {
+				return fmt.Errorf("%s needs a string, got %s", r.name, t)
+			}
+		}
+	}
+	return nil
+}
+
+func parseRules(tag string) ([]rule, error) {
+	if tag == "" {
+		return nil, nil
+	}
+	if cached, ok := ruleCache.Load(tag); ok {
+		return cached.([]rule), nil
+	}
+
+	var rules []rule
+	for _, part := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(part, "=")
+		r := rule{name: name, param: param}
+		switch name {
+		case "required", "notblank", "nonnull", "email", "phone", "uuid", "hhmm", "timezone", "password", "dive":
+		case "min", "max", "gt":
+			limit, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s needs a number, got %q", name, param)
+			}
+			r.limit = limit
+		case "oneof":
+			if strings.TrimSpace(param) == "" {
+				return nil, errors.New("oneof needs at least one value")
+			}
+		default:
+			return nil, fmt.Errorf("unknown rule %q", name)
+		}
+		if (name == "required" || name == "notblank") && len(rules) > 0 && rules[len(rules)-1].name != "dive" {
+			return nil, fmt.Errorf("%s must come first in %q", name, tag)
+		}
+		rules = append(rules, r)
+	}
+
+	ruleCache.Store(tag, rules)
+	return rules, nil
+}
+
+// cachedRules returns the rules of a tag register has already parsed
+func cachedRules(tag string) []rule {
+	if cached, ok := ruleCache.Load(tag); ok {
+		return cached.([]rule)
+	}
+	return nil
+}
+
+func hasRule(rules []rule, name string) bool {
+	for _, r := range rules {
+		if r.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// unwrapType is the type a wrapper field holds, or t itself
+func unwrapType(t reflect.Type) reflect.Type {
+	if t.Implements(wrapperType) {
+		return reflect.TypeOf(reflect.Zero(t).Interface().(wrapper).Unwrap())
+	}
+	return t
+}
+
+func deref(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+func toError(v any) error {
+	err, _ := v.(error)
+	return err
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
@@ -0,0 +1,227 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+
+	"quickbite/internal/apperr"
+	"quickbite/internal/model"
+)
+
+func intPtr(n int) *int { return &n }
+
+func boolPtr(b bool) *bool { return &b }
+
+// firstFailure validates v and returns "field message" for its first
+// invalid field, or "" if it passes
+func firstFailure(t *testing.T, v any) string {
+	t.Helper()
+	err := Struct(v)
+	if err == nil {
+		return ""
+	}
+	e, ok := apperr.As(err)
+	if !ok || e.Kind != apperr.KindValidation {
+		t.Fatalf("Struct(%+v) = %v, want a validation error", v, err)
+	}
+	return e.Fields[0].Field + " " + e.Fields[0].Message
+}
+
+func TestRules(t *testing.T) {
+	type item struct {
+		Quantity int `json:"quantity" validate:"required,min=1"`
+	}
+
+	tests := []struct {
+		name string
+		v    any
+		want string
+	}{
+		{"required set", struct {
+			V string `json:"v" validate:"required"`
+		}{"x"}, ""},
+		{"required blank", struct {
+			V string `json:"v" validate:"required"`
+		}{"  "}, "v is required"},
+		{"required nil", struct {
+			V *int `json:"v" validate:"required"`
+		}{}, "v is required"},
+		{"required pointer to zero", struct {
+			V *bool `json:"v" validate:"required"`
+		}{boolPtr(false)}, ""},
+		{"notblank nil", struct {
+			V *string `json:"v" validate:"notblank"`
+		}{}, ""},
+		{"notblank blank", struct {
+			V *string `json:"v" validate:"notblank"`
+		}{new(string)}, "v must not be blank"},
+
+		{"min string", struct {
+			V string `json:"v" validate:"min=3"`
+		}{"ab"}, "v must be at least 3 characters"},
+		{"min counts characters", struct {
+			V string `json:"v" validate:"min=3"`
+		}{"née"}, ""},
+		{"max slice", struct {
+			V []int `json:"v" validate:"max=2"`
+		}{[]int{1, 2, 3}}, "v must be at most 2 items"},
+		{"min number", struct {
+			V *int `json:"v" validate:"min=0"`
+		}{intPtr(-1)}, "v must be at least 0"},
+		{"empty string skips min", struct {
+			V string `json:"v" validate:"min=3"`
+		}{""}, ""},
+		{"gt pass", struct {
+			V float64 `json:"v" validate:"gt=0"`
+		}{0.5}, ""},
+		{"gt equal", struct {
+			V float64 `json:"v" validate:"gt=0"`
+		}{0}, "v must be greater than 0"},
+
+		{"oneof pass", struct {
+			V string `json:"v" validate:"oneof=cash card"`
+		}{"card"}, ""},
+		{"oneof fail", struct {
+			V string `json:"v" validate:"oneof=cash card"`
+		}{"upi"}, "v must be one of: cash, card"},
+		{"oneof number", struct {
+			V int `json:"v" validate:"oneof=1 2"`
+		}{3}, "v must be one of: 1, 2"},
+
+		{"email pass", struct {
+			V string `json:"v" validate:"email"`
+		}{"a@example.com"}, ""},
+		{"email with name", struct {
+			V string `json:"v" validate:"email"`
+		}{"A <a@example.com>"}, "v must be a valid email address"},
+		{"phone pass", struct {
+			V string `json:"v" validate:"phone"`
+		}{"+91 (98) 765-43210"}, ""},
+		{"phone short", struct {
+			V string `json:"v" validate:"phone"`
+		}{"12345"}, "v must be a valid phone number"},
+		{"uuid pass", struct {
+			V string `json:"v" validate:"uuid"`
+		}{"123e4567-e89b-12d3-a456-426614174000"}, ""},
+		{"uuid fail", struct {
+			V string `json:"v" validate:"uuid"`
+		}{"123e4567e89b12d3a456426614174000"}, "v must be a valid UUID"},
+		{"hhmm pass", struct {
+			V string `json:"v" validate:"hhmm"`
+		}{"23:59"}, ""},
+		{"hhmm fail", struct {
+			V string `json:"v" validate:"hhmm"`
+		}{"24:00"}, "v must be in HH:MM format"},
+		{"timezone pass", struct {
+			V string `json:"v" validate:"timezone"`
+		}{"Asia/Kolkata"}, ""},
+		{"timezone local", struct {
+			V string `json:"v" validate:"timezone"`
+		}{"Local"}, "v must be a valid IANA time zone"},
+		{"password pass", struct {
+			V string `json:"v" validate:"password"`
+		}{"hunter22"}, ""},
+		{"password no digit", struct {
+			V string `json:"v" validate:"password"`
+		}{"hunterhunter"}, "v must be 8 to 72 characters and contain a letter and a digit"},
+		{"password too long", struct {
+			V string `json:"v" validate:"password"`
+		}{strings.Repeat("a1", 37)}, "v must be 8 to 72 characters and contain a letter and a digit"},
+
+		{"patch field absent", struct {
+			V model.Optional[string] `json:"v" validate:"notblank,max=3"`
+		}{}, ""},
+		{"patch field checked when set", struct {
+			V model.Optional[string] `json:"v" validate:"notblank,max=3"`
+		}{model.Set("abcd")}, "v must be at most 3 characters"},
+		{"patch field null clears", struct {
+			V model.Optional[string] `json:"v" validate:"max=3"`
+		}{model.Optional[string]{Present: true}}, ""},
+		{"notblank patch field null", struct {
+			V model.Optional[string] `json:"v" validate:"notblank,max=3"`
+		}{model.Optional[string]{Present: true}}, "v must not be null"},
+		{"nonnull null", struct {
+			V model.Optional[bool] `json:"v" validate:"nonnull"`
+		}{model.Optional[bool]{Present: true}}, "v must not be null"},
+		{"nonnull false", struct {
+			V model.Optional[bool] `json:"v" validate:"nonnull"`
+		}{model.Set(false)}, ""},
+
+		{"dive", struct {
+			V []string `json:"v" validate:"dive,uuid"`
+		}{[]string{"123e4567-e89b-12d3-a456-426614174000", "x"}}, "v[1] must be a valid UUID"},
+		{"dive into structs", struct {
+			V []item `json:"items" validate:"required,dive"`
+		}{[]item{{1}, {0}}}, "items[1].quantity is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := firstFailure(t, tt.v); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructReportsEveryField(t *testing.T) {
+	err := Struct(&model.RegisterRequest{Email: "nope"})
+	e, ok := apperr.As(err)
+	if !ok || len(e.Fields) < 2 {
+		t.Fatalf("Struct() = %v, want several invalid fields", err)
+	}
+}
+
+func TestBadTagsAreErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+		want string
+	}{
+		{"unknown rule", struct {
+			V string `validate:"shiny"`
+		}{}, `unknown rule "shiny"`},
+		{"bad number", struct {
+			V string `validate:"min=few"`
+		}{}, `min needs a number`},
+		{"required not first", struct {
+			V string `validate:"uuid,required"`
+		}{}, "required must come first"},
+		{"min on a bool", struct {
+			V bool `validate:"min=1"`
+		}{}, "min can't measure bool"},
+		{"uuid on an int", struct {
+			V *int `validate:"uuid"`
+		}{}, "uuid needs a string"},
+		{"dive on a string", struct {
+			V string `validate:"dive"`
+		}{}, "dive needs a slice"},
+		{"nonnull on a plain field", struct {
+			V *bool `validate:"nonnull"`
+		}{}, "nonnull needs a patch field"},
+		{"gt on a patch bool", struct {
+			V model.Optional[bool] `validate:"gt=0"`
+		}{}, "gt can't measure bool"},
+		{"nested", struct {
+			V []struct {
+				W int `validate:"email"`
+			}
+		}{}, "email needs a string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Register(tt.v)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Register() = %v, want it to mention %q", err, tt.want)
+			}
+			if err := Struct(tt.v); err == nil || apperr.KindOf(err) != apperr.KindInternal {
+				t.Errorf("Struct() = %v, want an internal error", err)
+			}
+		})
+	}
+}
+
+func TestRequestTags(t *testing.T) {
+	if err := Register(model.Requests...); err != nil {
+		t.Fatal(err)
+	}
+}
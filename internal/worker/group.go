@@ -0,0 +1,113 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Group runs workers that can be stopped one by one at shutdown
+type Group struct {
+	workers []*running
+}
+
+type running struct {
+	name     string
+	interval time.Duration
+	cancel   context.CancelFunc
+	done     chan struct{}
+
+	mu       sync.Mutex
+	lastBeat time.Time // end of the last run, or the start time before the first
+	lastErr  error
+}
+
+func NewGroup() *Group {
+	return &Group{}
+}
+
+// Go starts task under Run. Not safe for concurrent use; start workers from main.
+func (g *Group) Go(name string, interval time.Duration, task func(ctx context.Context) error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &running{
+		name:     name,
+		interval: interval,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		lastBeat: time.Now(),
+	}
+	g.workers = append(g.workers, w)
+
+	go func() {
+		defer close(w.done)
+		Run(ctx, name, interval, func(ctx context.Context) error {
+			err := task(ctx)
+			w.beat(err)
+			return err
+		})
+	}()
+}
+
+func (w *running) beat(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.lastBeat = time.Now()
+	w.lastErr = err
+}
+
+// Names lists the workers in start order
+func (g *Group) Names() []string {
+	names := make([]string, len(g.workers))
+	for i, w := range g.workers {
+		names[i] = w.name
+	}
+	return names
+}
+
+// HeartbeatCheck fails when the named worker has missed two runs in a row
+// or its last run returned an error
+func (g *Group) HeartbeatCheck(name string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		for _, w := range g.workers {
+			if w.name == name {
+				return w.check()
+			}
+		}
+		return fmt.Errorf("worker %s is not running", name)
+	}
+}
+
+func (w *running) check() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if since := time.Since(w.lastBeat); since > 2*w.interval {
+		return fmt.Errorf("no heartbeat for %s (runs every %s)", since.Round(time.Second), w.interval)
+	}
+	if w.lastErr != nil {
+		return fmt.Errorf("last run failed: %w", w.lastErr)
+	}
+	return nil
+}
+
+// Stop cancels workers in reverse start order, waiting for each to finish
+// its current run before moving on. If ctx expires first, the remaining
+// workers are cancelled without waiting.
+func (g *Group) Stop(ctx context.Context) error {
+	for i := len(g.workers) - 1; i >= 0; i-- {
+		w := g.workers[i]
+		w.cancel()
+
+		select {
+		case <-w.done:
+		case <-ctx.Done():
+			for _, rest := range g.workers[:i] {
+				rest.cancel()
+			}
+			return ctx.Err()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Run calls task every interval until ctx is cancelled.
+// The first run happens immediately so a restart doesn't wait a full interval.
+// Each run gets at most one interval, so a stuck run can't block the next.
+func Run(ctx context.Context, name string, interval time.Duration, task func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	slog.Info("worker started", "worker", name, "interval", interval.String())
+
+	for {
+		runCtx, cancel := context.WithTimeout(ctx, interval)
+		if err := task(runCtx); err != nil {
+			slog.Error("worker run failed", "worker", name, "error", err)
+		}
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			slog.Info("worker stopped", "worker", name)
+			return
+		case <-ticker.C:
+		}
+	}
+}